@@ -0,0 +1,318 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "log/slog"
+    "os"
+    "strings"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/config"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/index"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/vault"
+)
+
+// newCommand creates the flag set of a subcommand with all configuration
+// flags registered on it.
+func newCommand(name, synopsis string) (*flag.FlagSet, *config.Flags) {
+    fs := flag.NewFlagSet(name, flag.ExitOnError)
+    fs.Usage = func() {
+        fmt.Fprintf(fs.Output(), "Usage: obsidian-search-mcp %s\n\nFlags:\n", synopsis)
+        fs.PrintDefaults()
+    }
+    return fs, config.RegisterFlags(fs)
+}
+
+// loadConfig parses args, loads the layered configuration and sets up
+// logging. The returned log file, if any, must be closed by the caller.
+// Commands other than serve need at least one vault to work on.
+func loadConfig(fs *flag.FlagSet, flags *config.Flags, args []string) (*config.Config, io.Closer) {
+    fs.Parse(args)
+    
+    cfg, err := config.Load(flags)
+    if err != nil {
+        fatal("Failed to load config", "err", err)
+    }
+    if len(cfg.VaultList()) == 0 {
+        fatal("OBSIDIAN_VAULT_PATH or OBSIDIAN_VAULTS environment variable must be set")
+    }
+    logFile, err := setupLogging(cfg.Logging)
+    if err != nil {
+        fatal("Failed to set up logging", "err", err)
+    }
+    return cfg, logFile
+}
+
+// selectVaults resolves the -name flag of a subcommand.
+func selectVaults(vaults *vault.Manager, name string) []*vault.Vault {
+    selected, err := vaults.Select(name)
+    if err != nil {
+        fatal(err.Error())
+    }
+    return selected
+}
+
+func writeJSON(w io.Writer, v interface{}) {
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    if err := enc.Encode(v); err != nil {
+        fatal("Failed to write output", "err", err)
+    }
+}
+
+// runIndex brings the index of each vault up to date, or rebuilds it from
+// scratch with -rebuild.
+func runIndex(args []string) int {
+    fs, flags := newCommand("index", "index [flags]")
+    rebuild := fs.Bool("rebuild", false, "discard the index and index every file again")
+    name := fs.String("name", "", "only index the vault with this name")
+    cfg, logFile := loadConfig(fs, flags, args)
+    if logFile != nil {
+        defer logFile.Close()
+    }
+    
+    vaults := vault.Open(cfg)
+    defer vaults.Close()
+    
+    status := 0
+    for _, v := range selectVaults(vaults, *name) {
+        var err error
+        if *rebuild {
+            err = vaults.Reindex(v)
+        } else {
+            err = vaults.Index(v)
+        }
+        if err != nil {
+            fmt.Printf("%s: failed: %v\n", v.Name, err)
+            status = 1
+            continue
+        }
+        fmt.Printf("%s: %d files indexed\n", v.Name, v.Stats().IndexedFiles)
+    }
+    return status
+}
+
+// runSearch queries the existing index without indexing first.
+func runSearch(args []string) int {
+    fs, flags := newCommand("search", "search [flags] <query>")
+    limit := fs.Int("limit", 0, "maximum number of results (default from configuration)")
+    offset := fs.Int("offset", 0, "number of results to skip")
+    name := fs.String("name", "", "only search the vault with this name")
+    asJSON := fs.Bool("json", false, "print results as JSON")
+    fuzzy := fs.Bool("fuzzy", false, "also match words with typos")
+    folder := fs.String("folder", "", "only search notes below this vault-relative folder")
+    withSyntaxHelp(fs)
+    cfg, logFile := loadConfig(fs, flags, args)
+    if logFile != nil {
+        defer logFile.Close()
+    }
+    
+    query := strings.Join(fs.Args(), " ")
+    if query == "" {
+        fs.Usage()
+        return 2
+    }
+    if *limit <= 0 {
+        *limit = cfg.Search.DefaultLimit
+    }
+    if *limit > cfg.Search.MaxLimit {
+        *limit = cfg.Search.MaxLimit
+    }
+    
+    vaults := vault.Open(cfg)
+    defer vaults.Close()
+    
+    opts := index.SearchOptions{Folder: *folder}
+    if *fuzzy {
+        opts.Fuzzy = &index.FuzzyOptions{
+            Distance:      cfg.Search.Fuzzy.Distance,
+            PrefixLength:  cfg.Search.Fuzzy.PrefixLength,
+            MaxExpansions: cfg.Search.Fuzzy.MaxExpansions,
+        }
+    }
+    
+    out, err := vaults.Search(*name, query, vault.Page{Offset: max(*offset, 0), Limit: *limit}, opts)
+    if err != nil {
+        slog.Error(err.Error())
+        return 1
+    }
+    
+    if *asJSON {
+        failures := make(map[string]string)
+        for _, name := range vault.FailedVaults(out.Failures) {
+            failures[name] = out.Failures[name].Error()
+        }
+        writeJSON(os.Stdout, struct {
+            Query       string               `json:"query"`
+            Total       int                  `json:"total"`
+            TotalCapped bool                 `json:"total_capped,omitempty"`
+            Offset      int                  `json:"offset"`
+            Results     []index.SearchResult `json:"results"`
+            Suggestion  string               `json:"suggestion,omitempty"`
+            Failures    map[string]string    `json:"failures,omitempty"`
+        }{query, out.Total, out.TotalCapped, out.Offset, out.Results, out.Suggestion, failures})
+    } else {
+        for i, result := range out.Results {
+            fmt.Printf("%d. [%s] %s (Score: %.2f)\n", out.Offset+i+1, result.Vault, result.FilePath, result.Score)
+            if result.Snippet != "" {
+                fmt.Printf("   %s\n", result.Snippet)
+            }
+        }
+        if len(out.Results) == 0 {
+            fmt.Printf("No results found for %q\n", query)
+        } else if out.NextCursor != "" {
+            fmt.Printf("%d of %d results, use -offset %d for more\n", len(out.Results), out.Total, out.Offset+len(out.Results))
+        }
+        if out.Suggestion != "" {
+            fmt.Printf("Did you mean: %s\n", out.Suggestion)
+        }
+        for _, name := range vault.FailedVaults(out.Failures) {
+            fmt.Fprintf(os.Stderr, "%s: search failed: %v\n", name, out.Failures[name])
+        }
+    }
+    
+    if len(out.Failures) > 0 {
+        return 1
+    }
+    return 0
+}
+
+// withSyntaxHelp adds the query syntax to the usage message of fs.
+func withSyntaxHelp(fs *flag.FlagSet) {
+    usage := fs.Usage
+    fs.Usage = func() {
+        usage()
+        fmt.Fprintf(fs.Output(), "\nQuery syntax: %s\n", query.Syntax)
+    }
+}
+
+// vaultStats is the output of the stats command for one vault.
+type vaultStats struct {
+    vault.Stats
+    Index *index.IndexStats `json:"index,omitempty"`
+}
+
+// runStats prints the size and contents of each vault's index.
+func runStats(args []string) int {
+    fs, flags := newCommand("stats", "stats [flags]")
+    name := fs.String("name", "", "only report the vault with this name")
+    asJSON := fs.Bool("json", false, "print statistics as JSON")
+    cfg, logFile := loadConfig(fs, flags, args)
+    if logFile != nil {
+        defer logFile.Close()
+    }
+    
+    vaults := vault.Open(cfg)
+    defer vaults.Close()
+    
+    status := 0
+    var all []vaultStats
+    for _, v := range selectVaults(vaults, *name) {
+        stats := vaultStats{Stats: v.Stats()}
+        if v.Available() {
+            idx, err := v.Index.Stats()
+            if err != nil {
+                stats.Error = err.Error()
+            } else {
+                stats.Index = &idx
+            }
+        }
+        if stats.Error != "" {
+            status = 1
+        }
+        all = append(all, stats)
+    }
+    
+    if *asJSON {
+        writeJSON(os.Stdout, all)
+        return status
+    }
+    
+    for _, stats := range all {
+        fmt.Printf("%s\n  Path: %s\n  Index: %s\n", stats.Name, stats.Path, stats.IndexPath)
+        if stats.Error != "" {
+            fmt.Printf("  Error: %s\n", stats.Error)
+        }
+        if stats.Index != nil {
+            fmt.Printf("  Files: %d\n  Documents: %d\n  Size: %d bytes\n",
+                stats.Index.Files, stats.Index.Documents, stats.Index.SizeBytes)
+        }
+    }
+    return status
+}
+
+// vaultCheck is the output of the check command for one vault.
+type vaultCheck struct {
+    Name       string `json:"name"`
+    Consistent bool   `json:"consistent"`
+    *index.CheckReport
+    Error string `json:"error,omitempty"`
+}
+
+// runCheck compares each vault with its index and exits non-zero if they
+// differ, so it can be used in scripts and health checks.
+func runCheck(args []string) int {
+    fs, flags := newCommand("check", "check [flags]")
+    name := fs.String("name", "", "only check the vault with this name")
+    asJSON := fs.Bool("json", false, "print the report as JSON")
+    cfg, logFile := loadConfig(fs, flags, args)
+    if logFile != nil {
+        defer logFile.Close()
+    }
+    
+    vaults := vault.Open(cfg)
+    defer vaults.Close()
+    
+    status := 0
+    var all []vaultCheck
+    for _, v := range selectVaults(vaults, *name) {
+        check := vaultCheck{Name: v.Name}
+        if !v.Available() {
+            check.Error = v.Err().Error()
+        } else if report, err := v.Index.Check(v.Path); err != nil {
+            check.Error = err.Error()
+        } else {
+            check.CheckReport = report
+            check.Consistent = report.Consistent()
+        }
+        if !check.Consistent {
+            status = 1
+        }
+        all = append(all, check)
+    }
+    
+    if *asJSON {
+        writeJSON(os.Stdout, all)
+        return status
+    }
+    
+    for _, check := range all {
+        switch {
+        case check.Error != "":
+            fmt.Printf("%s: error: %s\n", check.Name, check.Error)
+            continue
+        case check.Consistent:
+            fmt.Printf("%s: ok (%d files)\n", check.Name, check.Files)
+            continue
+        }
+        
+        fmt.Printf("%s: index out of date (%d files, %d documents)\n", check.Name, check.Files, check.Documents)
+        printPaths("not indexed", check.Missing)
+        printPaths("deleted or ignored", check.Stale)
+        printPaths("modified since indexing", check.Outdated)
+    }
+    return status
+}
+
+func printPaths(label string, paths []string) {
+    if len(paths) == 0 {
+        return
+    }
+    fmt.Printf("  %s (%d):\n", label, len(paths))
+    for _, path := range paths {
+        fmt.Printf("    %s\n", path)
+    }
+}
\ No newline at end of file
@@ -1,59 +1,117 @@
 package main
 
 import (
+    "flag"
     "fmt"
-    "log"
+    "io"
+    "log/slog"
     "os"
     "os/signal"
+    "strings"
     "syscall"
     
     "github.com/mark3labs/mcp-go/server"
     "github.com/Atomzwieback/obsidian-search-mcp/internal/config"
-    "github.com/Atomzwieback/obsidian-search-mcp/internal/index"
     "github.com/Atomzwieback/obsidian-search-mcp/internal/mcp"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/vault"
 )
 
+const usage = `Usage: obsidian-search-mcp [command] [flags]
+
+Commands:
+  serve    run the MCP server (default)
+  index    index the vaults and exit, -rebuild starts from scratch
+  search   search the existing index: search [flags] <query>
+  stats    print index statistics
+  check    compare the index with the vault, exit 1 if they differ
+  config   print the effective configuration
+
+Run "obsidian-search-mcp <command> -h" for the flags of a command.
+
+Flags:
+`
+
 func main() {
+    command := "serve"
+    args := os.Args[1:]
+    if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+        command, args = args[0], args[1:]
+    }
+    
+    switch command {
+    case "index":
+        os.Exit(runIndex(args))
+    case "search":
+        os.Exit(runSearch(args))
+    case "stats":
+        os.Exit(runStats(args))
+    case "check":
+        os.Exit(runCheck(args))
+    }
+    
+    fs := flag.NewFlagSet("obsidian-search-mcp", flag.ExitOnError)
+    fs.Usage = func() {
+        fmt.Fprint(fs.Output(), usage)
+        fs.PrintDefaults()
+    }
+    flags := config.RegisterFlags(fs)
+    fs.Parse(args)
+    
     // Konfiguration laden
-    cfg, err := config.LoadConfig()
+    cfg, err := config.Load(flags)
     if err != nil {
-        log.Fatalf("Failed to load config: %v", err)
+        fatal("Failed to load config", "err", err)
     }
     
-    if cfg.VaultPath == "" {
-        log.Fatal("OBSIDIAN_VAULT_PATH environment variable must be set")
+    switch command {
+    case "serve":
+        serve(cfg)
+    case "config":
+        if err := cfg.WriteYAML(os.Stdout); err != nil {
+            fatal("Failed to print config", "err", err)
+        }
+    default:
+        fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", command)
+        fs.Usage()
+        os.Exit(2)
+    }
+}
+
+func serve(cfg *config.Config) {
+    logFile, err := setupLogging(cfg.Logging)
+    if err != nil {
+        fatal("Failed to set up logging", "err", err)
+    }
+    if logFile != nil {
+        defer logFile.Close()
     }
     
-    fmt.Printf("Starting Obsidian MCP Search Server...\n")
-    fmt.Printf("Vault Path: %s\n", cfg.VaultPath)
-    fmt.Printf("Index Path: %s\n", cfg.IndexPath)
+    if len(cfg.VaultList()) == 0 {
+        fatal("OBSIDIAN_VAULT_PATH or OBSIDIAN_VAULTS environment variable must be set")
+    }
     
-    // Tantivy Index initialisieren
-    tantivyIndex, err := index.NewTantivyIndex(cfg.IndexPath)
-    if err != nil {
-        log.Fatalf("Failed to initialize index: %v", err)
+    // Logs go to stderr, stdout belongs to the stdio transport
+    slog.Info("Starting Obsidian MCP Search Server...")
+    for _, v := range cfg.VaultList() {
+        slog.Info("Vault", "vault", v.Name, "path", v.Path, "index", v.IndexPath)
     }
-    defer tantivyIndex.Close()
+    slog.Info("Watch mode", "mode", cfg.WatchMode)
+    
+    // Indexe initialisieren, ein Vault-Fehler stoppt die anderen nicht
+    vaults := vault.Open(cfg)
+    defer vaults.Close()
     
     // Initial indexing
-    fmt.Println("Starting initial indexing...")
-    if err := tantivyIndex.IndexDirectory(cfg.VaultPath, cfg.MaxWorkers); err != nil {
-        log.Printf("Warning: Initial indexing error: %v", err)
-    }
+    slog.Info("Starting initial indexing...")
+    vaults.IndexAll()
     
     // File Watcher starten
     if cfg.WatchFiles {
-        watcher, err := index.NewFileWatcher(tantivyIndex, cfg.VaultPath)
-        if err != nil {
-            log.Printf("Failed to start file watcher: %v", err)
-        } else {
-            go watcher.Start()
-            defer watcher.Stop()
-        }
+        vaults.StartWatchers()
     }
     
     // MCP Server setup
-    handler := mcp.NewSearchHandler(tantivyIndex)
+    handler := mcp.NewSearchHandler(vaults).WithConfig(cfg)
     mcpServer := handler.SetupServer()
     
     // Graceful shutdown
@@ -62,14 +120,54 @@ func main() {
     
     go func() {
         <-sigChan
-        fmt.Println("\nShutting down server...")
-        tantivyIndex.Close()
+        slog.Info("Shutting down server...")
+        vaults.Close()
         os.Exit(0)
     }()
     
     // Server starten
-    fmt.Println("MCP Server ready. Listening on stdio...")
-    if err := server.ServeStdio(mcpServer); err != nil {
-        log.Fatalf("Server error: %v", err)
+    switch cfg.Server.Transport {
+    case "sse":
+        slog.Info("MCP Server ready. Listening for SSE...", "address", cfg.Server.Address)
+        err = server.NewSSEServer(mcpServer).Start(cfg.Server.Address)
+    case "http":
+        slog.Info("MCP Server ready. Listening for streamable HTTP...", "address", cfg.Server.Address)
+        err = server.NewStreamableHTTPServer(mcpServer).Start(cfg.Server.Address)
+    default:
+        slog.Info("MCP Server ready. Listening on stdio...")
+        err = server.ServeStdio(mcpServer)
+    }
+    if err != nil {
+        fatal("Server error", "err", err)
     }
+}
+
+// setupLogging installs the default slog logger with the configured level,
+// writing to stderr or the configured file. Failures are logged at warn or
+// error, so every level still reports them.
+func setupLogging(cfg config.LoggingConfig) (io.Closer, error) {
+    var out io.Writer = os.Stderr
+    var closer io.Closer
+    if cfg.File != "" {
+        f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+        if err != nil {
+            return nil, err
+        }
+        out, closer = f, f
+    }
+    
+    var level slog.Level
+    if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+        return closer, err
+    }
+    
+    slog.SetDefault(slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: level})))
+    return closer, nil
+}
+
+// fatal logs msg at error level, which every configured level reports, and
+// exits.
+func fatal(msg string, args ...any) {
+    slog.Error(msg, args...)
+    os.Exit(1)
 }
\ No newline at end of file
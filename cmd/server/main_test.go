@@ -0,0 +1,45 @@
+package main
+
+import (
+    "log/slog"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/config"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/vault"
+)
+
+func TestSetupLoggingLevel(t *testing.T) {
+    defer slog.SetDefault(slog.Default())
+    
+    dir := t.TempDir()
+    logPath := filepath.Join(dir, "server.log")
+    closer, err := setupLogging(config.LoggingConfig{Level: "warn", File: logPath})
+    if err != nil {
+        t.Fatalf("Failed to set up logging: %v", err)
+    }
+    defer closer.Close()
+    
+    // An index below a regular file cannot be opened
+    blocked := filepath.Join(dir, "blocked")
+    os.WriteFile(blocked, nil, 0644)
+    cfg := config.Default()
+    cfg.VaultPath = filepath.Join(dir, "vault")
+    cfg.IndexPath = filepath.Join(blocked, "index")
+    slog.Info("Starting Obsidian MCP Search Server...")
+    vault.Open(cfg).Close()
+    
+    data, err := os.ReadFile(logPath)
+    if err != nil {
+        t.Fatalf("Failed to read log: %v", err)
+    }
+    logged := string(data)
+    if !strings.Contains(logged, "level=ERROR") || !strings.Contains(logged, "Vault unavailable") {
+        t.Errorf("Expected the failure at warn level, got %q", logged)
+    }
+    if strings.Contains(logged, "Starting") {
+        t.Errorf("Expected no info messages at warn level, got %q", logged)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,160 @@
+// Package analysis decides how note text is analyzed: which language a note
+// is written in and how text is normalized before it is matched.
+package analysis
+
+import (
+    "sort"
+    "strings"
+    "unicode"
+)
+
+// DefaultLanguage is used when no languages are configured.
+const DefaultLanguage = "en"
+
+// Languages lists the language codes that have a stemmer in the index.
+var Languages = []string{
+    "ar", "ca", "da", "de", "el", "en", "es", "et", "eu", "fi", "fr", "ga", "hi", "hu", "hy",
+    "id", "it", "lt", "ne", "nl", "no", "pt", "ro", "ru", "sr", "sv", "ta", "tr", "yi",
+}
+
+// languageNames maps spellings found in frontmatter to language codes.
+var languageNames = map[string]string{
+    "english":    "en",
+    "german":     "de",
+    "deutsch":    "de",
+    "french":     "fr",
+    "français":   "fr",
+    "francais":   "fr",
+    "spanish":    "es",
+    "español":    "es",
+    "espanol":    "es",
+    "italian":    "it",
+    "italiano":   "it",
+    "dutch":      "nl",
+    "nederlands": "nl",
+    "portuguese": "pt",
+    "português":  "pt",
+    "portugues":  "pt",
+    "swedish":    "sv",
+    "svenska":    "sv",
+    "danish":     "da",
+    "dansk":      "da",
+    "norwegian":  "no",
+    "norsk":      "no",
+    "nb":         "no",
+    "nn":         "no",
+    "finnish":    "fi",
+    "suomi":      "fi",
+    "russian":    "ru",
+    "русский":    "ru",
+}
+
+// IsSupported reports whether code is a language the index can analyze.
+func IsSupported(code string) bool {
+    i := sort.SearchStrings(Languages, code)
+    return i < len(Languages) && Languages[i] == code
+}
+
+// NormalizeLanguage turns a language tag such as "de-DE", "en_US" or
+// "German" into a language code. It returns "" for unknown languages.
+func NormalizeLanguage(tag string) string {
+    tag = strings.ToLower(strings.TrimSpace(tag))
+    if code, ok := languageNames[tag]; ok {
+        return code
+    }
+    if i := strings.IndexAny(tag, "-_"); i > 0 {
+        tag = tag[:i]
+    }
+    if code, ok := languageNames[tag]; ok {
+        return code
+    }
+    if IsSupported(tag) {
+        return tag
+    }
+    return ""
+}
+
+// Detector guesses the language of a note among the configured languages.
+// Detection counts common function words, so it runs offline and needs no
+// model; languages without a word list can only be chosen explicitly.
+type Detector struct {
+    languages []string
+}
+
+// minStopwords is the number of function words a text needs before its
+// language is trusted over the default.
+const minStopwords = 3
+
+// maxDetectRunes bounds the amount of text inspected per note.
+const maxDetectRunes = 20000
+
+func NewDetector(languages []string) *Detector {
+    if len(languages) == 0 {
+        languages = []string{DefaultLanguage}
+    }
+    return &Detector{languages: languages}
+}
+
+// Languages returns the configured languages, the default first.
+func (d *Detector) Languages() []string {
+    return d.languages
+}
+
+// Default returns the language used when detection is inconclusive.
+func (d *Detector) Default() string {
+    return d.languages[0]
+}
+
+// Resolve picks the language of a note: an explicit tag, typically the
+// frontmatter lang property, wins if it names a configured language;
+// otherwise the language is detected from text.
+func (d *Detector) Resolve(tag, text string) string {
+    if code := NormalizeLanguage(tag); code != "" && d.configured(code) {
+        return code
+    }
+    return d.Detect(text)
+}
+
+// Detect returns the configured language whose function words occur most
+// often in text, or the default language if none occurs often enough. Ties
+// go to the language configured first.
+func (d *Detector) Detect(text string) string {
+    if len(d.languages) == 1 {
+        return d.languages[0]
+    }
+    
+    counts := make(map[string]int, len(d.languages))
+    runes := 0
+    words := strings.FieldsFunc(text, func(r rune) bool {
+        runes++
+        return runes > maxDetectRunes || !(unicode.IsLetter(r) || r == '\'')
+    })
+    for _, word := range words {
+        word = strings.ToLower(word)
+        for _, lang := range d.languages {
+            if stopwords[lang][word] {
+                counts[lang]++
+            }
+        }
+    }
+    
+    best, bestCount := d.languages[0], 0
+    for _, lang := range d.languages {
+        if counts[lang] > bestCount {
+            best, bestCount = lang, counts[lang]
+        }
+    }
+    if bestCount < minStopwords {
+        return d.languages[0]
+    }
+    return best
+}
+
+func (d *Detector) configured(code string) bool {
+    for _, lang := range d.languages {
+        if lang == code {
+            return true
+        }
+    }
+    return false
+}
\ No newline at end of file
@@ -0,0 +1,55 @@
+package analysis
+
+import "testing"
+
+func TestNormalizeLanguage(t *testing.T) {
+    tests := map[string]string{
+        "de":      "de",
+        "de-DE":   "de",
+        "en_US":   "en",
+        " German": "de",
+        "Deutsch": "de",
+        "nb-NO":   "no",
+        "klingon": "",
+        "":        "",
+    }
+    
+    for tag, want := range tests {
+        if got := NormalizeLanguage(tag); got != want {
+            t.Errorf("NormalizeLanguage(%q) = %q, want %q", tag, got, want)
+        }
+    }
+}
+
+func TestDetect(t *testing.T) {
+    d := NewDetector([]string{"en", "de"})
+    
+    tests := []struct {
+        name string
+        text string
+        want string
+    }{
+        {"english", "The meeting was moved to Friday, and we will discuss the budget for the next quarter.", "en"},
+        {"german", "Das Treffen wurde auf Freitag verschoben und wir werden über das Budget für das nächste Quartal sprechen.", "de"},
+        {"too short", "Budget Q3", "en"},
+        {"unconfigured language", "Le rendez-vous est reporté à vendredi et nous parlerons du budget.", "en"},
+    }
+    
+    for _, tt := range tests {
+        if got := d.Detect(tt.text); got != tt.want {
+            t.Errorf("%s: Detect = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestResolvePrefersConfiguredTag(t *testing.T) {
+    d := NewDetector([]string{"en", "de"})
+    english := "The meeting was moved to Friday and we will discuss the budget for the next quarter."
+    
+    if got := d.Resolve("de-AT", english); got != "de" {
+        t.Errorf("Resolve with configured tag = %q, want de", got)
+    }
+    if got := d.Resolve("fr", english); got != "en" {
+        t.Errorf("Resolve with unconfigured tag = %q, want detected en", got)
+    }
+}
\ No newline at end of file
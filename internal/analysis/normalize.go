@@ -0,0 +1,103 @@
+package analysis
+
+import (
+    "strings"
+    "unicode"
+    
+    "golang.org/x/text/unicode/norm"
+)
+
+// Normalize composes text to Unicode NFC. Notes synced from macOS often use
+// decomposed (NFD) characters, which the index tokenizer would otherwise
+// split at every combining mark. Index and query text both pass through
+// Normalize; lowercasing and ASCII folding happen in the index analyzers.
+func Normalize(text string) string {
+    return norm.NFC.String(text)
+}
+
+// foldSpecial spells out letters that do not decompose into a base letter
+// and combining marks.
+var foldSpecial = map[rune]string{
+    'ß': "ss", 'ẞ': "SS",
+    'æ': "ae", 'Æ': "AE",
+    'œ': "oe", 'Œ': "OE",
+    'ø': "o", 'Ø': "O",
+    'đ': "d", 'Đ': "D",
+    'ð': "d", 'Ð': "D",
+    'ł': "l", 'Ł': "L",
+    'þ': "th", 'Þ': "TH",
+    'ı': "i",
+}
+
+// Fold lowercases text and reduces it to ASCII where possible, the same way
+// the index analyzers do, so that "Munchen" matches "München" in either
+// normalization form.
+func Fold(text string) string {
+    folded, _ := FoldWithOffsets(text)
+    return folded
+}
+
+// FoldWithOffsets folds text like Fold and also returns, for every byte of
+// the folded string, the byte offset of the character of text it came from.
+// The final entry maps the end of the folded string to len(text). This lets
+// callers match on folded text and cut the original at the same place.
+func FoldWithOffsets(text string) (string, []int) {
+    var b strings.Builder
+    offsets := make([]int, 0, len(text)+1)
+    
+    for i, r := range text {
+        var out string
+        if special, ok := foldSpecial[r]; ok {
+            out = special
+        } else {
+            var sb strings.Builder
+            for _, d := range norm.NFD.String(string(r)) {
+                if !unicode.Is(unicode.Mn, d) {
+                    sb.WriteRune(d)
+                }
+            }
+            out = sb.String()
+        }
+        
+        out = strings.ToLower(out)
+        b.WriteString(out)
+        for j := 0; j < len(out); j++ {
+            offsets = append(offsets, i)
+        }
+    }
+    
+    offsets = append(offsets, len(text))
+    return b.String(), offsets
+}
+
+// Tokenize folds text and splits it into words of letters and digits. It
+// approximates the index tokenizer without stemming, so the words are real
+// spellings that can be suggested back to the user.
+func Tokenize(text string) []string {
+    return strings.FieldsFunc(Fold(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+}
+
+// TokenizeCode folds code and splits it into identifiers, keeping names
+// like snake_case and pkg.Func whole. A compound name is followed by its
+// parts, so "pkg.Func" is also found by "Func".
+func TokenizeCode(text string) []string {
+    var words []string
+    idents := strings.FieldsFunc(Fold(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.'
+    })
+    for _, ident := range idents {
+        if ident = strings.Trim(ident, "."); ident == "" {
+            continue
+        }
+        words = append(words, ident)
+        parts := strings.FieldsFunc(ident, func(r rune) bool {
+            return r == '_' || r == '.'
+        })
+        if len(parts) > 1 || len(parts) == 1 && parts[0] != ident {
+            words = append(words, parts...)
+        }
+    }
+    return words
+}
\ No newline at end of file
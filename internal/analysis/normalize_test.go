@@ -0,0 +1,55 @@
+package analysis
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestNormalizeComposes(t *testing.T) {
+    nfd := "Mu\u0308nchen"
+    if got := Normalize(nfd); got != "M\u00fcnchen" {
+        t.Errorf("Normalize(%q) = %q, want NFC form", nfd, got)
+    }
+}
+
+func TestFold(t *testing.T) {
+    tests := map[string]string{
+        "M\u00fcnchen":  "munchen",
+        "Mu\u0308nchen": "munchen",
+        "Straße":        "strasse",
+        "Crème Brûlée":  "creme brulee",
+        "Łódź":          "lodz",
+        "Ærø":           "aero",
+        "plain ASCII":   "plain ascii",
+    }
+    
+    for in, want := range tests {
+        if got := Fold(in); got != want {
+            t.Errorf("Fold(%q) = %q, want %q", in, got, want)
+        }
+    }
+}
+
+func TestFoldWithOffsets(t *testing.T) {
+    text := "Grüße aus Köln"
+    folded, offsets := FoldWithOffsets(text)
+    if len(offsets) != len(folded)+1 {
+        t.Fatalf("got %d offsets for %d bytes", len(offsets), len(folded))
+    }
+    
+    // Cut the original at the folded match of "koln"
+    i := strings.Index(folded, "koln")
+    start, end := offsets[i], offsets[i+len("koln")]
+    if got := text[start:end]; got != "Köln" {
+        t.Errorf("mapped match = %q, want %q", got, "Köln")
+    }
+}
+
+func TestTokenizeCode(t *testing.T) {
+    got := TokenizeCode("ctx, cancel := context.WithTimeout(parent, max_wait)\n__init__ end.")
+    want := []string{"ctx", "cancel", "context.withtimeout", "context", "withtimeout", "parent", "max_wait", "max", "wait", "__init__", "init", "end"}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("TokenizeCode() = %q, want %q", got, want)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,46 @@
+package analysis
+
+import "strings"
+
+// stopwords holds the most frequent function words of each language that
+// can be detected. Words shared by several languages count for all of them.
+var stopwords = map[string]map[string]bool{
+    "en": wordSet("the and of to in is that it for was on are with as be this have from or by not but what all were when we there can an which their if has will would about they been who into its than them these so some could our"),
+    "de": wordSet("der die das und ist nicht ein eine einen einem einer zu mit den dem des auf für im von sich auch es wir ich sie er wird werden sind war wurde bei aus nach wie oder aber noch nur wenn dass doch schon über kann mehr hat haben vom zum zur"),
+    "fr": wordSet("le la les et des est une un du dans que qui pour pas sur au avec ce sont par plus il elle nous vous ne se ses leur mais ou aux été être cette comme fait tout sans"),
+    "es": wordSet("el la los las y es una un del en que por para con no se su al lo como más pero sus le ya fue este esta son entre cuando muy sin sobre también hasta hay donde"),
+    "it": wordSet("il la le gli di che è e un una per non sono del della con si nel alla da anche come più ma questo questa ha essere dei delle sulla nella loro"),
+    "nl": wordSet("de het een en van is dat die niet op te zijn met voor in ook aan er maar om als bij nog wordt worden naar heeft dit wat werd ze hij zij"),
+    "pt": wordSet("o a os as e é um uma do da dos das em que para com não se por mais mas como foi ao na no seu sua ele ela são está também já ou"),
+    "sv": wordSet("och att det som en på är av för med till den har inte om ett de var men jag hon han så kan vi ska från eller efter när"),
+    "da": wordSet("og at det som en på er af for med til den har ikke om et de var men jeg hun han så kan vi skal fra eller efter når"),
+    "no": wordSet("og at det som en på er av for med til den har ikke om et de var men jeg hun han så kan vi skal fra eller etter når"),
+    "fi": wordSet("ja on ei se että oli kuin mutta hän tai myös ovat mitä sen jos niin kun nyt vain olla olen"),
+    "ru": wordSet("и в не на что он с как а то все она так его но да ты к у же вы за бы по только ее мне было вот от меня еще нет о из ему теперь когда"),
+}
+
+func wordSet(words string) map[string]bool {
+    set := make(map[string]bool)
+    for _, word := range strings.Fields(words) {
+        set[word] = true
+    }
+    return set
+}
+
+// foldedStopwords holds the stopwords of all languages, folded like the
+// words that Tokenize returns.
+var foldedStopwords = func() map[string]bool {
+    set := make(map[string]bool)
+    for _, words := range stopwords {
+        for word := range words {
+            set[Fold(word)] = true
+        }
+    }
+    return set
+}()
+
+// IsStopword reports whether word, as returned by Tokenize, is a function
+// word in any of the supported languages.
+func IsStopword(word string) bool {
+    return foldedStopwords[word]
+}
\ No newline at end of file
@@ -1,27 +1,599 @@
 package config
 
 import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
     "os"
     "path/filepath"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+    
+    "gopkg.in/yaml.v3"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/embed"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/extract"
 )
 
+// ToolNames lists the MCP tools that can be enabled or disabled.
+var ToolNames = []string{
+    "search_vault",
+    "reindex_vault",
+    "suggest_notes",
+    "read_note",
+    "find_similar",
+    "query_tasks",
+    "query_properties",
+    "resolve_reference",
+    "get_periodic_notes",
+}
+
+// BoostFields lists the index fields that accept a ranking weight.
+var BoostFields = []string{
+    "title",
+    "aliases",
+    "headings",
+    "tags",
+    "path",
+    "content",
+    "urls",
+    "code",
+}
+
 type Config struct {
-    VaultPath   string
-    IndexPath   string
-    MaxWorkers  int
-    WatchFiles  bool
+    VaultPath      string            `yaml:"vault_path"`
+    Vaults         []VaultConfig     `yaml:"vaults"`
+    IndexPath      string            `yaml:"index_path"`
+    MaxWorkers     int               `yaml:"workers"`
+    WatchFiles     bool              `yaml:"watch"`
+    WatchMode      string            `yaml:"watch_mode"`
+    PollInterval   Duration          `yaml:"poll_interval"`
+    IgnorePatterns []string          `yaml:"ignore"`
+    Attachments    AttachmentsConfig `yaml:"attachments"`
+    Search         SearchConfig      `yaml:"search"`
+    Embeddings     EmbeddingsConfig  `yaml:"embeddings"`
+    Server         ServerConfig      `yaml:"server"`
+    Logging        LoggingConfig     `yaml:"logging"`
+    Tools          map[string]bool   `yaml:"tools"`
 }
 
-func LoadConfig() (*Config, error) {
+// VaultConfig describes one named vault. IndexPath defaults to a directory
+// named after the vault below the global index path.
+type VaultConfig struct {
+    Name      string   `yaml:"name"`
+    Path      string   `yaml:"path"`
+    IndexPath string   `yaml:"index_path,omitempty"`
+    Ignore    []string `yaml:"ignore,omitempty"`
+}
+
+// DefaultVaultName names the vault configured through vault_path alone.
+const DefaultVaultName = "default"
+
+var vaultNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type SearchConfig struct {
+    Backend       string             `yaml:"backend"`
+    DefaultLimit  int                `yaml:"default_limit"`
+    MaxLimit      int                `yaml:"max_limit"`
+    SnippetLength int                `yaml:"snippet_length"`
+    Boosts        map[string]float64 `yaml:"boosts"`
+    Languages     []string           `yaml:"languages"`
+    Fuzzy         FuzzyConfig        `yaml:"fuzzy"`
+    Recency       RecencyConfig      `yaml:"recency"`
+    ResolveEmbeds bool               `yaml:"resolve_embeds"`
+}
+
+// AttachmentsConfig selects the attachments indexed besides notes and
+// canvases by extension, and bounds the work spent on a single file. An
+// empty list of extensions indexes none.
+type AttachmentsConfig struct {
+    Extensions []string `yaml:"extensions"`
+    MaxSizeMB  int      `yaml:"max_size_mb"`
+    Timeout    Duration `yaml:"timeout"`
+}
+
+// FuzzyConfig holds the defaults of the fuzzy option of search_vault.
+type FuzzyConfig struct {
+    Distance      int `yaml:"distance"`
+    PrefixLength  int `yaml:"prefix_length"`
+    MaxExpansions int `yaml:"max_expansions"`
+}
+
+// RecencyConfig controls the boost of recently changed or created notes.
+// A weight of 0 turns it off.
+type RecencyConfig struct {
+    Weight   float64  `yaml:"weight"`
+    HalfLife Duration `yaml:"half_life"`
+    Date     string   `yaml:"date"`
+}
+
+// EmbeddingsConfig selects the embedding model for semantic search. An
+// empty provider turns semantic search off; an empty URL or model uses the
+// provider's default.
+type EmbeddingsConfig struct {
+    Provider  string   `yaml:"provider"`
+    URL       string   `yaml:"url"`
+    Model     string   `yaml:"model"`
+    APIKey    string   `yaml:"api_key,omitempty"`
+    BatchSize int      `yaml:"batch_size"`
+    Timeout   Duration `yaml:"timeout"`
+    ChunkSize int      `yaml:"chunk_size"`
+}
+
+// Enabled reports whether semantic search is configured.
+func (e EmbeddingsConfig) Enabled() bool {
+    return e.Provider != ""
+}
+
+type ServerConfig struct {
+    Transport string `yaml:"transport"`
+    Address   string `yaml:"address"`
+}
+
+type LoggingConfig struct {
+    Level string `yaml:"level"`
+    File  string `yaml:"file"`
+}
+
+// Duration is a time.Duration written as a Go duration string ("10s").
+type Duration time.Duration
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+    return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+    parsed, err := time.ParseDuration(node.Value)
+    if err != nil {
+        return fmt.Errorf("line %d: invalid duration %q", node.Line, node.Value)
+    }
+    *d = Duration(parsed)
+    return nil
+}
+
+// Default returns the built-in configuration, the lowest layer.
+func Default() *Config {
     homeDir, _ := os.UserHomeDir()
-    defaultIndexPath := filepath.Join(homeDir, ".obsidian-mcp", "index")
+    
+    tools := make(map[string]bool, len(ToolNames))
+    for _, name := range ToolNames {
+        tools[name] = true
+    }
     
     return &Config{
-        VaultPath:  os.Getenv("OBSIDIAN_VAULT_PATH"),
-        IndexPath:  getEnvOrDefault("MCP_INDEX_PATH", defaultIndexPath),
-        MaxWorkers: 4,
-        WatchFiles: true,
-    }, nil
+        IndexPath:    filepath.Join(homeDir, ".obsidian-mcp", "index"),
+        MaxWorkers:   4,
+        WatchFiles:   true,
+        WatchMode:    "auto",
+        PollInterval: Duration(10 * time.Second),
+        Attachments: AttachmentsConfig{
+            Extensions: extract.Extensions(),
+            MaxSizeMB:  int(extract.DefaultLimits.MaxSize >> 20),
+            Timeout:    Duration(extract.DefaultLimits.Timeout),
+        },
+        Search: SearchConfig{
+            Backend:       "auto",
+            DefaultLimit:  10,
+            MaxLimit:      100,
+            SnippetLength: 150,
+            Boosts: map[string]float64{
+                "title":    2.0,
+                "aliases":  2.0,
+                "headings": 1.5,
+                "tags":     1.5,
+                "path":     1.0,
+                "content":  1.0,
+                "urls":     0.5,
+                "code":     1.0,
+            },
+            Languages: []string{analysis.DefaultLanguage},
+            Fuzzy: FuzzyConfig{
+                Distance:      2,
+                PrefixLength:  1,
+                MaxExpansions: 10,
+            },
+            Recency: RecencyConfig{
+                HalfLife: Duration(30 * 24 * time.Hour),
+                Date:     "modified",
+            },
+            ResolveEmbeds: true,
+        },
+        Embeddings: EmbeddingsConfig{
+            BatchSize: 32,
+            Timeout:   Duration(30 * time.Second),
+            ChunkSize: 1000,
+        },
+        Server: ServerConfig{
+            Transport: "stdio",
+            Address:   ":8080",
+        },
+        Logging: LoggingConfig{
+            Level: "info",
+        },
+        Tools: tools,
+    }
+}
+
+// LoadConfig builds the configuration from defaults, the config file and
+// environment variables, without command line overrides.
+func LoadConfig() (*Config, error) {
+    return Load(nil)
+}
+
+// Load layers the configuration: defaults, then the config file, then
+// environment variables, then command line flags. The result is validated.
+func Load(flags *Flags) (*Config, error) {
+    cfg := Default()
+    
+    path, explicit := configFilePath(flags)
+    if path != "" {
+        if err := cfg.loadFile(path, explicit); err != nil {
+            return nil, err
+        }
+    }
+    
+    if err := cfg.applyEnv(); err != nil {
+        return nil, err
+    }
+    
+    if flags != nil {
+        if err := flags.apply(cfg); err != nil {
+            return nil, err
+        }
+    }
+    
+    if err := cfg.Validate(); err != nil {
+        return nil, err
+    }
+    return cfg, nil
+}
+
+// configFilePath picks the config file from --config, MCP_CONFIG or the
+// default location. Only explicitly requested files must exist.
+func configFilePath(flags *Flags) (string, bool) {
+    if flags != nil && flags.configFile != "" {
+        return flags.configFile, true
+    }
+    if path := os.Getenv("MCP_CONFIG"); path != "" {
+        return path, true
+    }
+    
+    homeDir, err := os.UserHomeDir()
+    if err != nil {
+        return "", false
+    }
+    return filepath.Join(homeDir, ".obsidian-mcp", "config.yaml"), false
+}
+
+func (c *Config) loadFile(path string, required bool) error {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) && !required {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("failed to read config file: %w", err)
+    }
+    
+    decoder := yaml.NewDecoder(bytes.NewReader(data))
+    decoder.KnownFields(true)
+    if err := decoder.Decode(c); err != nil && err != io.EOF {
+        return fmt.Errorf("config file %s: %w", path, err)
+    }
+    return nil
+}
+
+func (c *Config) applyEnv() error {
+    // A config file may have set these to null
+    if c.Tools == nil {
+        c.Tools = make(map[string]bool)
+    }
+    if c.Search.Boosts == nil {
+        c.Search.Boosts = make(map[string]float64)
+    }
+    
+    c.VaultPath = getEnvOrDefault("OBSIDIAN_VAULT_PATH", c.VaultPath)
+    c.IndexPath = getEnvOrDefault("MCP_INDEX_PATH", c.IndexPath)
+    c.WatchMode = getEnvOrDefault("MCP_WATCH_MODE", c.WatchMode)
+    c.Search.Backend = getEnvOrDefault("MCP_SEARCH_BACKEND", c.Search.Backend)
+    c.Server.Transport = getEnvOrDefault("MCP_TRANSPORT", c.Server.Transport)
+    c.Server.Address = getEnvOrDefault("MCP_ADDRESS", c.Server.Address)
+    c.Logging.Level = getEnvOrDefault("MCP_LOG_LEVEL", c.Logging.Level)
+    c.Logging.File = getEnvOrDefault("MCP_LOG_FILE", c.Logging.File)
+    c.Embeddings.Provider = getEnvOrDefault("MCP_EMBEDDINGS_PROVIDER", c.Embeddings.Provider)
+    c.Embeddings.URL = getEnvOrDefault("MCP_EMBEDDINGS_URL", c.Embeddings.URL)
+    c.Embeddings.Model = getEnvOrDefault("MCP_EMBEDDINGS_MODEL", c.Embeddings.Model)
+    c.Embeddings.APIKey = getEnvOrDefault("MCP_EMBEDDINGS_API_KEY", c.Embeddings.APIKey)
+    
+    if value := os.Getenv("OBSIDIAN_VAULTS"); value != "" {
+        vaults, err := parseVaultList(value)
+        if err != nil {
+            return fmt.Errorf("OBSIDIAN_VAULTS: %w", err)
+        }
+        c.Vaults = vaults
+    }
+    
+    if value := os.Getenv("MCP_LANGUAGES"); value != "" {
+        c.Search.Languages = splitList(value)
+    }
+    
+    // "none" turns attachments off
+    if value := os.Getenv("MCP_ATTACHMENTS"); value == "none" {
+        c.Attachments.Extensions = nil
+    } else if value != "" {
+        c.Attachments.Extensions = splitList(value)
+    }
+    
+    if value := os.Getenv("MCP_IGNORE_PATTERNS"); value != "" {
+        c.IgnorePatterns = append(c.IgnorePatterns, splitList(value)...)
+    }
+    
+    ints := map[string]*int{
+        "MCP_WORKERS":        &c.MaxWorkers,
+        "MCP_DEFAULT_LIMIT":  &c.Search.DefaultLimit,
+        "MCP_MAX_LIMIT":      &c.Search.MaxLimit,
+        "MCP_SNIPPET_LENGTH": &c.Search.SnippetLength,
+        "MCP_FUZZY_DISTANCE": &c.Search.Fuzzy.Distance,
+        "MCP_FUZZY_PREFIX":   &c.Search.Fuzzy.PrefixLength,
+    }
+    for key, target := range ints {
+        if value := os.Getenv(key); value != "" {
+            parsed, err := strconv.Atoi(value)
+            if err != nil {
+                return fmt.Errorf("%s: invalid integer %q", key, value)
+            }
+            *target = parsed
+        }
+    }
+    
+    if value := os.Getenv("MCP_WATCH"); value != "" {
+        parsed, err := strconv.ParseBool(value)
+        if err != nil {
+            return fmt.Errorf("MCP_WATCH: invalid boolean %q", value)
+        }
+        c.WatchFiles = parsed
+    }
+    
+    if value := os.Getenv("MCP_RESOLVE_EMBEDS"); value != "" {
+        parsed, err := strconv.ParseBool(value)
+        if err != nil {
+            return fmt.Errorf("MCP_RESOLVE_EMBEDS: invalid boolean %q", value)
+        }
+        c.Search.ResolveEmbeds = parsed
+    }
+    
+    if value := os.Getenv("MCP_RECENCY_WEIGHT"); value != "" {
+        parsed, err := strconv.ParseFloat(value, 64)
+        if err != nil {
+            return fmt.Errorf("MCP_RECENCY_WEIGHT: invalid number %q", value)
+        }
+        c.Search.Recency.Weight = parsed
+    }
+    
+    if value := os.Getenv("MCP_RECENCY_HALF_LIFE"); value != "" {
+        parsed, err := time.ParseDuration(value)
+        if err != nil {
+            return fmt.Errorf("MCP_RECENCY_HALF_LIFE: invalid duration %q", value)
+        }
+        c.Search.Recency.HalfLife = Duration(parsed)
+    }
+    
+    if value := os.Getenv("MCP_POLL_INTERVAL"); value != "" {
+        parsed, err := time.ParseDuration(value)
+        if err != nil {
+            return fmt.Errorf("MCP_POLL_INTERVAL: invalid duration %q", value)
+        }
+        c.PollInterval = Duration(parsed)
+    }
+    
+    for _, name := range splitList(os.Getenv("MCP_DISABLED_TOOLS")) {
+        c.Tools[name] = false
+    }
+    
+    return nil
+}
+
+// Validate reports every invalid setting, naming each by its config file key.
+func (c *Config) Validate() error {
+    var errs []error
+    invalid := func(format string, args ...interface{}) {
+        errs = append(errs, fmt.Errorf(format, args...))
+    }
+    
+    if c.IndexPath == "" {
+        invalid("index_path must not be empty")
+    }
+    names := make(map[string]bool)
+    indexPaths := make(map[string]string)
+    for i, v := range c.VaultList() {
+        switch {
+        case !vaultNamePattern.MatchString(v.Name):
+            invalid("vaults[%d].name must only contain letters, digits, '-' and '_' (got %q)", i, v.Name)
+        case names[v.Name]:
+            invalid("vaults[%d].name %q is used more than once", i, v.Name)
+        }
+        names[v.Name] = true
+        if v.Path == "" {
+            invalid("vaults[%d].path must not be empty", i)
+        }
+        if other, ok := indexPaths[v.IndexPath]; ok {
+            invalid("vaults[%d].index_path %s is already used by vault %q", i, v.IndexPath, other)
+        }
+        indexPaths[v.IndexPath] = v.Name
+    }
+    if c.MaxWorkers < 1 {
+        invalid("workers must be at least 1 (got %d)", c.MaxWorkers)
+    }
+    if !oneOf(c.WatchMode, "auto", "fsnotify", "poll") {
+        invalid("watch_mode must be one of auto, fsnotify, poll (got %q)", c.WatchMode)
+    }
+    if c.PollInterval <= 0 {
+        invalid("poll_interval must be positive (got %s)", time.Duration(c.PollInterval))
+    }
+    
+    for i, ext := range c.Attachments.Extensions {
+        if _, ok := extract.Builtin(ext); !ok {
+            invalid("attachments.extensions[%d]: unsupported extension %q (valid: %s)", i, ext, strings.Join(extract.Extensions(), ", "))
+        }
+    }
+    if c.Attachments.MaxSizeMB < 1 {
+        invalid("attachments.max_size_mb must be at least 1 (got %d)", c.Attachments.MaxSizeMB)
+    }
+    if c.Attachments.Timeout <= 0 {
+        invalid("attachments.timeout must be positive (got %s)", time.Duration(c.Attachments.Timeout))
+    }
+    
+    if !oneOf(c.Search.Backend, "auto", "tantivy", "bm25") {
+        invalid("search.backend must be one of auto, tantivy, bm25 (got %q)", c.Search.Backend)
+    }
+    if c.Search.DefaultLimit < 1 {
+        invalid("search.default_limit must be at least 1 (got %d)", c.Search.DefaultLimit)
+    }
+    if c.Search.MaxLimit < c.Search.DefaultLimit {
+        invalid("search.max_limit must be at least search.default_limit (got %d < %d)", c.Search.MaxLimit, c.Search.DefaultLimit)
+    }
+    if c.Search.SnippetLength < 1 {
+        invalid("search.snippet_length must be at least 1 (got %d)", c.Search.SnippetLength)
+    }
+    for field, boost := range c.Search.Boosts {
+        if !oneOf(field, BoostFields...) {
+            invalid("search.boosts: unknown field %q (valid: %s)", field, strings.Join(BoostFields, ", "))
+        } else if boost < 0 {
+            invalid("search.boosts.%s must not be negative (got %g)", field, boost)
+        }
+    }
+    if c.Search.Fuzzy.Distance < 1 || c.Search.Fuzzy.Distance > 2 {
+        invalid("search.fuzzy.distance must be 1 or 2 (got %d)", c.Search.Fuzzy.Distance)
+    }
+    if c.Search.Fuzzy.PrefixLength < 0 {
+        invalid("search.fuzzy.prefix_length must not be negative (got %d)", c.Search.Fuzzy.PrefixLength)
+    }
+    if c.Search.Fuzzy.MaxExpansions < 1 {
+        invalid("search.fuzzy.max_expansions must be at least 1 (got %d)", c.Search.Fuzzy.MaxExpansions)
+    }
+    if c.Search.Recency.Weight < 0 {
+        invalid("search.recency.weight must not be negative (got %g)", c.Search.Recency.Weight)
+    }
+    if c.Search.Recency.HalfLife <= 0 {
+        invalid("search.recency.half_life must be positive (got %s)", time.Duration(c.Search.Recency.HalfLife))
+    }
+    if !oneOf(c.Search.Recency.Date, "modified", "created") {
+        invalid("search.recency.date must be one of modified, created (got %q)", c.Search.Recency.Date)
+    }
+    if len(c.Search.Languages) == 0 {
+        invalid("search.languages must name at least one language")
+    }
+    for i, lang := range c.Search.Languages {
+        if !analysis.IsSupported(lang) {
+            invalid("search.languages[%d]: unsupported language %q (valid: %s)", i, lang, strings.Join(analysis.Languages, ", "))
+        } else if oneOf(lang, c.Search.Languages[:i]...) {
+            invalid("search.languages[%d]: %q is listed more than once", i, lang)
+        }
+    }
+    
+    if c.Embeddings.Provider != "" && !oneOf(c.Embeddings.Provider, embed.Providers...) {
+        invalid("embeddings.provider must be one of %s (got %q)", strings.Join(embed.Providers, ", "), c.Embeddings.Provider)
+    }
+    if c.Embeddings.BatchSize < 1 {
+        invalid("embeddings.batch_size must be at least 1 (got %d)", c.Embeddings.BatchSize)
+    }
+    if c.Embeddings.Timeout <= 0 {
+        invalid("embeddings.timeout must be positive (got %s)", time.Duration(c.Embeddings.Timeout))
+    }
+    if c.Embeddings.ChunkSize < 100 {
+        invalid("embeddings.chunk_size must be at least 100 (got %d)", c.Embeddings.ChunkSize)
+    }
+    
+    if !oneOf(c.Server.Transport, "stdio", "sse", "http") {
+        invalid("server.transport must be one of stdio, sse, http (got %q)", c.Server.Transport)
+    }
+    if c.Server.Transport != "stdio" && c.Server.Address == "" {
+        invalid("server.address is required for the %s transport", c.Server.Transport)
+    }
+    
+    if !oneOf(c.Logging.Level, "debug", "info", "warn", "error") {
+        invalid("logging.level must be one of debug, info, warn, error (got %q)", c.Logging.Level)
+    }
+    
+    for name := range c.Tools {
+        if !oneOf(name, ToolNames...) {
+            invalid("tools: unknown tool %q (valid: %s)", name, strings.Join(ToolNames, ", "))
+        }
+    }
+    
+    if len(errs) > 0 {
+        return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+    }
+    return nil
+}
+
+// VaultList returns the configured vaults with their index paths resolved.
+// Without a vaults section, vault_path is served as a single vault that keeps
+// using index_path directly.
+func (c *Config) VaultList() []VaultConfig {
+    if len(c.Vaults) == 0 {
+        if c.VaultPath == "" {
+            return nil
+        }
+        return []VaultConfig{{
+            Name:      DefaultVaultName,
+            Path:      c.VaultPath,
+            IndexPath: c.IndexPath,
+        }}
+    }
+    
+    vaults := make([]VaultConfig, len(c.Vaults))
+    for i, v := range c.Vaults {
+        if v.IndexPath == "" {
+            v.IndexPath = filepath.Join(c.IndexPath, v.Name)
+        }
+        vaults[i] = v
+    }
+    return vaults
+}
+
+// parseVaultList parses "name=path" pairs separated by commas.
+func parseVaultList(value string) ([]VaultConfig, error) {
+    var vaults []VaultConfig
+    for _, item := range splitList(value) {
+        name, path, ok := strings.Cut(item, "=")
+        if !ok {
+            return nil, fmt.Errorf("expected name=path, got %q", item)
+        }
+        vaults = append(vaults, VaultConfig{Name: strings.TrimSpace(name), Path: strings.TrimSpace(path)})
+    }
+    return vaults, nil
+}
+
+// ToolEnabled reports whether the named tool should be registered.
+func (c *Config) ToolEnabled(name string) bool {
+    enabled, ok := c.Tools[name]
+    return !ok || enabled
+}
+
+// WriteYAML prints the effective configuration in config file format. The
+// embeddings API key is left out.
+func (c *Config) WriteYAML(w io.Writer) error {
+    printed := *c
+    printed.Embeddings.APIKey = ""
+    
+    encoder := yaml.NewEncoder(w)
+    encoder.SetIndent(2)
+    if err := encoder.Encode(&printed); err != nil {
+        return err
+    }
+    return encoder.Close()
+}
+
+func oneOf(value string, options ...string) bool {
+    for _, option := range options {
+        if value == option {
+            return true
+        }
+    }
+    return false
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -29,4 +601,15 @@ func getEnvOrDefault(key, defaultValue string) string {
         return value
     }
     return defaultValue
+}
+
+// splitList parses a comma-separated environment value, dropping empty items.
+func splitList(value string) []string {
+    var items []string
+    for _, item := range strings.Split(value, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
 }
\ No newline at end of file
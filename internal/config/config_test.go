@@ -0,0 +1,271 @@
+package config
+
+import (
+    "bytes"
+    "flag"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), "config.yaml")
+    if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func loadWithArgs(t *testing.T, args ...string) (*Config, error) {
+    t.Helper()
+    fs := flag.NewFlagSet("test", flag.ContinueOnError)
+    flags := RegisterFlags(fs)
+    if err := fs.Parse(args); err != nil {
+        t.Fatal(err)
+    }
+    return Load(flags)
+}
+
+func TestLayering(t *testing.T) {
+    path := writeConfigFile(t, `
+vault_path: /from/file
+workers: 2
+watch_mode: poll
+poll_interval: 30s
+search:
+  snippet_length: 200
+  boosts:
+    title: 3
+  recency:
+    weight: 1
+embeddings:
+  provider: ollama
+  model: from-file
+tools:
+  reindex_vault: false
+`)
+    t.Setenv("MCP_CONFIG", path)
+    t.Setenv("MCP_WORKERS", "6")
+    t.Setenv("MCP_SNIPPET_LENGTH", "250")
+    t.Setenv("MCP_EMBEDDINGS_MODEL", "from-env")
+    t.Setenv("MCP_RESOLVE_EMBEDS", "false")
+    
+    cfg, err := loadWithArgs(t, "-snippet-length", "300", "-boost", "content=0.5", "-recency-half-life", "168h", "-embeddings-url", "http://gpu:11434")
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    
+    if cfg.VaultPath != "/from/file" {
+        t.Errorf("Expected vault path from file, got %q", cfg.VaultPath)
+    }
+    if cfg.MaxWorkers != 6 {
+        t.Errorf("Expected env to override file workers, got %d", cfg.MaxWorkers)
+    }
+    if cfg.Search.SnippetLength != 300 {
+        t.Errorf("Expected flag to override env snippet length, got %d", cfg.Search.SnippetLength)
+    }
+    if time.Duration(cfg.PollInterval) != 30*time.Second || cfg.WatchMode != "poll" {
+        t.Errorf("Expected poll mode every 30s, got %s every %s", cfg.WatchMode, time.Duration(cfg.PollInterval))
+    }
+    if cfg.Search.Boosts["title"] != 3 || cfg.Search.Boosts["content"] != 0.5 || cfg.Search.Boosts["tags"] != 1.5 {
+        t.Errorf("Unexpected boosts: %v", cfg.Search.Boosts)
+    }
+    if cfg.Search.Recency.Weight != 1 || time.Duration(cfg.Search.Recency.HalfLife) != 7*24*time.Hour || cfg.Search.Recency.Date != "modified" {
+        t.Errorf("Unexpected recency: %+v", cfg.Search.Recency)
+    }
+    if e := cfg.Embeddings; !e.Enabled() || e.Provider != "ollama" || e.Model != "from-env" || e.URL != "http://gpu:11434" || e.ChunkSize != 1000 {
+        t.Errorf("Unexpected embeddings: %+v", e)
+    }
+    if cfg.ToolEnabled("reindex_vault") || !cfg.ToolEnabled("search_vault") {
+        t.Errorf("Unexpected tool enablement: %v", cfg.Tools)
+    }
+    if cfg.Search.DefaultLimit != 10 {
+        t.Errorf("Expected default limit to keep its default, got %d", cfg.Search.DefaultLimit)
+    }
+    if cfg.Search.ResolveEmbeds {
+        t.Error("Expected env to turn off embed resolution")
+    }
+}
+
+func TestInvalidConfig(t *testing.T) {
+    tests := []struct {
+        name    string
+        file    string
+        env     map[string]string
+        wantErr string
+    }{
+        {
+            name:    "unknown key",
+            file:    "workerz: 2\n",
+            wantErr: "field workerz not found",
+        },
+        {
+            name:    "bad duration",
+            file:    "poll_interval: soon\n",
+            wantErr: `invalid duration "soon"`,
+        },
+        {
+            name:    "bad watch mode",
+            file:    "watch_mode: inotify\n",
+            wantErr: `watch_mode must be one of auto, fsnotify, poll (got "inotify")`,
+        },
+        {
+            name:    "bad search backend",
+            file:    "search:\n  backend: lucene\n",
+            wantErr: `search.backend must be one of auto, tantivy, bm25 (got "lucene")`,
+        },
+        {
+            name:    "unknown boost field",
+            file:    "search:\n  boosts:\n    body: 2\n",
+            wantErr: `search.boosts: unknown field "body"`,
+        },
+        {
+            name:    "fuzzy distance",
+            env:     map[string]string{"MCP_FUZZY_DISTANCE": "3"},
+            wantErr: "search.fuzzy.distance must be 1 or 2 (got 3)",
+        },
+        {
+            name:    "recency date",
+            file:    "search:\n  recency:\n    date: updated\n",
+            wantErr: `search.recency.date must be one of modified, created (got "updated")`,
+        },
+        {
+            name:    "negative recency weight",
+            env:     map[string]string{"MCP_RECENCY_WEIGHT": "-1"},
+            wantErr: "search.recency.weight must not be negative (got -1)",
+        },
+        {
+            name:    "embeddings provider",
+            env:     map[string]string{"MCP_EMBEDDINGS_PROVIDER": "cohere"},
+            wantErr: `embeddings.provider must be one of openai, ollama (got "cohere")`,
+        },
+        {
+            name:    "unsupported language",
+            file:    "search:\n  languages: [en, xx]\n",
+            wantErr: `search.languages[1]: unsupported language "xx"`,
+        },
+        {
+            name:    "no languages",
+            env:     map[string]string{"MCP_LANGUAGES": " , "},
+            wantErr: "search.languages must name at least one language",
+        },
+        {
+            name:    "unknown tool",
+            file:    "tools:\n  delete_vault: true\n",
+            wantErr: `tools: unknown tool "delete_vault"`,
+        },
+        {
+            name:    "unsupported attachment",
+            env:     map[string]string{"MCP_ATTACHMENTS": "pdf,docx"},
+            wantErr: `attachments.extensions[1]: unsupported extension "docx"`,
+        },
+        {
+            name:    "attachment size",
+            file:    "attachments:\n  max_size_mb: 0\n",
+            wantErr: "attachments.max_size_mb must be at least 1 (got 0)",
+        },
+        {
+            name:    "bad env integer",
+            env:     map[string]string{"MCP_WORKERS": "many"},
+            wantErr: `MCP_WORKERS: invalid integer "many"`,
+        },
+        {
+            name:    "limits",
+            env:     map[string]string{"MCP_MAX_LIMIT": "5"},
+            wantErr: "search.max_limit must be at least search.default_limit",
+        },
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            t.Setenv("MCP_CONFIG", writeConfigFile(t, tt.file))
+            for key, value := range tt.env {
+                t.Setenv(key, value)
+            }
+            
+            _, err := loadWithArgs(t)
+            if err == nil {
+                t.Fatal("Expected an error")
+            }
+            if !strings.Contains(err.Error(), tt.wantErr) {
+                t.Errorf("Expected error containing %q, got %q", tt.wantErr, err)
+            }
+        })
+    }
+}
+
+func TestMissingExplicitConfigFile(t *testing.T) {
+    if _, err := loadWithArgs(t, "-config", filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+        t.Error("Expected an error for a missing config file passed with -config")
+    }
+}
+
+func TestWriteYAMLRoundTrip(t *testing.T) {
+    cfg := Default()
+    cfg.VaultPath = "/vault"
+    cfg.Embeddings.APIKey = "secret"
+    
+    var buf bytes.Buffer
+    if err := cfg.WriteYAML(&buf); err != nil {
+        t.Fatalf("WriteYAML failed: %v", err)
+    }
+    if !strings.Contains(buf.String(), "poll_interval: 10s") {
+        t.Errorf("Expected durations to be printed as strings:\n%s", buf.String())
+    }
+    if strings.Contains(buf.String(), "secret") {
+        t.Errorf("Expected the API key to be left out:\n%s", buf.String())
+    }
+    
+    t.Setenv("MCP_CONFIG", writeConfigFile(t, buf.String()))
+    loaded, err := loadWithArgs(t)
+    if err != nil {
+        t.Fatalf("Printed config does not load: %v", err)
+    }
+    if loaded.VaultPath != "/vault" {
+        t.Errorf("Expected vault path to round-trip, got %q", loaded.VaultPath)
+    }
+}
+
+func TestVaultList(t *testing.T) {
+    t.Setenv("MCP_CONFIG", writeConfigFile(t, "index_path: /data/index\n"))
+    t.Setenv("OBSIDIAN_VAULTS", "work=/vaults/work, personal=/vaults/personal")
+    
+    cfg, err := loadWithArgs(t)
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    
+    vaults := cfg.VaultList()
+    if len(vaults) != 2 {
+        t.Fatalf("Expected 2 vaults, got %v", vaults)
+    }
+    if vaults[1].Name != "personal" || vaults[1].Path != "/vaults/personal" {
+        t.Errorf("Unexpected vault: %+v", vaults[1])
+    }
+    if vaults[0].IndexPath != filepath.Join("/data/index", "work") {
+        t.Errorf("Expected a per-vault index directory, got %s", vaults[0].IndexPath)
+    }
+}
+
+func TestSingleVaultKeepsIndexPath(t *testing.T) {
+    cfg := Default()
+    cfg.VaultPath = "/vault"
+    
+    vaults := cfg.VaultList()
+    if len(vaults) != 1 || vaults[0].Name != DefaultVaultName || vaults[0].IndexPath != cfg.IndexPath {
+        t.Errorf("Unexpected single vault: %+v", vaults)
+    }
+}
+
+func TestDuplicateVaultNames(t *testing.T) {
+    t.Setenv("MCP_CONFIG", writeConfigFile(t, ""))
+    t.Setenv("OBSIDIAN_VAULTS", "work=/a,work=/b")
+    
+    _, err := loadWithArgs(t)
+    if err == nil || !strings.Contains(err.Error(), `vaults[1].name "work" is used more than once`) {
+        t.Errorf("Expected a duplicate name error, got %v", err)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,177 @@
+package config
+
+import (
+    "flag"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Flags holds the command line overrides, the highest configuration layer.
+type Flags struct {
+    set           *flag.FlagSet
+    configFile    string
+    vaultPath     string
+    vaults        string
+    indexPath     string
+    workers       int
+    watch         bool
+    watchMode     string
+    pollInterval  time.Duration
+    ignore        listFlag
+    transport     string
+    address       string
+    logLevel      string
+    logFile       string
+    backend       string
+    defaultLimit  int
+    maxLimit      int
+    snippetLen    int
+    boosts        listFlag
+    languages     string
+    fuzzyDist     int
+    fuzzyPrefix   int
+    recencyWeight float64
+    halfLife      time.Duration
+    resolveEmbeds bool
+    embedProvider string
+    embedURL      string
+    embedModel    string
+    enableTools   listFlag
+    disableTools  listFlag
+}
+
+type listFlag []string
+
+func (l *listFlag) String() string {
+    return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+    *l = append(*l, value)
+    return nil
+}
+
+// RegisterFlags defines the configuration flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+    f := &Flags{set: fs}
+    
+    fs.StringVar(&f.configFile, "config", "", "path to a YAML config file (env MCP_CONFIG)")
+    fs.StringVar(&f.vaultPath, "vault", "", "path to the Obsidian vault (env OBSIDIAN_VAULT_PATH)")
+    fs.StringVar(&f.vaults, "vaults", "", "named vaults as name=path,name=path (env OBSIDIAN_VAULTS)")
+    fs.StringVar(&f.indexPath, "index", "", "directory for the search index (env MCP_INDEX_PATH)")
+    fs.IntVar(&f.workers, "workers", 0, "number of indexing workers (env MCP_WORKERS)")
+    fs.BoolVar(&f.watch, "watch", true, "keep the index updated while running (env MCP_WATCH)")
+    fs.StringVar(&f.watchMode, "watch-mode", "", "auto, fsnotify or poll (env MCP_WATCH_MODE)")
+    fs.DurationVar(&f.pollInterval, "poll-interval", 0, "scan interval in poll mode (env MCP_POLL_INTERVAL)")
+    fs.Var(&f.ignore, "ignore", "additional ignore pattern, repeatable")
+    fs.StringVar(&f.transport, "transport", "", "stdio, sse or http (env MCP_TRANSPORT)")
+    fs.StringVar(&f.address, "address", "", "listen address for sse and http (env MCP_ADDRESS)")
+    fs.StringVar(&f.logLevel, "log-level", "", "debug, info, warn or error (env MCP_LOG_LEVEL)")
+    fs.StringVar(&f.logFile, "log-file", "", "write logs to this file instead of stderr (env MCP_LOG_FILE)")
+    fs.StringVar(&f.backend, "backend", "", "search backend: auto, tantivy or bm25 (env MCP_SEARCH_BACKEND)")
+    fs.IntVar(&f.defaultLimit, "default-limit", 0, "results returned when no limit is given (env MCP_DEFAULT_LIMIT)")
+    fs.IntVar(&f.maxLimit, "max-limit", 0, "upper bound for the limit parameter (env MCP_MAX_LIMIT)")
+    fs.IntVar(&f.snippetLen, "snippet-length", 0, "maximum snippet length in characters (env MCP_SNIPPET_LENGTH)")
+    fs.Var(&f.boosts, "boost", "field weight as field=weight, repeatable")
+    fs.IntVar(&f.fuzzyDist, "fuzzy-distance", 0, "default edit distance of fuzzy search, 1 or 2 (env MCP_FUZZY_DISTANCE)")
+    fs.IntVar(&f.fuzzyPrefix, "fuzzy-prefix", 0, "leading letters that must match exactly in fuzzy search (env MCP_FUZZY_PREFIX)")
+    fs.Float64Var(&f.recencyWeight, "recency-weight", 0, "boost of recent notes, 0 disables (env MCP_RECENCY_WEIGHT)")
+    fs.DurationVar(&f.halfLife, "recency-half-life", 0, "age at which the recency boost is halved (env MCP_RECENCY_HALF_LIFE)")
+    fs.BoolVar(&f.resolveEmbeds, "resolve-embeds", true, "index embedded notes with the note embedding them (env MCP_RESOLVE_EMBEDS)")
+    fs.StringVar(&f.embedProvider, "embeddings-provider", "", "openai or ollama, enables semantic search (env MCP_EMBEDDINGS_PROVIDER)")
+    fs.StringVar(&f.embedURL, "embeddings-url", "", "base URL of the embeddings API (env MCP_EMBEDDINGS_URL)")
+    fs.StringVar(&f.embedModel, "embeddings-model", "", "embedding model (env MCP_EMBEDDINGS_MODEL)")
+    fs.StringVar(&f.languages, "languages", "", "comma-separated note languages, the default first (env MCP_LANGUAGES)")
+    fs.Var(&f.enableTools, "enable-tool", "enable an MCP tool, repeatable")
+    fs.Var(&f.disableTools, "disable-tool", "disable an MCP tool, repeatable (env MCP_DISABLED_TOOLS)")
+    
+    return f
+}
+
+// apply copies the flags that were set explicitly onto cfg.
+func (f *Flags) apply(cfg *Config) error {
+    var err error
+    f.set.Visit(func(fl *flag.Flag) {
+        if err != nil {
+            return
+        }
+        switch fl.Name {
+        case "vault":
+            cfg.VaultPath = f.vaultPath
+        case "vaults":
+            vaults, parseErr := parseVaultList(f.vaults)
+            if parseErr != nil {
+                err = fmt.Errorf("-vaults: %w", parseErr)
+                return
+            }
+            cfg.Vaults = vaults
+        case "index":
+            cfg.IndexPath = f.indexPath
+        case "workers":
+            cfg.MaxWorkers = f.workers
+        case "watch":
+            cfg.WatchFiles = f.watch
+        case "watch-mode":
+            cfg.WatchMode = f.watchMode
+        case "poll-interval":
+            cfg.PollInterval = Duration(f.pollInterval)
+        case "ignore":
+            cfg.IgnorePatterns = append(cfg.IgnorePatterns, f.ignore...)
+        case "transport":
+            cfg.Server.Transport = f.transport
+        case "address":
+            cfg.Server.Address = f.address
+        case "log-level":
+            cfg.Logging.Level = f.logLevel
+        case "log-file":
+            cfg.Logging.File = f.logFile
+        case "backend":
+            cfg.Search.Backend = f.backend
+        case "default-limit":
+            cfg.Search.DefaultLimit = f.defaultLimit
+        case "max-limit":
+            cfg.Search.MaxLimit = f.maxLimit
+        case "snippet-length":
+            cfg.Search.SnippetLength = f.snippetLen
+        case "boost":
+            for _, boost := range f.boosts {
+                field, weight, ok := strings.Cut(boost, "=")
+                value, parseErr := strconv.ParseFloat(weight, 64)
+                if !ok || parseErr != nil {
+                    err = fmt.Errorf("-boost: expected field=weight, got %q", boost)
+                    return
+                }
+                cfg.Search.Boosts[field] = value
+            }
+        case "fuzzy-distance":
+            cfg.Search.Fuzzy.Distance = f.fuzzyDist
+        case "fuzzy-prefix":
+            cfg.Search.Fuzzy.PrefixLength = f.fuzzyPrefix
+        case "recency-weight":
+            cfg.Search.Recency.Weight = f.recencyWeight
+        case "recency-half-life":
+            cfg.Search.Recency.HalfLife = Duration(f.halfLife)
+        case "resolve-embeds":
+            cfg.Search.ResolveEmbeds = f.resolveEmbeds
+        case "embeddings-provider":
+            cfg.Embeddings.Provider = f.embedProvider
+        case "embeddings-url":
+            cfg.Embeddings.URL = f.embedURL
+        case "embeddings-model":
+            cfg.Embeddings.Model = f.embedModel
+        case "languages":
+            cfg.Search.Languages = splitList(f.languages)
+        case "enable-tool":
+            for _, name := range f.enableTools {
+                cfg.Tools[name] = true
+            }
+        case "disable-tool":
+            for _, name := range f.disableTools {
+                cfg.Tools[name] = false
+            }
+        }
+    })
+    return err
+}
\ No newline at end of file
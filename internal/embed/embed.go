@@ -0,0 +1,83 @@
+// Package embed turns text into vectors for semantic search, using an
+// embedding model served over HTTP.
+package embed
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// Embedder computes one embedding vector per text, in order.
+type Embedder interface {
+    Embed(ctx context.Context, texts []string) ([][]float32, error)
+    
+    // Model identifies the model. Vectors of different models are not
+    // comparable.
+    Model() string
+}
+
+// Providers, by the HTTP API they speak.
+const (
+    ProviderOpenAI = "openai"
+    ProviderOllama = "ollama"
+)
+
+// Providers lists the supported providers.
+var Providers = []string{ProviderOpenAI, ProviderOllama}
+
+var (
+    defaultURLs = map[string]string{
+        ProviderOpenAI: "https://api.openai.com/v1",
+        ProviderOllama: "http://localhost:11434",
+    }
+    defaultModels = map[string]string{
+        ProviderOpenAI: "text-embedding-3-small",
+        ProviderOllama: "nomic-embed-text",
+    }
+)
+
+// Config selects a provider and model. URL and Model default to the
+// provider's public endpoint and a small general-purpose model.
+type Config struct {
+    Provider  string
+    URL       string
+    Model     string
+    APIKey    string
+    BatchSize int
+    Timeout   time.Duration
+}
+
+// New returns an embedder for cfg.
+func New(cfg Config) (Embedder, error) {
+    base, ok := defaultURLs[cfg.Provider]
+    if !ok {
+        return nil, fmt.Errorf("unknown embedding provider %q (valid: %s)", cfg.Provider, strings.Join(Providers, ", "))
+    }
+    if cfg.URL != "" {
+        base = cfg.URL
+    }
+    model := cfg.Model
+    if model == "" {
+        model = defaultModels[cfg.Provider]
+    }
+    batchSize := cfg.BatchSize
+    if batchSize < 1 {
+        batchSize = 32
+    }
+    timeout := cfg.Timeout
+    if timeout <= 0 {
+        timeout = 30 * time.Second
+    }
+    
+    return &httpEmbedder{
+        provider:  cfg.Provider,
+        url:       strings.TrimRight(base, "/"),
+        model:     model,
+        apiKey:    cfg.APIKey,
+        batchSize: batchSize,
+        client:    &http.Client{Timeout: timeout},
+    }, nil
+}
\ No newline at end of file
@@ -0,0 +1,146 @@
+package embed
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+)
+
+// httpEmbedder calls the embeddings endpoint of an OpenAI-compatible server
+// (POST /embeddings) or of Ollama (POST /api/embed). Texts are sent in
+// batches of batchSize.
+type httpEmbedder struct {
+    provider  string
+    url       string
+    model     string
+    apiKey    string
+    batchSize int
+    client    *http.Client
+}
+
+func (e *httpEmbedder) Model() string {
+    return e.provider + "/" + e.model
+}
+
+func (e *httpEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+    vectors := make([][]float32, 0, len(texts))
+    for start := 0; start < len(texts); start += e.batchSize {
+        batch := texts[start:min(start+e.batchSize, len(texts))]
+        embedded, err := e.embedBatch(ctx, batch)
+        if err != nil {
+            return nil, err
+        }
+        if len(embedded) != len(batch) {
+            return nil, fmt.Errorf("embedding provider returned %d vectors for %d texts", len(embedded), len(batch))
+        }
+        vectors = append(vectors, embedded...)
+    }
+    return vectors, nil
+}
+
+// request is the body of both APIs.
+type request struct {
+    Model string   `json:"model"`
+    Input []string `json:"input"`
+}
+
+type openAIResponse struct {
+    Data []struct {
+        Index     int       `json:"index"`
+        Embedding []float32 `json:"embedding"`
+    } `json:"data"`
+}
+
+type ollamaResponse struct {
+    Embeddings [][]float32 `json:"embeddings"`
+}
+
+func (e *httpEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+    endpoint := e.url + "/embeddings"
+    if e.provider == ProviderOllama {
+        endpoint = e.url + "/api/embed"
+    }
+    
+    body, err := json.Marshal(request{Model: e.model, Input: texts})
+    if err != nil {
+        return nil, err
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    if e.apiKey != "" {
+        req.Header.Set("Authorization", "Bearer "+e.apiKey)
+    }
+    
+    resp, err := e.client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("embedding request failed: %w", err)
+    }
+    defer resp.Body.Close()
+    
+    data, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read embedding response: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("embedding provider returned %s: %s", resp.Status, errorMessage(data))
+    }
+    
+    if e.provider == ProviderOllama {
+        var parsed ollamaResponse
+        if err := json.Unmarshal(data, &parsed); err != nil {
+            return nil, fmt.Errorf("invalid embedding response: %w", err)
+        }
+        return parsed.Embeddings, nil
+    }
+    
+    var parsed openAIResponse
+    if err := json.Unmarshal(data, &parsed); err != nil {
+        return nil, fmt.Errorf("invalid embedding response: %w", err)
+    }
+    vectors := make([][]float32, len(parsed.Data))
+    for _, d := range parsed.Data {
+        if d.Index < 0 || d.Index >= len(vectors) {
+            return nil, fmt.Errorf("invalid embedding response: index %d out of range", d.Index)
+        }
+        if vectors[d.Index] != nil {
+            return nil, fmt.Errorf("invalid embedding response: index %d repeated", d.Index)
+        }
+        vectors[d.Index] = d.Embedding
+    }
+    for i, v := range vectors {
+        if len(v) == 0 {
+            return nil, fmt.Errorf("invalid embedding response: no vector for index %d", i)
+        }
+    }
+    return vectors, nil
+}
+
+// errorMessage extracts the message of an error response, which is
+// {"error": {"message": ...}} for OpenAI and {"error": ...} for Ollama.
+func errorMessage(data []byte) string {
+    var parsed struct {
+        Error json.RawMessage `json:"error"`
+    }
+    if json.Unmarshal(data, &parsed) == nil && len(parsed.Error) > 0 {
+        var message string
+        if json.Unmarshal(parsed.Error, &message) == nil {
+            return message
+        }
+        var nested struct {
+            Message string `json:"message"`
+        }
+        if json.Unmarshal(parsed.Error, &nested) == nil && nested.Message != "" {
+            return nested.Message
+        }
+    }
+    if len(data) > 200 {
+        data = data[:200]
+    }
+    return string(bytes.TrimSpace(data))
+}
\ No newline at end of file
@@ -0,0 +1,144 @@
+package embed
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// stubServer answers embedding requests of provider with the length of
+// each text as a one-dimensional vector and counts the requests.
+func stubServer(t *testing.T, provider string, requests *int) *httptest.Server {
+    return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        *requests++
+        
+        var req request
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            t.Errorf("Invalid request body: %v", err)
+        }
+        if req.Model != "test-model" {
+            t.Errorf("Expected model test-model, got %q", req.Model)
+        }
+        
+        switch provider {
+        case ProviderOpenAI:
+            if r.URL.Path != "/v1/embeddings" {
+                t.Errorf("Unexpected path %s", r.URL.Path)
+            }
+            if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+                t.Errorf("Expected the API key, got %q", got)
+            }
+            var resp openAIResponse
+            resp.Data = make([]struct {
+                Index     int       `json:"index"`
+                Embedding []float32 `json:"embedding"`
+            }, len(req.Input))
+            // Out of order, as the API allows
+            for i, text := range req.Input {
+                j := len(req.Input) - 1 - i
+                resp.Data[j].Index = i
+                resp.Data[j].Embedding = []float32{float32(len(text))}
+            }
+            json.NewEncoder(w).Encode(resp)
+        case ProviderOllama:
+            if r.URL.Path != "/api/embed" {
+                t.Errorf("Unexpected path %s", r.URL.Path)
+            }
+            var resp ollamaResponse
+            for _, text := range req.Input {
+                resp.Embeddings = append(resp.Embeddings, []float32{float32(len(text))})
+            }
+            json.NewEncoder(w).Encode(resp)
+        }
+    }))
+}
+
+func TestEmbed(t *testing.T) {
+    texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+    want := [][]float32{{1}, {2}, {3}, {4}, {5}}
+    
+    for _, provider := range Providers {
+        t.Run(provider, func(t *testing.T) {
+            requests := 0
+            server := stubServer(t, provider, &requests)
+            defer server.Close()
+            
+            url := server.URL
+            if provider == ProviderOpenAI {
+                url += "/v1/"
+            }
+            e, err := New(Config{Provider: provider, URL: url, Model: "test-model", APIKey: "secret", BatchSize: 2})
+            if err != nil {
+                t.Fatal(err)
+            }
+            
+            got, err := e.Embed(context.Background(), texts)
+            if err != nil {
+                t.Fatalf("Embed failed: %v", err)
+            }
+            if !reflect.DeepEqual(got, want) {
+                t.Errorf("Embed() = %v, want %v", got, want)
+            }
+            if requests != 3 {
+                t.Errorf("Expected 3 batches, got %d requests", requests)
+            }
+            if e.Model() != provider+"/test-model" {
+                t.Errorf("Unexpected model %q", e.Model())
+            }
+        })
+    }
+}
+
+func TestEmbedReportsProviderErrors(t *testing.T) {
+    tests := map[string]string{
+        ProviderOpenAI: `{"error": {"message": "invalid api key"}}`,
+        ProviderOllama: `{"error": "model not found"}`,
+    }
+    for provider, body := range tests {
+        server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            w.WriteHeader(http.StatusBadRequest)
+            w.Write([]byte(body))
+        }))
+        
+        e, _ := New(Config{Provider: provider, URL: server.URL})
+        _, err := e.Embed(context.Background(), []string{"text"})
+        server.Close()
+        
+        if err == nil || !strings.Contains(err.Error(), "400") {
+            t.Errorf("%s: expected the status in the error, got %v", provider, err)
+            continue
+        }
+        if !strings.Contains(err.Error(), "invalid api key") && !strings.Contains(err.Error(), "model not found") {
+            t.Errorf("%s: expected the provider's message, got %v", provider, err)
+        }
+    }
+}
+
+func TestEmbedRejectsInvalidIndexes(t *testing.T) {
+    for _, body := range []string{
+        `{"data": [{"index": 0, "embedding": [1, 0]}, {"index": 0, "embedding": [0, 1]}]}`,
+        `{"data": [{"index": 1, "embedding": [1, 0]}, {"index": 0, "embedding": []}]}`,
+    } {
+        server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            w.Write([]byte(body))
+        }))
+        
+        e, _ := New(Config{Provider: ProviderOpenAI, URL: server.URL})
+        vectors, err := e.Embed(context.Background(), []string{"first", "second"})
+        server.Close()
+        
+        if err == nil || !strings.Contains(err.Error(), "invalid embedding response") {
+            t.Errorf("%s: expected an invalid response, got %v, %v", body, vectors, err)
+        }
+    }
+}
+
+func TestNewRejectsUnknownProvider(t *testing.T) {
+    if _, err := New(Config{Provider: "cohere"}); err == nil {
+        t.Error("Expected an unknown provider to be rejected")
+    }
+}
\ No newline at end of file
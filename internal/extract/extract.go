@@ -0,0 +1,189 @@
+// Package extract turns attachments such as PDFs into text for the index.
+package extract
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+)
+
+// Page is the text of one page of a document. Number counts from 1 and is
+// 0 for documents without pages, which have a single Page.
+type Page struct {
+    Number int
+    Text   string
+}
+
+// Extractor turns the content of a file into text. Extractors check ctx
+// while they work and return once it is done, as the time spent on a file
+// is bounded only through ctx.
+type Extractor interface {
+    Extract(ctx context.Context, data []byte) ([]Page, error)
+}
+
+// ExtractorFunc adapts a function to Extractor.
+type ExtractorFunc func(ctx context.Context, data []byte) ([]Page, error)
+
+func (f ExtractorFunc) Extract(ctx context.Context, data []byte) ([]Page, error) {
+    return f(ctx, data)
+}
+
+// Limits bound the work spent on a single file. Larger files are not
+// read, extraction is cancelled after Timeout, and text beyond MaxText
+// bytes is dropped.
+type Limits struct {
+    MaxSize int64
+    Timeout time.Duration
+    MaxText int
+}
+
+// DefaultLimits are the limits used unless configured otherwise.
+var DefaultLimits = Limits{
+    MaxSize: 50 << 20,
+    Timeout: 30 * time.Second,
+    MaxText: 5 << 20,
+}
+
+var (
+    // ErrTooLarge is returned for files larger than Limits.MaxSize.
+    ErrTooLarge = errors.New("file too large")
+    
+    // ErrTimeout is returned when extraction takes longer than
+    // Limits.Timeout.
+    ErrTimeout = errors.New("extraction timed out")
+)
+
+// builtin are the extractors this package provides, by extension.
+var builtin = map[string]Extractor{
+    ".pdf":  ExtractorFunc(PDF),
+    ".txt":  ExtractorFunc(Text),
+    ".html": ExtractorFunc(HTML),
+    ".htm":  ExtractorFunc(HTML),
+}
+
+// Extensions lists the extensions with a built-in extractor, without the
+// leading dot.
+func Extensions() []string {
+    exts := make([]string, 0, len(builtin))
+    for ext := range builtin {
+        exts = append(exts, strings.TrimPrefix(ext, "."))
+    }
+    sort.Strings(exts)
+    return exts
+}
+
+// Builtin returns the built-in extractor for files with extension ext,
+// given with or without the leading dot.
+func Builtin(ext string) (Extractor, bool) {
+    e, ok := builtin[normalizeExt(ext)]
+    return e, ok
+}
+
+// Registry maps file extensions to the extractors for them. A nil Registry
+// supports no files.
+type Registry struct {
+    limits     Limits
+    extractors map[string]Extractor
+}
+
+// NewRegistry returns an empty registry that extracts within limits.
+func NewRegistry(limits Limits) *Registry {
+    return &Registry{limits: limits, extractors: make(map[string]Extractor)}
+}
+
+// Default returns a registry with every built-in extractor.
+func Default(limits Limits) *Registry {
+    r := NewRegistry(limits)
+    for ext, e := range builtin {
+        r.Register(ext, e)
+    }
+    return r
+}
+
+// Register makes e the extractor for files with extension ext, given with
+// or without the leading dot and compared case-insensitively.
+func (r *Registry) Register(ext string, e Extractor) {
+    r.extractors[normalizeExt(ext)] = e
+}
+
+// Supports reports whether the registry has an extractor for path.
+func (r *Registry) Supports(path string) bool {
+    if r == nil {
+        return false
+    }
+    _, ok := r.extractors[normalizeExt(filepath.Ext(path))]
+    return ok
+}
+
+// Extract reads the file at path and extracts its text within the limits
+// of the registry.
+func (r *Registry) Extract(path string) ([]Page, error) {
+    if !r.Supports(path) {
+        return nil, fmt.Errorf("no extractor for %s files", filepath.Ext(path))
+    }
+    e := r.extractors[normalizeExt(filepath.Ext(path))]
+    
+    info, err := os.Stat(path)
+    if err != nil {
+        return nil, err
+    }
+    if r.limits.MaxSize > 0 && info.Size() > r.limits.MaxSize {
+        return nil, fmt.Errorf("%w: %d bytes, the limit is %d", ErrTooLarge, info.Size(), r.limits.MaxSize)
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    
+    ctx := context.Background()
+    if r.limits.Timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, r.limits.Timeout)
+        defer cancel()
+    }
+    
+    // Extractors stop when ctx is done, so none is left running after
+    // the timeout
+    pages, err := e.Extract(ctx, data)
+    if ctx.Err() == context.DeadlineExceeded {
+        return nil, fmt.Errorf("%w after %s", ErrTimeout, r.limits.Timeout)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return truncate(pages, r.limits.MaxText), nil
+}
+
+// truncate drops the text of pages beyond max bytes, if max is positive.
+func truncate(pages []Page, max int) []Page {
+    if max <= 0 {
+        return pages
+    }
+    total := 0
+    for i, p := range pages {
+        if total+len(p.Text) > max {
+            p.Text = strings.ToValidUTF8(p.Text[:max-total], "")
+            return append(pages[:i:i], p)
+        }
+        total += len(p.Text)
+    }
+    return pages
+}
+
+func normalizeExt(ext string) string {
+    return "." + strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+// Join returns the text of pages separated by blank lines.
+func Join(pages []Page) string {
+    texts := make([]string, len(pages))
+    for i, p := range pages {
+        texts[i] = p.Text
+    }
+    return strings.Join(texts, "\n\n")
+}
\ No newline at end of file
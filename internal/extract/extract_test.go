@@ -0,0 +1,136 @@
+package extract
+
+import (
+    "bytes"
+    "compress/zlib"
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+// buildPDF writes a PDF with the given objects, numbered from 1, and a
+// trailer pointing to the catalog in object 1.
+func buildPDF(objects ...string) []byte {
+    var b bytes.Buffer
+    b.WriteString("%PDF-1.4\n")
+    for i, obj := range objects {
+        fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+    }
+    b.WriteString("trailer\n<< /Root 1 0 R /Size 99 >>\n%%EOF\n")
+    return b.Bytes()
+}
+
+func stream(dict, data string) string {
+    return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func flateStream(data string) string {
+    var b bytes.Buffer
+    w := zlib.NewWriter(&b)
+    w.Write([]byte(data))
+    w.Close()
+    return stream("/Filter /FlateDecode", b.String())
+}
+
+func TestPDF(t *testing.T) {
+    cmap := "/CIDInit /ProcSet findresource begin\n" +
+        "begincmap\n1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+        "2 beginbfchar <0001> <0048> <0002> <0069> endbfchar\n" +
+        "1 beginbfrange <0010> <0012> <00E4> endbfrange\n" +
+        "endcmap\n"
+    data := buildPDF(
+        "<< /Type /Catalog /Pages 2 0 R >>",
+        "<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>",
+        "<< /Type /Page /Parent 2 0 R /Contents 7 0 R >>",
+        "<< /Type /Page /Parent 2 0 R /Contents [8 0 R] >>",
+        "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+        "<< /Type /Font /Subtype /Type0 /Encoding /Identity-H /ToUnicode 9 0 R >>",
+        stream("", "BT /F1 12 Tf 72 720 Td (Quarterly \\(draft\\) report) Tj 0 -14 Td [(Caf) 250 (\\351) -400 (au lait)] TJ ET"),
+        flateStream("BT /F2 12 Tf 1 0 0 1 72 720 Tm <00010002> Tj 1 0 0 1 72 700 Tm <001000110012> Tj ET"),
+        flateStream(cmap),
+    )
+    
+    pages, err := PDF(context.Background(), data)
+    if err != nil {
+        t.Fatalf("PDF failed: %v", err)
+    }
+    want := []Page{
+        {Number: 1, Text: "Quarterly (draft) report\nCafé au lait"},
+        {Number: 2, Text: "Hi\näåæ"},
+    }
+    if !reflect.DeepEqual(pages, want) {
+        t.Errorf("PDF() =\n%q\nwant\n%q", pages, want)
+    }
+    
+    if _, err := PDF(context.Background(), []byte("just text")); err == nil {
+        t.Error("Expected a file that is not a PDF to fail")
+    }
+    encrypted := append(buildPDF("<< /Type /Catalog >>"), []byte("trailer << /Encrypt 5 0 R >>")...)
+    if _, err := PDF(context.Background(), encrypted); err == nil || !strings.Contains(err.Error(), "encrypted") {
+        t.Errorf("Expected encrypted PDFs to fail, got %v", err)
+    }
+}
+
+func TestHTML(t *testing.T) {
+    page := "<html><head><title>Recipes</title><style>p { color: red }</style></head>\n" +
+        "<body><h1>Pancakes</h1><!-- draft --><p>Flour &amp; eggs,\n   milk</p>" +
+        "<script>alert('x')</script><ul><li>Mix</li><li>Fry</li></ul></body></html>"
+    pages, err := HTML(context.Background(), []byte(page))
+    if err != nil {
+        t.Fatalf("HTML failed: %v", err)
+    }
+    want := "Recipes\nPancakes\nFlour & eggs,\nmilk\nMix\nFry"
+    if len(pages) != 1 || pages[0].Text != want {
+        t.Errorf("HTML() = %q, want %q", pages, want)
+    }
+}
+
+func TestRegistry(t *testing.T) {
+    dir := t.TempDir()
+    write := func(name, content string) string {
+        path := filepath.Join(dir, name)
+        os.WriteFile(path, []byte(content), 0644)
+        return path
+    }
+    
+    r := Default(Limits{MaxSize: 100, Timeout: 50 * time.Millisecond, MaxText: 12})
+    if !r.Supports("Notes/Export.TXT") || r.Supports("Note.md") {
+        t.Error("Expected text files to be supported and notes not")
+    }
+    var none *Registry
+    if none.Supports("a.txt") {
+        t.Error("Expected a nil registry to support nothing")
+    }
+    
+    pages, err := r.Extract(write("short.txt", "\uFEFFHello world, and more"))
+    if err != nil || len(pages) != 1 || pages[0].Text != "Hello world," {
+        t.Errorf("Expected the text cut to 12 bytes, got %q, %v", pages, err)
+    }
+    if _, err := r.Extract(write("big.txt", strings.Repeat("x", 101))); !errors.Is(err, ErrTooLarge) {
+        t.Errorf("Expected ErrTooLarge, got %v", err)
+    }
+    
+    // An extractor is cancelled after the timeout and not left running
+    stopped := false
+    r.Register("slow", ExtractorFunc(func(ctx context.Context, data []byte) ([]Page, error) {
+        <-ctx.Done()
+        stopped = true
+        return nil, ctx.Err()
+    }))
+    start := time.Now()
+    if _, err := r.Extract(write("a.slow", "x")); !errors.Is(err, ErrTimeout) {
+        t.Errorf("Expected ErrTimeout, got %v", err)
+    }
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Errorf("Expected extraction to give up after the timeout, took %s", elapsed)
+    }
+    if !stopped {
+        t.Error("Expected the extractor to have stopped")
+    }
+}
\ No newline at end of file
@@ -0,0 +1,886 @@
+package extract
+
+import (
+    "bytes"
+    "compress/zlib"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "unicode/utf16"
+    
+    "golang.org/x/text/encoding/charmap"
+)
+
+// PDF extracts the text of a PDF, a Page per page. Text is read from the
+// content streams of the pages, uncompressed or compressed with
+// FlateDecode, and mapped to Unicode through the ToUnicode maps of the
+// fonts or, for simple fonts without one, as WinAnsi. Objects are found by
+// scanning the file rather than through the cross-reference table, so
+// damaged files still yield their text. Encrypted PDFs are not supported.
+func PDF(ctx context.Context, data []byte) ([]Page, error) {
+    if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+        return nil, errors.New("not a PDF file")
+    }
+    doc, err := readPDF(ctx, data)
+    if err != nil {
+        return nil, err
+    }
+    if doc.encrypted {
+        return nil, errors.New("encrypted PDFs are not supported")
+    }
+    
+    var pages []Page
+    for i, page := range doc.pages() {
+        if err := ctx.Err(); err != nil {
+            return nil, err
+        }
+        pages = append(pages, Page{Number: i + 1, Text: doc.pageText(page)})
+    }
+    if len(pages) == 0 {
+        return nil, errors.New("no pages found")
+    }
+    return pages, nil
+}
+
+// PDF objects are parsed into these types, and into bool, float64, nil,
+// []interface{} and pdfString.
+type (
+    pdfName    string
+    pdfString  string
+    pdfKeyword string
+    pdfDict    map[pdfName]interface{}
+    
+    pdfRef struct {
+        num, gen int
+    }
+    
+    pdfStream struct {
+        dict pdfDict
+        data []byte
+    }
+)
+
+// maxStreamSize bounds the size of a decompressed stream.
+const maxStreamSize = 64 << 20
+
+type pdfDoc struct {
+    objects   map[int]interface{}
+    trailer   pdfDict
+    encrypted bool
+}
+
+var (
+    objHeader   = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+    trailerDict = regexp.MustCompile(`trailer\s*<<`)
+)
+
+// readPDF finds and parses every indirect object in data, including those
+// in object streams. Later definitions replace earlier ones, as with
+// incremental updates.
+func readPDF(ctx context.Context, data []byte) (*pdfDoc, error) {
+    doc := &pdfDoc{objects: make(map[int]interface{}), trailer: pdfDict{}}
+    for i, loc := range objHeader.FindAllSubmatchIndex(data, -1) {
+        if i%100 == 0 && ctx.Err() != nil {
+            return nil, ctx.Err()
+        }
+        num, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+        p := &pdfParser{data: data, pos: loc[1]}
+        obj, err := p.object()
+        if err != nil {
+            continue
+        }
+        if dict, ok := obj.(pdfDict); ok {
+            if stream, ok := p.stream(dict); ok {
+                obj = stream
+            }
+        }
+        doc.objects[num] = obj
+    }
+    
+    for _, loc := range trailerDict.FindAllIndex(data, -1) {
+        p := &pdfParser{data: data, pos: loc[1] - 2}
+        if obj, err := p.object(); err == nil {
+            dict, _ := obj.(pdfDict)
+            for k, v := range dict {
+                doc.trailer[k] = v
+            }
+        }
+    }
+    
+    // Objects in object streams, and the trailer entries of
+    // cross-reference streams
+    var nums []int
+    for num := range doc.objects {
+        nums = append(nums, num)
+    }
+    sort.Ints(nums)
+    for _, num := range nums {
+        stream, ok := doc.objects[num].(*pdfStream)
+        if !ok {
+            continue
+        }
+        switch stream.dict["Type"] {
+        case pdfName("ObjStm"):
+            if err := ctx.Err(); err != nil {
+                return nil, err
+            }
+            doc.readObjectStream(stream)
+        case pdfName("XRef"):
+            for _, key := range []pdfName{"Root", "Encrypt"} {
+                if v, ok := stream.dict[key]; ok {
+                    doc.trailer[key] = v
+                }
+            }
+        }
+    }
+    _, doc.encrypted = doc.trailer["Encrypt"]
+    return doc, nil
+}
+
+// readObjectStream adds the objects compressed into stream.
+func (d *pdfDoc) readObjectStream(stream *pdfStream) {
+    data, err := d.decode(stream)
+    if err != nil {
+        return
+    }
+    n, _ := d.resolve(stream.dict["N"]).(float64)
+    first, _ := d.resolve(stream.dict["First"]).(float64)
+    if int(first) > len(data) {
+        return
+    }
+    header := &pdfParser{data: data[:int(first)]}
+    for i := 0; i < int(n); i++ {
+        num, err1 := header.object()
+        offset, err2 := header.object()
+        if err1 != nil || err2 != nil {
+            return
+        }
+        objNum, _ := num.(float64)
+        off, _ := offset.(float64)
+        if _, ok := d.objects[int(objNum)]; ok {
+            continue
+        }
+        p := &pdfParser{data: data, pos: int(first) + int(off)}
+        if obj, err := p.object(); err == nil {
+            d.objects[int(objNum)] = obj
+        }
+    }
+}
+
+// resolve follows references until it reaches a direct object.
+func (d *pdfDoc) resolve(v interface{}) interface{} {
+    for i := 0; i < 32; i++ {
+        ref, ok := v.(pdfRef)
+        if !ok {
+            return v
+        }
+        v = d.objects[ref.num]
+    }
+    return nil
+}
+
+func (d *pdfDoc) dict(v interface{}) pdfDict {
+    switch v := d.resolve(v).(type) {
+    case pdfDict:
+        return v
+    case *pdfStream:
+        return v.dict
+    }
+    return nil
+}
+
+func (d *pdfDoc) array(v interface{}) []interface{} {
+    a, _ := d.resolve(v).([]interface{})
+    return a
+}
+
+// decode returns the data of stream with its filters undone. Only
+// FlateDecode is supported.
+func (d *pdfDoc) decode(stream *pdfStream) ([]byte, error) {
+    var filters []interface{}
+    switch f := d.resolve(stream.dict["Filter"]).(type) {
+    case pdfName:
+        filters = []interface{}{f}
+    case []interface{}:
+        filters = f
+    }
+    
+    data := stream.data
+    for _, f := range filters {
+        switch d.resolve(f) {
+        case pdfName("FlateDecode"), pdfName("Fl"):
+            r, err := zlib.NewReader(bytes.NewReader(data))
+            if err != nil {
+                return nil, err
+            }
+            // Keep what could be read from streams with a damaged end
+            out, err := io.ReadAll(io.LimitReader(r, maxStreamSize))
+            if err != nil && len(out) == 0 {
+                return nil, err
+            }
+            data = out
+        default:
+            return nil, fmt.Errorf("unsupported filter %v", f)
+        }
+    }
+    return data, nil
+}
+
+// pages returns the page dictionaries in order, from the page tree of the
+// document catalog or, without one, every page object in object order.
+func (d *pdfDoc) pages() []pdfDict {
+    var pages []pdfDict
+    seen := make(map[interface{}]bool)
+    var walk func(node interface{}, depth int)
+    walk = func(node interface{}, depth int) {
+        if ref, ok := node.(pdfRef); ok {
+            if seen[ref] {
+                return
+            }
+            seen[ref] = true
+        }
+        dict := d.dict(node)
+        if dict == nil || depth > 64 {
+            return
+        }
+        switch dict["Type"] {
+        case pdfName("Page"):
+            pages = append(pages, dict)
+        default:
+            for _, kid := range d.array(dict["Kids"]) {
+                walk(kid, depth+1)
+            }
+        }
+    }
+    if root := d.dict(d.trailer["Root"]); root != nil {
+        walk(root["Pages"], 0)
+    }
+    if len(pages) > 0 {
+        return pages
+    }
+    
+    var nums []int
+    for num := range d.objects {
+        nums = append(nums, num)
+    }
+    sort.Ints(nums)
+    for _, num := range nums {
+        if dict := d.dict(d.objects[num]); dict["Type"] == pdfName("Page") {
+            pages = append(pages, dict)
+        }
+    }
+    return pages
+}
+
+// inherited returns the value of key in page or the nearest of its parents
+// that has it.
+func (d *pdfDoc) inherited(page pdfDict, key pdfName) interface{} {
+    for i := 0; page != nil && i < 64; i++ {
+        if v, ok := page[key]; ok {
+            return v
+        }
+        page = d.dict(page["Parent"])
+    }
+    return nil
+}
+
+// pageText interprets the content streams of page and returns its text.
+func (d *pdfDoc) pageText(page pdfDict) string {
+    var content []byte
+    contents := d.resolve(page["Contents"])
+    if stream, ok := contents.(*pdfStream); ok {
+        contents = []interface{}{stream}
+    }
+    for _, c := range d.array(contents) {
+        if stream, ok := d.resolve(c).(*pdfStream); ok {
+            if data, err := d.decode(stream); err == nil {
+                content = append(append(content, data...), '\n')
+            }
+        }
+    }
+    
+    fonts := make(map[pdfName]*pdfFont)
+    resources := d.dict(d.inherited(page, "Resources"))
+    for name, ref := range d.dict(resources["Font"]) {
+        fonts[name] = d.font(d.dict(ref))
+    }
+    return textOf(content, fonts)
+}
+
+// pdfFont maps the character codes in strings shown with a font to text.
+type pdfFont struct {
+    toUnicode map[string]string
+    lengths   []int
+    composite bool
+}
+
+func (d *pdfDoc) font(dict pdfDict) *pdfFont {
+    f := &pdfFont{composite: dict["Subtype"] == pdfName("Type0")}
+    if stream, ok := d.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+        if data, err := d.decode(stream); err == nil {
+            f.toUnicode, f.lengths = parseCMap(data)
+        }
+    }
+    return f
+}
+
+// decode returns the text of the codes in s.
+func (f *pdfFont) decode(s string) string {
+    if f == nil || len(f.toUnicode) == 0 {
+        if f != nil && f.composite {
+            // Glyph IDs without a map to Unicode can't be read
+            return ""
+        }
+        text, _ := charmap.Windows1252.NewDecoder().String(s)
+        return text
+    }
+    
+    var out strings.Builder
+    for i := 0; i < len(s); {
+        matched := false
+        for _, n := range f.lengths {
+            if i+n <= len(s) {
+                if text, ok := f.toUnicode[s[i:i+n]]; ok {
+                    out.WriteString(text)
+                    i += n
+                    matched = true
+                    break
+                }
+            }
+        }
+        if !matched {
+            i += f.lengths[0]
+        }
+    }
+    return out.String()
+}
+
+// maxCMapRange bounds the codes a single bfrange entry may map.
+const maxCMapRange = 1 << 16
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode CMap and
+// the code lengths of its codespace ranges, shortest first.
+func parseCMap(data []byte) (map[string]string, []int) {
+    codes := make(map[string]string)
+    lengthSet := make(map[int]bool)
+    p := &pdfParser{data: data}
+    var operands []interface{}
+    for {
+        obj, err := p.object()
+        if err != nil {
+            break
+        }
+        kw, ok := obj.(pdfKeyword)
+        if !ok {
+            operands = append(operands, obj)
+            continue
+        }
+        switch kw {
+        case "endcodespacerange":
+            for _, v := range operands {
+                if s, ok := v.(pdfString); ok && len(s) > 0 {
+                    lengthSet[len(s)] = true
+                }
+            }
+        case "endbfchar":
+            for i := 0; i+1 < len(operands); i += 2 {
+                src, ok1 := operands[i].(pdfString)
+                dst, ok2 := operands[i+1].(pdfString)
+                if ok1 && ok2 {
+                    codes[string(src)] = utf16Text(string(dst))
+                    lengthSet[len(src)] = true
+                }
+            }
+        case "endbfrange":
+            for i := 0; i+2 < len(operands); i += 3 {
+                lo, ok1 := operands[i].(pdfString)
+                hi, ok2 := operands[i+1].(pdfString)
+                if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 {
+                    continue
+                }
+                lengthSet[len(lo)] = true
+                start, end := codeValue(string(lo)), codeValue(string(hi))
+                if end < start || end-start >= maxCMapRange {
+                    continue
+                }
+                for code := start; code <= end; code++ {
+                    key := codeBytes(code, len(lo))
+                    switch dst := operands[i+2].(type) {
+                    case pdfString:
+                        codes[key] = offsetText(string(dst), code-start)
+                    case []interface{}:
+                        if i := int(code - start); i < len(dst) {
+                            if s, ok := dst[i].(pdfString); ok {
+                                codes[key] = utf16Text(string(s))
+                            }
+                        }
+                    }
+                }
+            }
+        }
+        operands = operands[:0]
+    }
+    
+    var lengths []int
+    for n := range lengthSet {
+        lengths = append(lengths, n)
+    }
+    sort.Ints(lengths)
+    if len(lengths) == 0 {
+        lengths = []int{1}
+    }
+    return codes, lengths
+}
+
+func codeValue(s string) uint32 {
+    var v uint32
+    for i := 0; i < len(s); i++ {
+        v = v<<8 | uint32(s[i])
+    }
+    return v
+}
+
+func codeBytes(v uint32, n int) string {
+    b := make([]byte, n)
+    for i := n - 1; i >= 0; i-- {
+        b[i] = byte(v)
+        v >>= 8
+    }
+    return string(b)
+}
+
+// utf16Text decodes the UTF-16BE text of a CMap destination.
+func utf16Text(s string) string {
+    units := make([]uint16, 0, len(s)/2)
+    for i := 0; i+1 < len(s); i += 2 {
+        units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+    }
+    return string(utf16.Decode(units))
+}
+
+// offsetText returns the UTF-16BE text s with its last unit increased by
+// offset, for the codes of a bfrange after the first.
+func offsetText(s string, offset uint32) string {
+    if len(s) < 2 {
+        return ""
+    }
+    b := []byte(s)
+    last := uint32(b[len(b)-2])<<8 | uint32(b[len(b)-1])
+    last += offset
+    b[len(b)-2], b[len(b)-1] = byte(last>>8), byte(last)
+    return utf16Text(string(b))
+}
+
+// textOf interprets the text operators of a content stream. Moves to
+// another line start a new line of text, and wide gaps in TJ arrays become
+// spaces.
+func textOf(content []byte, fonts map[pdfName]*pdfFont) string {
+    var out strings.Builder
+    newline := func() {
+        if s := out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+            out.WriteByte('\n')
+        }
+    }
+    number := func(v interface{}) float64 {
+        f, _ := v.(float64)
+        return f
+    }
+    
+    var font *pdfFont
+    var lastY float64
+    var operands []interface{}
+    p := &pdfParser{data: content}
+    for {
+        obj, err := p.object()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            // Skip what can't be parsed, such as a stray delimiter
+            p.pos++
+            operands = operands[:0]
+            continue
+        }
+        kw, ok := obj.(pdfKeyword)
+        if !ok {
+            operands = append(operands, obj)
+            continue
+        }
+        
+        switch kw {
+        case "Tf":
+            if len(operands) >= 1 {
+                name, _ := operands[0].(pdfName)
+                font = fonts[name]
+            }
+        case "Tj":
+            if len(operands) >= 1 {
+                if s, ok := operands[len(operands)-1].(pdfString); ok {
+                    out.WriteString(font.decode(string(s)))
+                }
+            }
+        case "'", "\"":
+            newline()
+            if len(operands) >= 1 {
+                if s, ok := operands[len(operands)-1].(pdfString); ok {
+                    out.WriteString(font.decode(string(s)))
+                }
+            }
+        case "TJ":
+            if len(operands) >= 1 {
+                items, _ := operands[len(operands)-1].([]interface{})
+                for _, item := range items {
+                    switch v := item.(type) {
+                    case pdfString:
+                        out.WriteString(font.decode(string(v)))
+                    case float64:
+                        if v < -250 {
+                            out.WriteByte(' ')
+                        }
+                    }
+                }
+            }
+        case "Td", "TD":
+            if len(operands) >= 2 && number(operands[1]) != 0 {
+                newline()
+            } else if len(operands) >= 2 && number(operands[0]) > 0 {
+                out.WriteByte(' ')
+            }
+        case "T*":
+            newline()
+        case "Tm":
+            if len(operands) >= 6 {
+                if y := number(operands[5]); y != lastY {
+                    newline()
+                    lastY = y
+                } else {
+                    out.WriteByte(' ')
+                }
+            }
+        case "BI":
+            p.skipInlineImage()
+        }
+        operands = operands[:0]
+    }
+    
+    var lines []string
+    for _, line := range strings.Split(out.String(), "\n") {
+        if line = strings.Join(strings.Fields(line), " "); line != "" {
+            lines = append(lines, line)
+        }
+    }
+    return strings.Join(lines, "\n")
+}
+
+// pdfParser reads PDF objects and content stream operators.
+type pdfParser struct {
+    data []byte
+    pos  int
+}
+
+func isPDFSpace(c byte) bool {
+    return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+    return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (p *pdfParser) skipSpace() {
+    for p.pos < len(p.data) {
+        c := p.data[p.pos]
+        switch {
+        case isPDFSpace(c):
+            p.pos++
+        case c == '%':
+            for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+                p.pos++
+            }
+        default:
+            return
+        }
+    }
+}
+
+var errPDFSyntax = errors.New("invalid PDF syntax")
+
+// object reads the next object or operator. It returns io.EOF at the end
+// of the data.
+func (p *pdfParser) object() (interface{}, error) {
+    p.skipSpace()
+    if p.pos >= len(p.data) {
+        return nil, io.EOF
+    }
+    c := p.data[p.pos]
+    switch {
+    case c == '/':
+        p.pos++
+        return pdfName(p.name()), nil
+    case c == '(':
+        p.pos++
+        return p.literalString(), nil
+    case c == '<' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '<':
+        p.pos += 2
+        return p.dictionary()
+    case c == '<':
+        p.pos++
+        return p.hexString(), nil
+    case c == '[':
+        p.pos++
+        var items []interface{}
+        for {
+            p.skipSpace()
+            if p.pos >= len(p.data) {
+                return nil, errPDFSyntax
+            }
+            if p.data[p.pos] == ']' {
+                p.pos++
+                return items, nil
+            }
+            item, err := p.object()
+            if err != nil {
+                return nil, errPDFSyntax
+            }
+            items = append(items, item)
+        }
+    case c == '+' || c == '-' || c == '.' || c >= '0' && c <= '9':
+        return p.number(), nil
+    case isPDFDelimiter(c):
+        return nil, errPDFSyntax
+    }
+    
+    word := p.word()
+    switch word {
+    case "true":
+        return true, nil
+    case "false":
+        return false, nil
+    case "null":
+        return nil, nil
+    }
+    return pdfKeyword(word), nil
+}
+
+func (p *pdfParser) word() string {
+    start := p.pos
+    for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+        p.pos++
+    }
+    if p.pos == start {
+        p.pos++
+    }
+    return string(p.data[start:p.pos])
+}
+
+// name reads a name after its '/', undoing #xx escapes.
+func (p *pdfParser) name() string {
+    start := p.pos
+    for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+        p.pos++
+    }
+    name := string(p.data[start:p.pos])
+    if !strings.Contains(name, "#") {
+        return name
+    }
+    var b strings.Builder
+    for i := 0; i < len(name); i++ {
+        if name[i] == '#' && i+2 < len(name) {
+            if v, err := strconv.ParseUint(name[i+1:i+3], 16, 8); err == nil {
+                b.WriteByte(byte(v))
+                i += 2
+                continue
+            }
+        }
+        b.WriteByte(name[i])
+    }
+    return b.String()
+}
+
+// number reads a number, or a reference "num gen R".
+func (p *pdfParser) number() interface{} {
+    start := p.pos
+    p.pos++
+    for p.pos < len(p.data) && (p.data[p.pos] >= '0' && p.data[p.pos] <= '9' || p.data[p.pos] == '.') {
+        p.pos++
+    }
+    text := string(p.data[start:p.pos])
+    v, _ := strconv.ParseFloat(text, 64)
+    
+    if !strings.ContainsAny(text, ".+-") {
+        // Look ahead for the generation and R of a reference
+        save := p.pos
+        p.skipSpace()
+        genStart := p.pos
+        for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+            p.pos++
+        }
+        if p.pos > genStart {
+            gen, _ := strconv.Atoi(string(p.data[genStart:p.pos]))
+            p.skipSpace()
+            if p.pos < len(p.data) && p.data[p.pos] == 'R' &&
+                (p.pos+1 == len(p.data) || isPDFSpace(p.data[p.pos+1]) || isPDFDelimiter(p.data[p.pos+1])) {
+                p.pos++
+                return pdfRef{num: int(v), gen: gen}
+            }
+        }
+        p.pos = save
+    }
+    return v
+}
+
+func (p *pdfParser) literalString() pdfString {
+    var b []byte
+    depth := 1
+    for p.pos < len(p.data) {
+        c := p.data[p.pos]
+        p.pos++
+        switch c {
+        case '(':
+            depth++
+        case ')':
+            if depth--; depth == 0 {
+                return pdfString(b)
+            }
+        case '\\':
+            if p.pos >= len(p.data) {
+                return pdfString(b)
+            }
+            c = p.data[p.pos]
+            p.pos++
+            switch c {
+            case 'n':
+                c = '\n'
+            case 'r':
+                c = '\r'
+            case 't':
+                c = '\t'
+            case 'b':
+                c = '\b'
+            case 'f':
+                c = '\f'
+            case '\r':
+                // A line continuation
+                if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+                    p.pos++
+                }
+                continue
+            case '\n':
+                continue
+            default:
+                if c >= '0' && c <= '7' {
+                    v := int(c - '0')
+                    for i := 0; i < 2 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+                        v = v*8 + int(p.data[p.pos]-'0')
+                        p.pos++
+                    }
+                    c = byte(v)
+                }
+            }
+        }
+        b = append(b, c)
+    }
+    return pdfString(b)
+}
+
+func (p *pdfParser) hexString() pdfString {
+    var digits []byte
+    for p.pos < len(p.data) && p.data[p.pos] != '>' {
+        if c := p.data[p.pos]; !isPDFSpace(c) {
+            digits = append(digits, c)
+        }
+        p.pos++
+    }
+    p.pos++
+    if len(digits)%2 == 1 {
+        digits = append(digits, '0')
+    }
+    b := make([]byte, 0, len(digits)/2)
+    for i := 0; i < len(digits); i += 2 {
+        v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+        if err != nil {
+            break
+        }
+        b = append(b, byte(v))
+    }
+    return pdfString(b)
+}
+
+func (p *pdfParser) dictionary() (interface{}, error) {
+    dict := pdfDict{}
+    for {
+        p.skipSpace()
+        if p.pos+1 < len(p.data) && p.data[p.pos] == '>' && p.data[p.pos+1] == '>' {
+            p.pos += 2
+            return dict, nil
+        }
+        key, err := p.object()
+        if err != nil {
+            return nil, errPDFSyntax
+        }
+        name, ok := key.(pdfName)
+        if !ok {
+            return nil, errPDFSyntax
+        }
+        value, err := p.object()
+        if err != nil {
+            return nil, errPDFSyntax
+        }
+        dict[name] = value
+    }
+}
+
+// stream reads the data of a stream after its dictionary, if there is one.
+func (p *pdfParser) stream(dict pdfDict) (*pdfStream, bool) {
+    p.skipSpace()
+    if !bytes.HasPrefix(p.data[p.pos:], []byte("stream")) {
+        return nil, false
+    }
+    start := p.pos + len("stream")
+    if start < len(p.data) && p.data[start] == '\r' {
+        start++
+    }
+    if start < len(p.data) && p.data[start] == '\n' {
+        start++
+    }
+    
+    // Trust a direct Length only if endstream follows it
+    if length, ok := dict["Length"].(float64); ok && length >= 0 && start+int(length) <= len(p.data) {
+        end := start + int(length)
+        if bytes.HasPrefix(bytes.TrimLeft(p.data[end:], " \t\r\n"), []byte("endstream")) {
+            return &pdfStream{dict: dict, data: p.data[start:end]}, true
+        }
+    }
+    end := bytes.Index(p.data[start:], []byte("endstream"))
+    if end < 0 {
+        return nil, false
+    }
+    data := bytes.TrimRight(p.data[start:start+end], "\r\n")
+    return &pdfStream{dict: dict, data: data}, true
+}
+
+// skipInlineImage skips the data of an inline image after BI, up to and
+// including its EI.
+func (p *pdfParser) skipInlineImage() {
+    id := bytes.Index(p.data[p.pos:], []byte("ID"))
+    if id < 0 {
+        p.pos = len(p.data)
+        return
+    }
+    p.pos += id + 2
+    for p.pos < len(p.data) {
+        ei := bytes.Index(p.data[p.pos:], []byte("EI"))
+        if ei < 0 {
+            p.pos = len(p.data)
+            return
+        }
+        p.pos += ei + 2
+        if ei > 0 && isPDFSpace(p.data[p.pos-3]) && (p.pos == len(p.data) || isPDFSpace(p.data[p.pos])) {
+            return
+        }
+    }
+}
\ No newline at end of file
@@ -0,0 +1,53 @@
+package extract
+
+import (
+    "context"
+    "html"
+    "regexp"
+    "strings"
+)
+
+// Text extracts plain text files, which are expected in UTF-8. Invalid
+// bytes are dropped.
+func Text(_ context.Context, data []byte) ([]Page, error) {
+    text := strings.TrimPrefix(string(data), "\uFEFF")
+    return []Page{{Text: strings.ToValidUTF8(text, "")}}, nil
+}
+
+var (
+    // htmlHidden matches elements whose content is not shown as text.
+    htmlHidden = regexp.MustCompile(`(?is)<script\b.*?</script\s*>|<style\b.*?</style\s*>|<noscript\b.*?</noscript\s*>|<template\b.*?</template\s*>`)
+    
+    htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+    
+    // htmlBreak matches the tags that start a new line of text.
+    htmlBreak = regexp.MustCompile(`(?i)<(?:br|hr|/?(?:p|div|li|tr|td|th|h[1-6]|ul|ol|dl|dt|dd|table|section|article|header|footer|blockquote|pre|title))\b[^>]*>`)
+    
+    htmlTag = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// HTML extracts the text of an HTML page: the text between its tags, a
+// line per block element, without scripts, styles and comments.
+func HTML(ctx context.Context, data []byte) ([]Page, error) {
+    pages, _ := Text(ctx, data)
+    text := pages[0].Text
+    steps := []struct {
+        re          *regexp.Regexp
+        replacement string
+    }{{htmlComment, ""}, {htmlHidden, ""}, {htmlBreak, "\n"}, {htmlTag, ""}}
+    for _, step := range steps {
+        if err := ctx.Err(); err != nil {
+            return nil, err
+        }
+        text = step.re.ReplaceAllString(text, step.replacement)
+    }
+    text = html.UnescapeString(text)
+    
+    var lines []string
+    for _, line := range strings.Split(text, "\n") {
+        if line = strings.Join(strings.Fields(line), " "); line != "" {
+            lines = append(lines, line)
+        }
+    }
+    return []Page{{Text: strings.Join(lines, "\n")}}, nil
+}
\ No newline at end of file
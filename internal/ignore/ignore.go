@@ -0,0 +1,272 @@
+package ignore
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+// IgnoreFileName is a vault-level file with .gitignore syntax that only
+// affects the search index.
+const IgnoreFileName = ".searchignore"
+
+// DefaultPatterns are excluded unless re-included by a later "!" pattern.
+var DefaultPatterns = []string{
+    ".git/",
+    ".obsidian/",
+    ".trash/",
+}
+
+type rule struct {
+    pattern *regexp.Regexp
+    negate  bool
+    dirOnly bool
+}
+
+// Matcher decides which paths below a vault root are excluded from
+// indexing. It combines the default patterns, the vault's .gitignore and
+// ignore file, extra patterns from the configuration and Obsidian's
+// "Excluded files" setting.
+type Matcher struct {
+    root    string
+    rules   []rule
+    filters []string
+    regexps []*regexp.Regexp
+}
+
+// Load builds the matcher for the vault at root. Missing ignore files are
+// skipped; unreadable or invalid ones are reported.
+func Load(root string, patterns []string) (*Matcher, error) {
+    m := &Matcher{root: filepath.Clean(root)}
+    
+    if err := m.AddPatterns(DefaultPatterns...); err != nil {
+        return nil, err
+    }
+    
+    for _, name := range []string{".gitignore", IgnoreFileName} {
+        if err := m.addFile(filepath.Join(root, name)); err != nil {
+            return nil, err
+        }
+    }
+    
+    if err := m.AddPatterns(patterns...); err != nil {
+        return nil, err
+    }
+    
+    if err := m.addObsidianFilters(filepath.Join(root, ".obsidian", "app.json")); err != nil {
+        return nil, err
+    }
+    
+    return m, nil
+}
+
+// AddPatterns appends .gitignore-style patterns. Later patterns take
+// precedence over earlier ones.
+func (m *Matcher) AddPatterns(patterns ...string) error {
+    for _, p := range patterns {
+        r, ok, err := compile(p)
+        if err != nil {
+            return fmt.Errorf("invalid ignore pattern %q: %w", p, err)
+        }
+        if ok {
+            m.rules = append(m.rules, r)
+        }
+    }
+    return nil
+}
+
+func (m *Matcher) addFile(path string) error {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    
+    for i, line := range strings.Split(string(data), "\n") {
+        r, ok, err := compile(line)
+        if err != nil {
+            return fmt.Errorf("%s:%d: invalid pattern %q: %w", path, i+1, line, err)
+        }
+        if ok {
+            m.rules = append(m.rules, r)
+        }
+    }
+    return nil
+}
+
+// addObsidianFilters reads userIgnoreFilters from .obsidian/app.json. Obsidian
+// treats each entry as a path prefix, or as a regular expression when it is
+// wrapped in slashes.
+func (m *Matcher) addObsidianFilters(path string) error {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    
+    var app struct {
+        UserIgnoreFilters []string `json:"userIgnoreFilters"`
+    }
+    if err := json.Unmarshal(data, &app); err != nil {
+        return fmt.Errorf("failed to parse %s: %w", path, err)
+    }
+    
+    for _, filter := range app.UserIgnoreFilters {
+        if len(filter) > 2 && strings.HasPrefix(filter, "/") && strings.HasSuffix(filter, "/") {
+            re, err := regexp.Compile(filter[1 : len(filter)-1])
+            if err != nil {
+                return fmt.Errorf("%s: invalid excluded files pattern %q: %w", path, filter, err)
+            }
+            m.regexps = append(m.regexps, re)
+        } else if filter != "" {
+            m.filters = append(m.filters, filter)
+        }
+    }
+    return nil
+}
+
+// Root returns the vault root the matcher was loaded for, or "" for a nil
+// matcher.
+func (m *Matcher) Root() string {
+    if m == nil {
+        return ""
+    }
+    return m.root
+}
+
+// Match reports whether path should be left out of the index. Paths outside
+// the vault root are never ignored.
+func (m *Matcher) Match(path string, isDir bool) bool {
+    if m == nil {
+        return false
+    }
+    
+    rel, err := filepath.Rel(m.root, path)
+    if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+        return false
+    }
+    rel = filepath.ToSlash(rel)
+    
+    for _, filter := range m.filters {
+        if strings.HasPrefix(rel, filter) || strings.HasPrefix(rel+"/", filter) {
+            return true
+        }
+    }
+    for _, re := range m.regexps {
+        if re.MatchString(rel) {
+            return true
+        }
+    }
+    
+    // Like git, nothing below an excluded directory can be re-included
+    parts := strings.Split(rel, "/")
+    for i := 1; i < len(parts); i++ {
+        if m.matchRules(strings.Join(parts[:i], "/"), true) {
+            return true
+        }
+    }
+    return m.matchRules(rel, isDir)
+}
+
+func (m *Matcher) matchRules(rel string, isDir bool) bool {
+    ignored := false
+    for _, r := range m.rules {
+        if r.dirOnly && !isDir {
+            continue
+        }
+        if r.pattern.MatchString(rel) {
+            ignored = !r.negate
+        }
+    }
+    return ignored
+}
+
+// compile turns a single .gitignore line into a rule. It returns false for
+// blank lines and comments.
+func compile(line string) (rule, bool, error) {
+    line = strings.TrimRight(line, " \t\r")
+    if line == "" || strings.HasPrefix(line, "#") {
+        return rule{}, false, nil
+    }
+    
+    var r rule
+    if strings.HasPrefix(line, "!") {
+        r.negate = true
+        line = line[1:]
+    } else if strings.HasPrefix(line, `\`) {
+        line = line[1:]
+    }
+    
+    if strings.HasSuffix(line, "/") {
+        r.dirOnly = true
+        line = strings.TrimRight(line, "/")
+    }
+    if line == "" {
+        return rule{}, false, nil
+    }
+    
+    // A slash anywhere but the end anchors the pattern to the vault root
+    anchored := strings.Contains(line, "/")
+    line = strings.TrimPrefix(line, "/")
+    
+    expr := globToRegexp(line)
+    if anchored {
+        expr = "^" + expr + "$"
+    } else {
+        expr = "^(?:.*/)?" + expr + "$"
+    }
+    
+    re, err := regexp.Compile(expr)
+    if err != nil {
+        return rule{}, false, err
+    }
+    r.pattern = re
+    return r, true, nil
+}
+
+func globToRegexp(glob string) string {
+    var sb strings.Builder
+    for i := 0; i < len(glob); i++ {
+        c := glob[i]
+        switch {
+        case strings.HasPrefix(glob[i:], "**/"):
+            sb.WriteString("(?:.*/)?")
+            i += 2
+        case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+            sb.WriteString("/.*")
+            i += 2
+        case strings.HasPrefix(glob[i:], "**"):
+            sb.WriteString(".*")
+            i++
+        case c == '*':
+            sb.WriteString("[^/]*")
+        case c == '?':
+            sb.WriteString("[^/]")
+        case c == '[':
+            end := strings.IndexByte(glob[i+1:], ']')
+            if end < 0 {
+                sb.WriteString(`\[`)
+                continue
+            }
+            class := glob[i+1 : i+1+end]
+            if strings.HasPrefix(class, "!") {
+                class = "^" + class[1:]
+            }
+            sb.WriteString("[" + class + "]")
+            i += end + 1
+        case c == '\\' && i+1 < len(glob):
+            i++
+            sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+        default:
+            sb.WriteString(regexp.QuoteMeta(string(c)))
+        }
+    }
+    return sb.String()
+}
\ No newline at end of file
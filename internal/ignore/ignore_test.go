@@ -0,0 +1,87 @@
+package ignore
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestDefaultPatterns(t *testing.T) {
+    root := t.TempDir()
+    m, err := Load(root, nil)
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    
+    tests := []struct {
+        path    string
+        isDir   bool
+        ignored bool
+    }{
+        {".obsidian", true, true},
+        {".obsidian/workspace.md", false, true},
+        {".trash/old.md", false, true},
+        {".git/HEAD", false, true},
+        {"Projects/.git", true, true},
+        {".github-notes/ci.md", false, false},
+        {"digit.git-notes/note.md", false, false},
+        {"Notes/today.md", false, false},
+        {"...archive/.git/HEAD", false, true},
+        {"..notes/.trash/old.md", false, true},
+        {"../elsewhere/.git/HEAD", false, false},
+    }
+    
+    for _, tt := range tests {
+        if got := m.Match(filepath.Join(root, tt.path), tt.isDir); got != tt.ignored {
+            t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.ignored)
+        }
+    }
+}
+
+func TestIgnoreSources(t *testing.T) {
+    root := t.TempDir()
+    os.MkdirAll(filepath.Join(root, ".obsidian"), 0755)
+    os.WriteFile(filepath.Join(root, ".gitignore"), []byte("# build output\n*.tmp.md\n/drafts/\n"), 0644)
+    os.WriteFile(filepath.Join(root, IgnoreFileName), []byte("Archive/**\n!Archive/keep.md\n"), 0644)
+    os.WriteFile(filepath.Join(root, ".obsidian", "app.json"),
+        []byte(`{"userIgnoreFilters": ["Templates/", "/^Daily/\\d{4}/"]}`), 0644)
+    
+    m, err := Load(root, []string{"private-*.md"})
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    
+    tests := []struct {
+        path    string
+        ignored bool
+    }{
+        {"scratch.tmp.md", true},
+        {"sub/scratch.tmp.md", true},
+        {"drafts/idea.md", true},
+        {"sub/drafts/idea.md", false},
+        {"Archive/2020.md", true},
+        {"Archive/keep.md", false},
+        {"private-diary.md", true},
+        {"Work/private-diary.md", true},
+        {"Templates/Meeting.md", true},
+        {"Daily/2024/2024-01-01.md", true},
+        {"Daily/index.md", false},
+        {"Projects/plan.md", false},
+    }
+    
+    for _, tt := range tests {
+        if got := m.Match(filepath.Join(root, tt.path), false); got != tt.ignored {
+            t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.ignored)
+        }
+    }
+}
+
+func TestInvalidPattern(t *testing.T) {
+    root := t.TempDir()
+    os.MkdirAll(filepath.Join(root, ".obsidian"), 0755)
+    os.WriteFile(filepath.Join(root, ".obsidian", "app.json"), []byte(`{"userIgnoreFilters": ["/[/"]}`), 0644)
+    
+    if _, err := Load(root, nil); err == nil {
+        t.Error("Expected an error for an invalid excluded files regex")
+    }
+}
\ No newline at end of file
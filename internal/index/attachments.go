@@ -0,0 +1,230 @@
+package index
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/extract"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+)
+
+// isNote reports whether path is a note or a canvas, the files the index
+// reads itself.
+func isNote(path string) bool {
+    return strings.HasSuffix(path, ".md") || isCanvas(path)
+}
+
+// isAttachment reports whether path is an attachment whose text is
+// extracted by one of the extractors of the index.
+func (ix *Index) isAttachment(path string) bool {
+    return !isNote(path) && ix.attachments.Supports(path)
+}
+
+// extractedText is the text extracted from an attachment when it was
+// indexed, or why that failed.
+type extractedText struct {
+    Modified time.Time      `json:"modified"`
+    Pages    []extract.Page `json:"pages,omitempty"`
+    Err      string         `json:"err,omitempty"`
+}
+
+// attachmentStore keeps the extracted text of every indexed attachment in a
+// file of its own below dir, so that searches and links don't extract it
+// again. Unlike the lists of notes it is not held in memory, as the text of
+// a single attachment can take megabytes.
+type attachmentStore struct {
+    dir string
+}
+
+// attachmentDir is the directory of the attachment store in the index.
+const attachmentDir = ".attachments"
+
+func (s attachmentStore) file(path string) string {
+    sum := sha256.Sum256([]byte(path))
+    return filepath.Join(s.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+func (s attachmentStore) put(path string, text extractedText) error {
+    data, err := json.Marshal(text)
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(s.dir, 0755); err != nil {
+        return err
+    }
+    
+    // Write and rename so a crash never leaves a truncated file
+    file := s.file(path)
+    tmp := file + ".tmp"
+    if err := os.WriteFile(tmp, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, file)
+}
+
+func (s attachmentStore) get(path string) (extractedText, bool) {
+    var text extractedText
+    data, err := os.ReadFile(s.file(path))
+    if err != nil || json.Unmarshal(data, &text) != nil {
+        return extractedText{}, false
+    }
+    return text, true
+}
+
+func (s attachmentStore) remove(path string) {
+    os.Remove(s.file(path))
+}
+
+// attachmentPages returns the pages of the attachment at path. They are
+// read from the store, unless the attachment changed since it was indexed
+// and is extracted again.
+func (ix *Index) attachmentPages(path string) ([]extract.Page, error) {
+    if text, ok := ix.extracted.get(path); ok {
+        if info, err := os.Stat(path); err == nil && info.ModTime().Equal(text.Modified) {
+            if text.Err != "" {
+                return nil, errors.New(text.Err)
+            }
+            return text.Pages, nil
+        }
+    }
+    return ix.attachments.Extract(path)
+}
+
+// readText reads the text of the note, canvas or attachment at path, see
+// readNote.
+func (ix *Index) readText(path string) (markdown.Frontmatter, string, int, error) {
+    if !ix.isAttachment(path) {
+        return readNote(path)
+    }
+    pages, err := ix.attachmentPages(path)
+    if err != nil {
+        return nil, "", 0, err
+    }
+    return nil, analysis.Normalize(extract.Join(pages)), 0, nil
+}
+
+// indexAttachment indexes the extracted text of the attachment at path and
+// keeps it in the attachment store. An attachment whose text can't be
+// extracted, because it is too large, takes too long or is damaged, is
+// indexed by its name alone, so it isn't tried again until it changes.
+func (ix *Index) indexAttachment(path string, info os.FileInfo) error {
+    pages, err := ix.attachments.Extract(path)
+    extracted := extractedText{Modified: info.ModTime(), Pages: pages}
+    if err != nil {
+        slog.Warn("Failed to extract the text of an attachment", "path", ix.vaultPath(path), "err", err)
+        extracted.Err = err.Error()
+    }
+    if err := ix.extracted.put(path, extracted); err != nil {
+        return fmt.Errorf("failed to store the text of %s: %w", ix.vaultPath(path), err)
+    }
+    text := analysis.Normalize(extract.Join(pages))
+    title := filepath.Base(path)
+    
+    doc := &document{
+        path:     path,
+        lang:     ix.detector.Resolve("", text),
+        title:    title,
+        content:  text,
+        pathText: analysis.Normalize(ix.relativePath(path)),
+        names:    noteNames(title, nil, path),
+        modified: info.ModTime(),
+        created:  info.ModTime(),
+    }
+    if err := ix.engine.add(doc); err != nil {
+        return err
+    }
+    
+    ix.terms.add(path, title+"\n"+text)
+    ix.tasks.remove(path)
+    ix.properties.remove(path)
+    ix.refs.remove(path)
+    
+    ix.regMu.Lock()
+    ix.lastIndexed[path] = info.ModTime()
+    ix.regMu.Unlock()
+    
+    return nil
+}
+
+// attachmentSnippet returns the lines of the attachment at path that
+// matcher matches. Lines of documents with pages, such as PDFs, are shown
+// after their page number and the numbers of the matching pages are
+// returned; other documents are shown like notes, with the numbers of the
+// matching lines of their text. The text is that stored when the
+// attachment was indexed, as searches must not wait for extraction.
+func (ix *Index) attachmentSnippet(path string, matcher *query.Matcher, maxLength int) (string, []int, []int) {
+    text, ok := ix.extracted.get(path)
+    pages := text.Pages
+    if !ok || matcher == nil || len(pages) == 0 {
+        return "", []int{}, nil
+    }
+    if len(pages) == 1 && pages[0].Number == 0 {
+        snippet, lines := ix.createSnippet(pages[0].Text, matcher, maxLength)
+        return snippet, lines, nil
+    }
+    
+    var lines []string
+    var numbers []int
+    for _, p := range pages {
+        for _, line := range strings.Split(p.Text, "\n") {
+            lines = append(lines, line)
+            numbers = append(numbers, p.Number)
+        }
+    }
+    
+    best, matched := matchLines(lines, matcher)
+    var parts []string
+    for _, n := range best {
+        parts = append(parts, fmt.Sprintf("p. %d: %s", numbers[n-1], lines[n-1]))
+    }
+    var matchedPages []int
+    for _, n := range matched {
+        if len(matchedPages) == 0 || matchedPages[len(matchedPages)-1] != numbers[n-1] {
+            matchedPages = append(matchedPages, numbers[n-1])
+        }
+    }
+    
+    snippet := strings.Join(parts, "\n")
+    if runes := []rune(snippet); len(runes) > maxLength {
+        snippet = string(runes[:maxLength]) + "..."
+    }
+    return snippet, []int{}, matchedPages
+}
+
+// resolveAttachment sets the text of r to that of the attachment at file,
+// or of the page that a subpath "page=N" names, the way Obsidian links to
+// the pages of PDFs.
+func (ix *Index) resolveAttachment(r *Reference, file, subpath string) (*Reference, error) {
+    pages, err := ix.attachmentPages(file)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read %s: %w", r.Path, err)
+    }
+    r.Line = 1
+    if subpath == "" {
+        r.Text = extract.Join(pages)
+        return r, nil
+    }
+    
+    number, err := strconv.Atoi(strings.TrimPrefix(subpath, "page="))
+    if !strings.HasPrefix(subpath, "page=") || err != nil {
+        return nil, fmt.Errorf("%s has no headings or blocks, link to a page with #page=N", r.Path)
+    }
+    for _, p := range pages {
+        if p.Number == number {
+            r.Text, r.Page = p.Text, p.Number
+            return r, nil
+        }
+    }
+    return nil, fmt.Errorf("no page %d in %s", number, r.Path)
+}
\ No newline at end of file
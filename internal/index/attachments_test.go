@@ -0,0 +1,156 @@
+package index
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/extract"
+)
+
+// twoPagePDF returns an uncompressed PDF with a page of text per line.
+func twoPagePDF(first, second string) string {
+    objects := []string{
+        "<< /Type /Catalog /Pages 2 0 R >>",
+        "<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R >> >> >>",
+        "<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>",
+        "<< /Type /Page /Parent 2 0 R /Contents 7 0 R >>",
+        "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+    }
+    for _, text := range []string{first, second} {
+        content := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+        objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+    }
+    var b strings.Builder
+    b.WriteString("%PDF-1.4\n")
+    for i, obj := range objects {
+        fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+    }
+    b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+    return b.String()
+}
+
+func TestAttachments(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(filepath.Join(vaultPath, "Attachments"), 0755)
+    manual := filepath.Join(vaultPath, "Attachments", "manual.pdf")
+    os.WriteFile(manual, []byte(twoPagePDF("Installing the boiler", "Bleeding the radiators")), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Attachments", "export.txt"), []byte("Meter readings\nradiators 42\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Attachments", "huge.txt"), []byte(strings.Repeat("radiators ", 500)), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Attachments", "photo.png"), []byte("radiators"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Heating.md"), []byte("See ![[manual.pdf#page=2]]\n"), 0644)
+    
+    opts := DefaultOptions()
+    opts.Backend = BackendBM25
+    opts.Attachments = extract.Default(extract.Limits{MaxSize: 1000, Timeout: time.Second})
+    index, err := Open(filepath.Join(t.TempDir(), "index"), opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    defer index.Close()
+    if err := index.IndexDirectory(vaultPath, 2); err != nil {
+        t.Fatalf("Failed to index directory: %v", err)
+    }
+    if got := index.GetIndexedFilesCount(); got != 4 {
+        t.Errorf("Expected the note and three attachments to be indexed, got %d", got)
+    }
+    
+    results, err := index.Search("radiators", 10)
+    if err != nil {
+        t.Fatalf("Search failed: %v", err)
+    }
+    byPath := make(map[string]SearchResult)
+    for _, r := range results {
+        byPath[index.vaultPath(r.FilePath)] = r
+    }
+    if len(byPath) != 2 {
+        t.Errorf("Expected the PDF and the text file, the one too large only by name, got %+v", results)
+    }
+    pdf := byPath["Attachments/manual.pdf"]
+    if !reflect.DeepEqual(pdf.Pages, []int{2}) || pdf.Snippet != "p. 2: Bleeding the radiators" {
+        t.Errorf("Expected the page in the snippet, got %+v", pdf)
+    }
+    txt := byPath["Attachments/export.txt"]
+    if !reflect.DeepEqual(txt.LineNumbers, []int{2}) || txt.Snippet != "L2: radiators 42" || len(txt.Pages) != 0 {
+        t.Errorf("Expected the line in the snippet, got %+v", txt)
+    }
+    if results, _ := index.Search("huge", 10); len(results) != 1 {
+        t.Errorf("Expected the attachment too large to read to be found by name, got %+v", results)
+    }
+    
+    // Embeds of attachments don't pull their text into notes, but links
+    // to them resolve
+    if results, _ := index.Search("boiler", 10); len(results) != 1 {
+        t.Errorf("Expected only the PDF, got %+v", results)
+    }
+    r, err := index.Resolve("[[manual.pdf#page=2]]", "")
+    if err != nil || r.Text != "Bleeding the radiators" || r.Page != 2 {
+        t.Errorf("Expected the second page, got %+v, %v", r, err)
+    }
+    if _, err := index.Resolve("manual.pdf#page=3", ""); err == nil || !strings.Contains(err.Error(), "no page 3") {
+        t.Errorf("Expected a missing page to fail, got %v", err)
+    }
+}
+
+func TestAttachmentsExtractedOnce(t *testing.T) {
+    vaultPath := t.TempDir()
+    export := filepath.Join(vaultPath, "export.txt")
+    os.WriteFile(export, []byte("Meter readings\nradiators 42\n"), 0644)
+    
+    extractions := 0
+    text, _ := extract.Builtin("txt")
+    registry := extract.NewRegistry(extract.DefaultLimits)
+    registry.Register("txt", extract.ExtractorFunc(func(ctx context.Context, data []byte) ([]extract.Page, error) {
+        extractions++
+        return text.Extract(ctx, data)
+    }))
+    
+    opts := DefaultOptions()
+    opts.Backend = BackendBM25
+    opts.Attachments = registry
+    index, err := Open(filepath.Join(t.TempDir(), "index"), opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    defer index.Close()
+    if err := index.IndexDirectory(vaultPath, 1); err != nil {
+        t.Fatalf("Failed to index directory: %v", err)
+    }
+    
+    // Searches and links read the text stored when indexing
+    for i := 0; i < 3; i++ {
+        results, err := index.Search("radiators", 10)
+        if err != nil || len(results) != 1 || results[0].Snippet != "L2: radiators 42" {
+            t.Fatalf("Expected the attachment with its snippet, got %+v, %v", results, err)
+        }
+    }
+    if r, err := index.Resolve("[[export.txt]]", ""); err != nil || r.Text != "Meter readings\nradiators 42\n" {
+        t.Errorf("Expected the text of the attachment, got %+v, %v", r, err)
+    }
+    if extractions != 1 {
+        t.Errorf("Expected the attachment to be extracted once, got %d", extractions)
+    }
+    
+    // An attachment changed since it was indexed is extracted again for
+    // links, but searches keep to the indexed text
+    os.WriteFile(export, []byte("Meter readings\nboiler 7\n"), 0644)
+    os.Chtimes(export, time.Now(), time.Now().Add(time.Minute))
+    if r, err := index.Resolve("[[export.txt]]", ""); err != nil || r.Text != "Meter readings\nboiler 7\n" {
+        t.Errorf("Expected the changed text, got %+v, %v", r, err)
+    }
+    if results, _ := index.Search("radiators", 10); len(results) != 1 || results[0].Snippet != "L2: radiators 42" || extractions != 2 {
+        t.Errorf("Expected the indexed snippet without extracting, got %+v after %d extractions", results, extractions)
+    }
+    
+    if err := index.RemoveFile(export); err != nil {
+        t.Fatalf("Failed to remove file: %v", err)
+    }
+    if _, ok := index.extracted.get(export); ok {
+        t.Error("Expected the stored text to be removed with the attachment")
+    }
+}
\ No newline at end of file
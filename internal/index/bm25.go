@@ -0,0 +1,470 @@
+package index
+
+import (
+    "encoding/gob"
+    "errors"
+    "fmt"
+    "math"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+)
+
+// BM25 parameters, the usual defaults that Tantivy and Lucene use as well.
+const (
+    bm25K1 = 1.2
+    bm25B  = 0.75
+)
+
+// valueGap separates the values of a field with several values, such as
+// aliases, so a phrase does not match across two of them.
+const valueGap = 100
+
+// bm25Field is the inverted index of one field.
+type bm25Field struct {
+    // Postings maps a word to the notes containing it and its positions.
+    Postings map[string]map[string][]int
+    // Lengths holds the number of words of every note with the field.
+    Lengths map[string]int
+    Total   int
+}
+
+// bm25Doc is what a hit needs besides its score.
+type bm25Doc struct {
+    Lang     string
+    Modified time.Time
+    Created  time.Time
+    Names    []string
+}
+
+// bm25Data is the part of a bm25Engine that is stored in .bm25.
+type bm25Data struct {
+    Docs   map[string]bm25Doc
+    Fields map[string]*bm25Field
+}
+
+// bm25Engine is a pure-Go inverted index ranked by BM25. It has a field
+// for every name in BoostFields, folds words like the rest of the index
+// but does not stem them. It is kept in memory and written to .bm25 in the
+// index directory on flush.
+type bm25Engine struct {
+    mu    sync.RWMutex
+    file  string
+    dirty bool
+    data  bm25Data
+}
+
+func newBM25Engine(indexPath string) (engine, error) {
+    e := &bm25Engine{
+        file: filepath.Join(indexPath, ".bm25"),
+        data: bm25Data{
+            Docs:   make(map[string]bm25Doc),
+            Fields: make(map[string]*bm25Field),
+        },
+    }
+    
+    f, err := os.Open(e.file)
+    if errors.Is(err, os.ErrNotExist) {
+        return e, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to open index: %w", err)
+    }
+    defer f.Close()
+    
+    var data bm25Data
+    if err := gob.NewDecoder(f).Decode(&data); err != nil {
+        // A damaged index is rebuilt from the vault
+        return e, nil
+    }
+    if data.Docs != nil && data.Fields != nil {
+        e.data = data
+    }
+    return e, nil
+}
+
+// fieldValues returns the values of every field of doc.
+func fieldValues(doc *document) map[string][]string {
+    return map[string][]string{
+        "title":    {doc.title},
+        "aliases":  doc.aliases,
+        "headings": doc.headings,
+        "tags":     doc.tags,
+        "path":     {doc.pathText},
+        "content":  {doc.content},
+        "urls":     doc.urls,
+        "code":     doc.code,
+        // Filters, not searched as text
+        "code_lang":    doc.codeLangs,
+        "callout_type": doc.calloutTypes,
+    }
+}
+
+// fieldTokens splits a value of the field name into words: code keeps its
+// identifiers whole, and filters match their values as they are.
+func fieldTokens(name, value string) []string {
+    switch name {
+    case "code":
+        return analysis.TokenizeCode(value)
+    case "code_lang", "callout_type":
+        return []string{value}
+    }
+    return analysis.Tokenize(value)
+}
+
+func (e *bm25Engine) add(doc *document) error {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    
+    e.removeLocked(doc.path)
+    e.data.Docs[doc.path] = bm25Doc{
+        Lang:     doc.lang,
+        Modified: doc.modified,
+        Created:  doc.created,
+        Names:    doc.names,
+    }
+    for name, values := range fieldValues(doc) {
+        field := e.data.Fields[name]
+        if field == nil {
+            field = &bm25Field{
+                Postings: make(map[string]map[string][]int),
+                Lengths:  make(map[string]int),
+            }
+            e.data.Fields[name] = field
+        }
+        
+        pos, length := 0, 0
+        for _, value := range values {
+            words := fieldTokens(name, value)
+            for i, word := range words {
+                postings := field.Postings[word]
+                if postings == nil {
+                    postings = make(map[string][]int)
+                    field.Postings[word] = postings
+                }
+                postings[doc.path] = append(postings[doc.path], pos+i)
+            }
+            pos += len(words) + valueGap
+            length += len(words)
+        }
+        if length > 0 {
+            field.Lengths[doc.path] = length
+            field.Total += length
+        }
+    }
+    e.dirty = true
+    return nil
+}
+
+func (e *bm25Engine) remove(path string) error {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    e.removeLocked(path)
+    return nil
+}
+
+func (e *bm25Engine) removeLocked(path string) {
+    if _, ok := e.data.Docs[path]; !ok {
+        return
+    }
+    delete(e.data.Docs, path)
+    for _, field := range e.data.Fields {
+        length, ok := field.Lengths[path]
+        if !ok {
+            continue
+        }
+        delete(field.Lengths, path)
+        field.Total -= length
+        for word, postings := range field.Postings {
+            if _, ok := postings[path]; ok {
+                delete(postings, path)
+                if len(postings) == 0 {
+                    delete(field.Postings, word)
+                }
+            }
+        }
+    }
+    e.dirty = true
+}
+
+func (e *bm25Engine) search(q query.Node, boosts map[string]float32, limit int) ([]scoredResult, error) {
+    e.mu.RLock()
+    defer e.mu.RUnlock()
+    
+    scores := e.evaluate(q, boosts)
+    hits := make([]scoredResult, 0, len(scores))
+    for path, score := range scores {
+        doc := e.data.Docs[path]
+        hits = append(hits, scoredResult{
+            path:     path,
+            lang:     doc.Lang,
+            score:    score,
+            modified: doc.Modified,
+            created:  doc.Created,
+        })
+    }
+    sort.Slice(hits, func(i, j int) bool {
+        if hits[i].score != hits[j].score {
+            return hits[i].score > hits[j].score
+        }
+        return hits[i].path < hits[j].path
+    })
+    if len(hits) > limit {
+        hits = hits[:limit]
+    }
+    return hits, nil
+}
+
+// evaluate returns the notes matching node and their scores.
+func (e *bm25Engine) evaluate(node query.Node, boosts map[string]float32) map[string]float64 {
+    switch n := node.(type) {
+    case *query.Term:
+        scores := e.phraseScores(n.Text, 0, boosts)
+        if n.Boost > 0 {
+            for path := range scores {
+                scores[path] *= n.Boost
+            }
+        }
+        return scores
+    case *query.Phrase:
+        return e.phraseScores(strings.Join(n.Words, " "), n.Slop, boosts)
+    case *query.Field:
+        // Filters select notes but don't add to their score
+        scores := make(map[string]float64)
+        if field := e.data.Fields[n.Name]; field != nil {
+            for path := range field.Postings[n.Value] {
+                scores[path] = 0
+            }
+        }
+        return scores
+    case *query.Bool:
+        return e.boolScores(n, boosts)
+    }
+    return nil
+}
+
+// boolScores combines the clauses of a Bool the way Tantivy does: without
+// a Must clause at least one Should clause has to match, with one the
+// Should clauses only add to the score.
+func (e *bm25Engine) boolScores(b *query.Bool, boosts map[string]float32) map[string]float64 {
+    var must, should []map[string]float64
+    excluded := make(map[string]bool)
+    for _, clause := range b.Clauses {
+        scores := e.evaluate(clause.Node, boosts)
+        switch clause.Occur {
+        case query.Must:
+            must = append(must, scores)
+        case query.Should:
+            should = append(should, scores)
+        case query.MustNot:
+            for path := range scores {
+                excluded[path] = true
+            }
+        }
+    }
+    
+    result := make(map[string]float64)
+    if len(must) > 0 {
+        for path, score := range must[0] {
+            result[path] = score
+        }
+        for _, scores := range must[1:] {
+            for path := range result {
+                score, ok := scores[path]
+                if !ok {
+                    delete(result, path)
+                    continue
+                }
+                result[path] += score
+            }
+        }
+        for _, scores := range should {
+            for path := range result {
+                result[path] += scores[path]
+            }
+        }
+    } else {
+        for _, scores := range should {
+            for path, score := range scores {
+                result[path] += score
+            }
+        }
+    }
+    
+    for path := range excluded {
+        delete(result, path)
+    }
+    return result
+}
+
+// phraseScores scores the notes containing the words of text in order,
+// with at most slop words in between in total, summed over the searched
+// fields. Every field splits text into words the way it splits its values.
+// A single word is a phrase of one.
+func (e *bm25Engine) phraseScores(text string, slop int, boosts map[string]float32) map[string]float64 {
+    scores := make(map[string]float64)
+    total := float64(len(e.data.Docs))
+    for _, name := range BoostFields {
+        boost, ok := boosts[name]
+        if !ok {
+            boost = 1
+        }
+        field := e.data.Fields[name]
+        if boost == 0 || field == nil || len(field.Lengths) == 0 {
+            continue
+        }
+        words := fieldTokens(name, text)
+        if len(words) == 0 {
+            continue
+        }
+        
+        postings := make([]map[string][]int, len(words))
+        idf := 0.0
+        for i, word := range words {
+            postings[i] = field.Postings[word]
+            if len(postings[i]) == 0 {
+                postings = nil
+                break
+            }
+            df := float64(len(postings[i]))
+            idf += math.Log(1 + (total-df+0.5)/(df+0.5))
+        }
+        if postings == nil {
+            continue
+        }
+        
+        avgLength := float64(field.Total) / float64(len(field.Lengths))
+        for path, positions := range postings[0] {
+            tf := len(positions)
+            if len(words) > 1 {
+                tf = phraseFreq(postings, path, slop)
+            }
+            if tf == 0 {
+                continue
+            }
+            norm := bm25K1 * (1 - bm25B + bm25B*float64(field.Lengths[path])/avgLength)
+            freq := float64(tf)
+            scores[path] += float64(boost) * idf * freq * (bm25K1 + 1) / (freq + norm)
+        }
+    }
+    return scores
+}
+
+// phraseFreq counts the positions in path where the words of postings
+// start a match.
+func phraseFreq(postings []map[string][]int, path string, slop int) int {
+    positions := make([][]int, len(postings))
+    for i, p := range postings {
+        positions[i] = p[path]
+        if len(positions[i]) == 0 {
+            return 0
+        }
+    }
+    
+    count := 0
+    for _, start := range positions[0] {
+        if matchPositions(positions, 1, start+1, slop) {
+            count++
+        }
+    }
+    return count
+}
+
+func matchPositions(positions [][]int, word, next, slop int) bool {
+    if word == len(positions) {
+        return true
+    }
+    for _, pos := range positions[word] {
+        if pos >= next && pos-next <= slop && matchPositions(positions, word+1, pos+1, slop-(pos-next)) {
+            return true
+        }
+    }
+    return false
+}
+
+func (e *bm25Engine) complete(prefix string, limit int) ([]nameMatch, error) {
+    words := analysis.Tokenize(prefix)
+    if len(words) == 0 {
+        return nil, nil
+    }
+    
+    e.mu.RLock()
+    defer e.mu.RUnlock()
+    
+    var matches []nameMatch
+    for path, doc := range e.data.Docs {
+        if len(doc.Names) > 0 && startsWords(words, analysis.Tokenize(strings.Join(doc.Names, "\n"))) {
+            matches = append(matches, nameMatch{path: path, names: doc.Names})
+        }
+    }
+    sort.Slice(matches, func(i, j int) bool {
+        return matches[i].path < matches[j].path
+    })
+    if len(matches) > limit {
+        matches = matches[:limit]
+    }
+    return matches, nil
+}
+
+// startsWords reports whether every word of prefixes starts one of words.
+func startsWords(prefixes, words []string) bool {
+    for _, prefix := range prefixes {
+        found := false
+        for _, word := range words {
+            if strings.HasPrefix(word, prefix) {
+                found = true
+                break
+            }
+        }
+        if !found {
+            return false
+        }
+    }
+    return true
+}
+
+func (e *bm25Engine) numDocs() (uint64, error) {
+    e.mu.RLock()
+    defer e.mu.RUnlock()
+    return uint64(len(e.data.Docs)), nil
+}
+
+// flush writes the index to .bm25 if it changed. It writes to a temporary
+// file first so a crash never leaves a truncated index behind.
+func (e *bm25Engine) flush() error {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    if !e.dirty {
+        return nil
+    }
+    
+    tmp := e.file + ".tmp"
+    f, err := os.Create(tmp)
+    if err != nil {
+        return err
+    }
+    if err := gob.NewEncoder(f).Encode(&e.data); err != nil {
+        f.Close()
+        os.Remove(tmp)
+        return err
+    }
+    if err := f.Close(); err != nil {
+        os.Remove(tmp)
+        return err
+    }
+    if err := os.Rename(tmp, e.file); err != nil {
+        return err
+    }
+    e.dirty = false
+    return nil
+}
+
+func (e *bm25Engine) close() error {
+    return e.flush()
+}
\ No newline at end of file
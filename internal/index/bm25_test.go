@@ -0,0 +1,121 @@
+package index
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "sort"
+    "testing"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+)
+
+func bm25Search(t *testing.T, e engine, q string) []string {
+    t.Helper()
+    parsed, err := query.Parse(q)
+    if err != nil {
+        t.Fatalf("Parse(%q) failed: %v", q, err)
+    }
+    hits, err := e.search(parsed, nil, 10)
+    if err != nil {
+        t.Fatalf("search %q failed: %v", q, err)
+    }
+    paths := []string{}
+    for _, hit := range hits {
+        paths = append(paths, hit.path)
+    }
+    return paths
+}
+
+func newTestBM25(t *testing.T, dir string) engine {
+    t.Helper()
+    e, err := newBM25Engine(dir)
+    if err != nil {
+        t.Fatalf("newBM25Engine failed: %v", err)
+    }
+    return e
+}
+
+func TestBM25Queries(t *testing.T) {
+    e := newTestBM25(t, t.TempDir())
+    e.add(&document{path: "a.md", title: "Garden", content: "raised beds for tomatoes and basil"})
+    e.add(&document{path: "b.md", title: "Kitchen", content: "basil pesto with tomatoes", tags: []string{"recipe/italian"}})
+    e.add(&document{path: "c.md", title: "Budget", content: "tomatoes are cheap this year", aliases: []string{"Money plan", "Tomato budget"}})
+    
+    tests := []struct {
+        q    string
+        want []string
+    }{
+        {"pesto", []string{"b.md"}},
+        {"TOMATOES basil", []string{"a.md", "b.md", "c.md"}},
+        {"tomatoes AND basil", []string{"a.md", "b.md"}},
+        {"tomatoes -basil", []string{"c.md"}},
+        {`"basil pesto"`, []string{"b.md"}},
+        {`"pesto basil"`, nil},
+        {"beds NEAR/2 tomatoes", []string{"a.md"}},
+        {"beds NEAR/0 tomatoes", nil},
+        {"italian", []string{"b.md"}},
+        // Values of a field are not one phrase
+        {`"plan tomato"`, nil},
+    }
+    for _, tt := range tests {
+        got := bm25Search(t, e, tt.q)
+        sort.Strings(got)
+        if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+            t.Errorf("%s: got %v, want %v", tt.q, got, tt.want)
+        }
+    }
+    
+    // Rarer words and title matches score higher
+    if got := bm25Search(t, e, "garden OR tomatoes"); got[0] != "a.md" {
+        t.Errorf("Expected the title match first, got %v", got)
+    }
+}
+
+func TestBM25Boosts(t *testing.T) {
+    e := newTestBM25(t, t.TempDir())
+    e.add(&document{path: "title.md", title: "Budget", content: "numbers"})
+    e.add(&document{path: "content.md", title: "Numbers", content: "budget"})
+    
+    parsed, _ := query.Parse("budget")
+    hits, _ := e.search(parsed, map[string]float32{"title": 2}, 10)
+    if len(hits) != 2 || hits[0].path != "title.md" {
+        t.Errorf("Expected the boosted title first, got %+v", hits)
+    }
+    
+    hits, _ = e.search(parsed, map[string]float32{"title": 0}, 10)
+    if len(hits) != 1 || hits[0].path != "content.md" {
+        t.Errorf("Expected a boost of 0 to leave out the title, got %+v", hits)
+    }
+}
+
+func TestBM25Persistence(t *testing.T) {
+    dir := t.TempDir()
+    e := newTestBM25(t, dir)
+    e.add(&document{path: "a.md", title: "Alpha", content: "first note", names: []string{"Alpha", "a"}})
+    e.add(&document{path: "b.md", title: "Beta", content: "second note", names: []string{"Beta", "b"}})
+    e.remove("a.md")
+    if err := e.close(); err != nil {
+        t.Fatalf("close failed: %v", err)
+    }
+    
+    e = newTestBM25(t, dir)
+    if n, _ := e.numDocs(); n != 1 {
+        t.Errorf("Expected 1 document after reopening, got %d", n)
+    }
+    if got := bm25Search(t, e, "note"); !reflect.DeepEqual(got, []string{"b.md"}) {
+        t.Errorf("Expected only b.md, got %v", got)
+    }
+    matches, _ := e.complete("be", 10)
+    if len(matches) != 1 || matches[0].path != "b.md" || matches[0].names[0] != "Beta" {
+        t.Errorf("Expected Beta to complete, got %+v", matches)
+    }
+    
+    // A damaged file starts an empty index rather than failing
+    if err := os.WriteFile(filepath.Join(dir, ".bm25"), []byte("garbage"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    if n, _ := newTestBM25(t, dir).numDocs(); n != 0 {
+        t.Errorf("Expected an empty index, got %d documents", n)
+    }
+}
\ No newline at end of file
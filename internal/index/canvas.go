@@ -0,0 +1,170 @@
+package index
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+)
+
+// isCanvas reports whether path is an Obsidian canvas rather than a note.
+func isCanvas(path string) bool {
+    return strings.HasSuffix(path, ".canvas")
+}
+
+func readCanvas(path string) (*markdown.Canvas, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    c, err := markdown.ParseCanvas(data)
+    if err != nil {
+        return nil, fmt.Errorf("invalid canvas %s: %w", path, err)
+    }
+    return c, nil
+}
+
+// readNote reads the note at path and splits off its frontmatter like
+// markdown.SplitFrontmatter. The body of a canvas is the text of its cards
+// and edges.
+func readNote(path string) (markdown.Frontmatter, string, int, error) {
+    if isCanvas(path) {
+        c, err := readCanvas(path)
+        if err != nil {
+            return nil, "", 0, err
+        }
+        return nil, analysis.Normalize(c.Text()), 0, nil
+    }
+    content, err := os.ReadFile(path)
+    if err != nil {
+        return nil, "", 0, err
+    }
+    frontmatter, body, bodyLine := markdown.SplitFrontmatter(analysis.Normalize(string(content)))
+    return frontmatter, body, bodyLine, nil
+}
+
+// indexCanvas indexes the canvas at path as a single document: the text of
+// its cards and edge labels as content, its group labels as headings.
+func (ix *Index) indexCanvas(path string, info os.FileInfo, resolver *linkResolver) error {
+    c, err := readCanvas(path)
+    if err != nil {
+        return err
+    }
+    text := analysis.Normalize(c.Text())
+    title := noteTitle(path, "")
+    
+    var groups []string
+    for _, label := range c.Groups() {
+        groups = append(groups, analysis.Normalize(label))
+    }
+    
+    doc := &document{
+        path:     path,
+        lang:     ix.detector.Resolve("", text),
+        title:    title,
+        content:  text,
+        pathText: analysis.Normalize(ix.relativePath(path)),
+        headings: groups,
+        tags:     markdown.Tags(nil, text),
+        names:    noteNames(title, nil, path),
+        modified: info.ModTime(),
+        created:  info.ModTime(),
+    }
+    if err := ix.engine.add(doc); err != nil {
+        return err
+    }
+    
+    ix.terms.add(path, title+"\n"+text)
+    ix.tasks.remove(path)
+    ix.properties.remove(path)
+    ix.refs.put(path, noteRefs{Edges: canvasEdges(resolver, c)})
+    
+    ix.regMu.Lock()
+    ix.lastIndexed[path] = info.ModTime()
+    ix.regMu.Unlock()
+    
+    return nil
+}
+
+// canvasLinks returns the notes that the canvas at file shows in file cards
+// or links to from text cards.
+func canvasLinks(resolver *linkResolver, file string, c *markdown.Canvas) map[string]bool {
+    linked := make(map[string]bool)
+    for _, node := range c.Nodes {
+        if node.Type != markdown.NodeFile {
+            continue
+        }
+        if target := resolver.file(node.File); target != "" {
+            linked[target] = true
+        }
+    }
+    for _, link := range c.Links() {
+        if target := resolver.resolve(file, link); target != "" {
+            linked[target] = true
+        }
+    }
+    return linked
+}
+
+// canvasEdges returns the pairs of notes that edges between file cards of
+// c connect.
+func canvasEdges(resolver *linkResolver, c *markdown.Canvas) [][2]string {
+    fileOf := func(id string) string {
+        if node, ok := c.Node(id); ok && node.Type == markdown.NodeFile {
+            return resolver.file(node.File)
+        }
+        return ""
+    }
+    var edges [][2]string
+    for _, edge := range c.Edges {
+        from, to := fileOf(edge.FromNode), fileOf(edge.ToNode)
+        if from != "" && to != "" && from != to {
+            edges = append(edges, [2]string{from, to})
+        }
+    }
+    return edges
+}
+
+// canvasSnippet returns the lines of the canvas at path that matcher
+// matches, each after the ID of its card or edge, and the IDs of all
+// matching cards and edges in canvas order.
+func (ix *Index) canvasSnippet(path string, matcher *query.Matcher, maxLength int) (string, []string) {
+    c, err := readCanvas(path)
+    if err != nil || matcher == nil {
+        return "", nil
+    }
+    
+    var lines, labels, ids []string
+    for _, t := range c.Texts() {
+        label := "edge " + t.ID
+        if _, ok := c.Node(t.ID); ok {
+            label = "node " + t.ID
+        }
+        for _, line := range strings.Split(t.Text, "\n") {
+            lines = append(lines, line)
+            labels = append(labels, label)
+            ids = append(ids, t.ID)
+        }
+    }
+    
+    best, matched := matchLines(lines, matcher)
+    var parts []string
+    for _, n := range best {
+        parts = append(parts, fmt.Sprintf("%s: %s", labels[n-1], lines[n-1]))
+    }
+    var nodes []string
+    for _, n := range matched {
+        if len(nodes) == 0 || nodes[len(nodes)-1] != ids[n-1] {
+            nodes = append(nodes, ids[n-1])
+        }
+    }
+    
+    snippet := strings.Join(parts, "\n")
+    if runes := []rune(snippet); len(runes) > maxLength {
+        snippet = string(runes[:maxLength]) + "..."
+    }
+    return snippet, nodes
+}
\ No newline at end of file
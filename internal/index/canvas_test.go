@@ -0,0 +1,100 @@
+package index
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestCanvas(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(filepath.Join(vaultPath, "Boards"), 0755)
+    board := `{
+        "nodes": [
+            {"id": "g1", "type": "group", "label": "Launch plan"},
+            {"id": "t1", "type": "text", "text": "Order the zeppelin\nfor the [[Gala]] #event"},
+            {"id": "f1", "type": "file", "file": "Gala.md"},
+            {"id": "f2", "type": "file", "file": "Catering.md"}
+        ],
+        "edges": [
+            {"id": "e1", "fromNode": "f1", "toNode": "f2", "label": "zeppelin catering"}
+        ]
+    }`
+    canvasFile := filepath.Join(vaultPath, "Boards", "Launch.canvas")
+    os.WriteFile(canvasFile, []byte(board), 0644)
+    // The notes share words but don't link, only the canvas connects them
+    os.WriteFile(filepath.Join(vaultPath, "Gala.md"), []byte("# Gala\nCanapes, oysters and champagne for the guests.\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Catering.md"), []byte("# Catering\nCanapes, oysters and champagne ordered.\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Other.md"), []byte("# Other\nCanapes, oysters and champagne again.\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Broken.canvas"), []byte("{nodes"), 0644)
+    
+    opts := DefaultOptions()
+    opts.Backend = BackendBM25
+    index, err := Open(filepath.Join(t.TempDir(), "index"), opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    defer index.Close()
+    if err := index.IndexDirectory(vaultPath, 2); err != nil {
+        t.Fatalf("Failed to index directory: %v", err)
+    }
+    indexed := index.IndexedFiles()
+    if _, ok := indexed[canvasFile]; !ok {
+        t.Fatal("Expected the canvas to be indexed")
+    }
+    if _, ok := indexed[filepath.Join(vaultPath, "Broken.canvas")]; ok {
+        t.Error("Expected the invalid canvas to be skipped")
+    }
+    
+    results, err := index.Search("zeppelin", 10)
+    if err != nil {
+        t.Fatalf("Search failed: %v", err)
+    }
+    if len(results) != 1 || results[0].FilePath != canvasFile {
+        t.Fatalf("Expected the canvas, got %+v", results)
+    }
+    if want := []string{"t1", "e1"}; !reflect.DeepEqual(results[0].Nodes, want) {
+        t.Errorf("Expected nodes %q, got %q", want, results[0].Nodes)
+    }
+    if !strings.Contains(results[0].Snippet, "node t1: Order the zeppelin") || !strings.Contains(results[0].Snippet, "edge e1: zeppelin catering") {
+        t.Errorf("Expected the matching cards in the snippet, got %q", results[0].Snippet)
+    }
+    
+    // Group labels are headings, the file name the title
+    only := func(field string) SearchOptions {
+        boosts := make(map[string]float32)
+        for _, f := range BoostFields {
+            boosts[f] = 0
+        }
+        boosts[field] = 1
+        return SearchOptions{Boosts: boosts}
+    }
+    for q, field := range map[string]string{"plan": "headings", "event": "tags", "launch": "title"} {
+        if results, _ := index.SearchWithOptions(q, 10, only(field)); len(results) != 1 {
+            t.Errorf("Expected %q in %s to find the canvas, got %+v", q, field, results)
+        }
+    }
+    
+    // An arrow between two file cards connects their notes
+    similar, err := index.Similar(filepath.Join(vaultPath, "Gala.md"), 10)
+    if err != nil {
+        t.Fatalf("Similar failed: %v", err)
+    }
+    if len(similar) != 1 || similar[0].FilePath != "Other.md" {
+        t.Errorf("Expected only the note not connected on the canvas, got %+v", similar)
+    }
+    
+    os.WriteFile(canvasFile, []byte(`{"nodes": [{"id": "t2", "type": "text", "text": "Hire a blimp"}]}`), 0644)
+    index.UpdateFile(canvasFile)
+    if results, _ := index.Search("blimp", 10); len(results) != 1 || !reflect.DeepEqual(results[0].Nodes, []string{"t2"}) {
+        t.Errorf("Expected the updated canvas, got %+v", results)
+    }
+    if results, _ := index.Search("zeppelin", 10); len(results) != 0 {
+        t.Errorf("Expected the old cards to be gone, got %+v", results)
+    }
+    if similar, _ := index.Similar(filepath.Join(vaultPath, "Gala.md"), 10); len(similar) != 2 {
+        t.Errorf("Expected the notes to be unconnected without the arrow, got %+v", similar)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,79 @@
+package index
+
+import (
+    "slices"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+)
+
+// document is a note as handed to a search engine: the text of every field
+// plus the dates that ranking needs back with each hit.
+type document struct {
+    path     string
+    lang     string
+    title    string
+    content  string
+    pathText string
+    aliases  []string
+    headings []string
+    tags     []string
+    urls     []string
+    // code holds the fenced code blocks; codeLangs and calloutTypes are
+    // the values of filters
+    code         []string
+    codeLangs    []string
+    calloutTypes []string
+    names        []string
+    modified     time.Time
+    created      time.Time
+}
+
+// nameMatch is a note whose names match a completion prefix. The title is
+// always the first name.
+type nameMatch struct {
+    path  string
+    names []string
+}
+
+// engine is the full-text part of an Index. Index serializes writes and
+// searches with its own lock, but indexing workers add documents
+// concurrently.
+type engine interface {
+    // add replaces the document with the same path.
+    add(doc *document) error
+    remove(path string) error
+    // search returns up to limit hits for q, best first. boosts weight the
+    // fields named in BoostFields; a missing field has weight 1 and a field
+    // with weight 0 is not searched.
+    search(q query.Node, boosts map[string]float32, limit int) ([]scoredResult, error)
+    // complete returns notes with a name in which every word of prefix
+    // starts a word.
+    complete(prefix string, limit int) ([]nameMatch, error)
+    numDocs() (uint64, error)
+    // flush persists the documents added so far.
+    flush() error
+    close() error
+}
+
+// addCode adds the code blocks to doc, and their languages once each.
+func (doc *document) addCode(blocks []markdown.CodeBlock) {
+    for _, block := range blocks {
+        doc.code = append(doc.code, block.Code)
+        if block.Lang != "" && !slices.Contains(doc.codeLangs, block.Lang) {
+            doc.codeLangs = append(doc.codeLangs, block.Lang)
+        }
+    }
+}
+
+// addCallouts adds the titles of the callouts to the headings of doc, and
+// their types once each.
+func (doc *document) addCallouts(callouts []markdown.Callout) {
+    for _, c := range callouts {
+        doc.headings = append(doc.headings, c.Title)
+        if !slices.Contains(doc.calloutTypes, c.Type) {
+            doc.calloutTypes = append(doc.calloutTypes, c.Type)
+        }
+    }
+}
\ No newline at end of file
@@ -0,0 +1,252 @@
+package index
+
+import (
+    "sort"
+    "strings"
+    "unicode"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+)
+
+// SearchOptions modify a single search.
+type SearchOptions struct {
+    // Fuzzy enables typo-tolerant matching when set.
+    Fuzzy *FuzzyOptions
+    // Boosts override the configured weights of the fields they name.
+    Boosts map[string]float32
+    // Recency overrides the configured recency boost when set.
+    Recency *RecencyOptions
+    // Hybrid adds the notes closest in meaning to the query, found with
+    // the embedder, and merges both rankings by reciprocal rank fusion.
+    Hybrid bool
+    // Dates keeps the periodic notes whose period overlaps the range when
+    // set, and leaves out all other notes.
+    Dates DateRange
+    // Folder keeps the files below this vault-relative folder when set.
+    Folder string
+}
+
+// FuzzyOptions control typo-tolerant matching. Every plain query word is
+// searched together with up to MaxExpansions indexed words that are at most
+// Distance edits away (insertions, deletions, substitutions and swaps of
+// adjacent letters) and share its first PrefixLength letters.
+type FuzzyOptions struct {
+    Distance      int
+    PrefixLength  int
+    MaxExpansions int
+}
+
+// suggestDistance bounds how far a "did you mean" correction may be from the
+// word it replaces.
+const suggestDistance = 2
+
+// fuzzyDistance limits the edit distance for short words, which would
+// otherwise match almost anything: words under three letters are matched
+// exactly and words under six letters allow a single edit.
+func fuzzyDistance(word []rune, max int) int {
+    switch {
+    case len(word) < 3:
+        return 0
+    case len(word) < 6 && max > 1:
+        return 1
+    }
+    return max
+}
+
+type termMatch struct {
+    term     string
+    distance int
+    freq     int
+}
+
+// similarTerms returns the indexed words within distance edits of word,
+// closest and most frequent first, excluding word itself.
+func (d *termDict) similarTerms(word string, distance, prefixLength int) []termMatch {
+    target := []rune(word)
+    if distance <= 0 {
+        return nil
+    }
+    prefix := ""
+    if prefixLength > 0 && prefixLength <= len(target) {
+        prefix = string(target[:prefixLength])
+    }
+    
+    var matches []termMatch
+    d.each(func(term string, freq int) {
+        if term == word || !strings.HasPrefix(term, prefix) {
+            return
+        }
+        candidate := []rune(term)
+        if abs(len(candidate)-len(target)) > distance {
+            return
+        }
+        if dist := editDistance(target, candidate, distance); dist <= distance {
+            matches = append(matches, termMatch{term, dist, freq})
+        }
+    })
+    
+    sort.Slice(matches, func(i, j int) bool {
+        a, b := matches[i], matches[j]
+        if a.distance != b.distance {
+            return a.distance < b.distance
+        }
+        if a.freq != b.freq {
+            return a.freq > b.freq
+        }
+        return a.term < b.term
+    })
+    return matches
+}
+
+// expandFuzzy rewrites node so that each plain word also matches similar
+// indexed words. The word as typed keeps full weight and the variants are
+// boosted down by their distance, so exact matches outrank fuzzy ones:
+// meetnig becomes (meetnig meeting^0.5 meetings^0.25). Phrases are kept
+// as they are.
+func (ix *Index) expandFuzzy(node query.Node, opts FuzzyOptions) query.Node {
+    switch n := node.(type) {
+    case *query.Term:
+        if !isPlainWord(n.Text) {
+            return n
+        }
+        folded := analysis.Fold(n.Text)
+        distance := fuzzyDistance([]rune(folded), opts.Distance)
+        matches := ix.terms.similarTerms(folded, distance, opts.PrefixLength)
+        if len(matches) == 0 {
+            return n
+        }
+        if opts.MaxExpansions > 0 && len(matches) > opts.MaxExpansions {
+            matches = matches[:opts.MaxExpansions]
+        }
+        
+        variants := &query.Bool{Clauses: []query.Clause{{Occur: query.Should, Node: n}}}
+        for _, m := range matches {
+            variants.Clauses = append(variants.Clauses, query.Clause{
+                Occur: query.Should,
+                Node:  &query.Term{Text: m.term, Boost: fuzzyBoost(m.distance)},
+            })
+        }
+        return variants
+    case *query.Bool:
+        expanded := &query.Bool{Clauses: make([]query.Clause, len(n.Clauses))}
+        for i, c := range n.Clauses {
+            expanded.Clauses[i] = query.Clause{Occur: c.Occur, Node: ix.expandFuzzy(c.Node, opts)}
+        }
+        return expanded
+    }
+    return node
+}
+
+// fuzzyBoost is the weight of a variant relative to the word as typed.
+func fuzzyBoost(distance int) float64 {
+    boost := 1.0
+    for i := 0; i < distance; i++ {
+        boost /= 2
+    }
+    return boost
+}
+
+// Suggest returns a corrected query for "did you mean" hints: every word
+// that does not occur in the index is replaced by the closest indexed word.
+// It returns "" if no word could be corrected.
+func (ix *Index) Suggest(query string) string {
+    changed := false
+    suggestion := rewriteWords(query, func(word string) string {
+        folded := analysis.Fold(word)
+        if ix.terms.freq(folded) > 0 {
+            return word
+        }
+        distance := fuzzyDistance([]rune(folded), suggestDistance)
+        matches := ix.terms.similarTerms(folded, distance, 0)
+        if len(matches) == 0 {
+            return word
+        }
+        changed = true
+        return matches[0].term
+    })
+    
+    if !changed {
+        return ""
+    }
+    return suggestion
+}
+
+// rewriteWords calls fn for every plain word of query and replaces the word
+// with the result. Quoted phrases, operators and words with punctuation are
+// kept as they are; signs and parentheses around a word stay in place.
+func rewriteWords(query string, fn func(word string) string) string {
+    var out []string
+    inPhrase := false
+    for _, token := range strings.Fields(query) {
+        if quotes := strings.Count(token, `"`); inPhrase || quotes > 0 {
+            if quotes%2 == 1 {
+                inPhrase = !inPhrase
+            }
+            out = append(out, token)
+            continue
+        }
+        
+        word := strings.TrimLeft(token, "+-(")
+        lead := token[:len(token)-len(word)]
+        trimmed := strings.TrimRight(word, ")")
+        trail := word[len(trimmed):]
+        if isPlainWord(trimmed) && trimmed != "AND" && trimmed != "OR" && trimmed != "NOT" {
+            trimmed = fn(trimmed)
+        }
+        out = append(out, lead+trimmed+trail)
+    }
+    return strings.Join(out, " ")
+}
+
+func isPlainWord(word string) bool {
+    if word == "" {
+        return false
+    }
+    for _, r := range word {
+        if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+            return false
+        }
+    }
+    return true
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b, counting a swap of adjacent letters as one edit. It stops early and
+// returns max+1 once the distance is known to exceed max.
+func editDistance(a, b []rune, max int) int {
+    prev2 := make([]int, len(b)+1)
+    prev := make([]int, len(b)+1)
+    curr := make([]int, len(b)+1)
+    for j := range prev {
+        prev[j] = j
+    }
+    
+    for i := 1; i <= len(a); i++ {
+        curr[0] = i
+        rowMin := curr[0]
+        for j := 1; j <= len(b); j++ {
+            cost := 1
+            if a[i-1] == b[j-1] {
+                cost = 0
+            }
+            curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+            if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+                curr[j] = min(curr[j], prev2[j-2]+1)
+            }
+            rowMin = min(rowMin, curr[j])
+        }
+        if rowMin > max {
+            return max + 1
+        }
+        prev2, prev, curr = prev, curr, prev2
+    }
+    return prev[len(b)]
+}
+
+func abs(n int) int {
+    if n < 0 {
+        return -n
+    }
+    return n
+}
\ No newline at end of file
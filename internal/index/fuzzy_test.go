@@ -0,0 +1,127 @@
+package index
+
+import (
+    "path/filepath"
+    "testing"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+)
+
+func TestEditDistance(t *testing.T) {
+    tests := []struct {
+        a, b string
+        want int
+    }{
+        {"meeting", "meeting", 0},
+        {"meetnig", "meeting", 1},
+        {"meting", "meeting", 1},
+        {"meetinq", "meeting", 1},
+        {"metting", "meeting", 1},
+        {"mtg", "meeting", 4},
+        {"", "abc", 3},
+    }
+    
+    for _, tt := range tests {
+        if got := editDistance([]rune(tt.a), []rune(tt.b), 4); got != tt.want {
+            t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+        }
+    }
+    
+    if got := editDistance([]rune("abcdef"), []rune("uvwxyz"), 2); got != 3 {
+        t.Errorf("Expected the bounded distance to stop at max+1, got %d", got)
+    }
+}
+
+func testIndexWithTerms(notes map[string]string) *Index {
+    ix := &Index{terms: newTermDict()}
+    for path, text := range notes {
+        ix.terms.add(path, text)
+    }
+    return ix
+}
+
+// expand parses q, expands it and renders it for Tantivy.
+func expand(t *testing.T, ix *Index, q string, opts FuzzyOptions) string {
+    t.Helper()
+    parsed, err := query.Parse(q)
+    if err != nil {
+        t.Fatalf("Parse(%q) failed: %v", q, err)
+    }
+    return query.Tantivy(ix.expandFuzzy(parsed, opts))
+}
+
+func TestExpandFuzzy(t *testing.T) {
+    ix := testIndexWithTerms(map[string]string{
+        "a.md": "Weekly meeting notes",
+        "b.md": "Meetings with the team",
+        "c.md": "Greeting cards",
+    })
+    opts := FuzzyOptions{Distance: 2, PrefixLength: 1, MaxExpansions: 10}
+    
+    got := expand(t, ix, "meetnig", opts)
+    want := "meetnig meeting^0.5 meetings^0.25"
+    if got != want {
+        t.Errorf("expandFuzzy = %q, want %q", got, want)
+    }
+    
+    // The prefix keeps "greeting" out, phrases and operators are untouched
+    got = expand(t, ix, `"meetnig notes" AND -meetnig`, opts)
+    want = `+"meetnig notes" -(meetnig meeting^0.5 meetings^0.25)`
+    if got != want {
+        t.Errorf("expandFuzzy = %q, want %q", got, want)
+    }
+    
+    if got := expand(t, ix, "meetnig", FuzzyOptions{Distance: 2, PrefixLength: 1, MaxExpansions: 1}); got != "meetnig meeting^0.5" {
+        t.Errorf("Expected expansions to be capped, got %q", got)
+    }
+}
+
+func TestExpandFuzzyShortWords(t *testing.T) {
+    ix := testIndexWithTerms(map[string]string{"a.md": "go to do it"})
+    if got := expand(t, ix, "go", FuzzyOptions{Distance: 2}); got != "go" {
+        t.Errorf("Expected two-letter words to stay exact, got %q", got)
+    }
+}
+
+func TestSuggest(t *testing.T) {
+    ix := testIndexWithTerms(map[string]string{
+        "a.md": "Quarterly budget review",
+        "b.md": "Budget planning",
+    })
+    
+    if got := ix.Suggest("quartely budgte"); got != "quarterly budget" {
+        t.Errorf("Suggest = %q, want %q", got, "quarterly budget")
+    }
+    if got := ix.Suggest("budget review"); got != "" {
+        t.Errorf("Expected no suggestion for known words, got %q", got)
+    }
+    if got := ix.Suggest("(quartely OR costs) -draft"); got != "(quarterly OR costs) -draft" {
+        t.Errorf("Expected operators and grouping to be kept, got %q", got)
+    }
+    if got := ix.Suggest("xylophone"); got != "" {
+        t.Errorf("Expected no suggestion without a close word, got %q", got)
+    }
+}
+
+func TestTermDictPersistence(t *testing.T) {
+    d := newTermDict()
+    d.add("a.md", "Straße und Weg")
+    d.add("b.md", "Weg")
+    d.add("a.md", "Straße")
+    
+    if d.freq("weg") != 1 || d.freq("strasse") != 1 || d.freq("und") != 0 {
+        t.Fatalf("Unexpected frequencies after re-adding a.md: %v", d.docFreq)
+    }
+    
+    path := filepath.Join(t.TempDir(), ".terms")
+    if err := d.save(path); err != nil {
+        t.Fatalf("save failed: %v", err)
+    }
+    loaded := newTermDict()
+    loaded.load(path)
+    loaded.remove("b.md")
+    
+    if loaded.freq("strasse") != 1 || loaded.freq("weg") != 0 {
+        t.Errorf("Unexpected frequencies after load and remove: %v", loaded.docFreq)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,1222 @@
+package index
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "slices"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    
+    "github.com/karrick/godirwalk"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/embed"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/extract"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/ignore"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/periodic"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+)
+
+type SearchResult struct {
+    Vault       string             `json:"vault,omitempty"`
+    FilePath    string             `json:"file_path"`
+    Aliases     []string           `json:"aliases,omitempty"`
+    Snippet     string             `json:"snippet"`
+    Score       float32            `json:"score"`
+    LineNumbers []int              `json:"line_numbers"`
+    Nodes       []string           `json:"nodes,omitempty"`
+    Pages       []int              `json:"pages,omitempty"`
+    Callouts    []markdown.Callout `json:"callouts,omitempty"`
+    Language    string             `json:"language,omitempty"`
+}
+
+// Options tune ranking and result formatting. FieldBoosts weight the
+// fields named in BoostFields, a boost of 0 leaves the field out of the
+// search. Languages name the analyzers the index is built with, the first
+// being the fallback for notes whose language cannot be detected. Backend
+// is one of Backends. ResolveEmbeds indexes the text of embedded notes,
+// sections and blocks with the note that embeds them. Attachments extracts
+// the text of the attachments indexed besides notes and canvases; with nil
+// none are.
+type Options struct {
+    SnippetLength int
+    FieldBoosts   map[string]float32
+    Recency       RecencyOptions
+    Languages     []string
+    Backend       string
+    ResolveEmbeds bool
+    Attachments   *extract.Registry
+}
+
+func DefaultOptions() Options {
+    return Options{
+        SnippetLength: 150,
+        FieldBoosts: map[string]float32{
+            "title":    2.0,
+            "aliases":  2.0,
+            "headings": 1.5,
+            "tags":     1.5,
+            "path":     1.0,
+            "content":  1.0,
+            "urls":     0.5,
+            "code":     1.0,
+        },
+        Recency:       DefaultRecency(),
+        Languages:     []string{analysis.DefaultLanguage},
+        Backend:       BackendAuto,
+        ResolveEmbeds: true,
+        Attachments:   extract.Default(extract.DefaultLimits),
+    }
+}
+
+// BoostFields are the names that FieldBoosts accept.
+var BoostFields = []string{"title", "aliases", "headings", "tags", "path", "content", "urls", "code"}
+
+// schemaVersion changes whenever the fields of the index change. Indexes
+// written with another version, backend or other languages are rebuilt on
+// open.
+const schemaVersion = 14
+
+// Search backends. BackendAuto picks Tantivy when the binary was built
+// with it and the pure-Go BM25 index otherwise.
+const (
+    BackendAuto    = "auto"
+    BackendTantivy = "tantivy"
+    BackendBM25    = "bm25"
+)
+
+// Backends lists the values that Options.Backend accepts.
+var Backends = []string{BackendAuto, BackendTantivy, BackendBM25}
+
+// SearchIndex is the full-text index of one vault: it indexes the notes
+// below a directory, keeps them current and answers searches. Index
+// implements it on top of either search backend.
+type SearchIndex interface {
+    IndexDirectory(rootPath string, numWorkers int) error
+    Rebuild(rootPath string, numWorkers int) error
+    UpdateFile(path string) error
+    RemoveFile(path string) error
+    Watch(rootPath, mode string, pollInterval time.Duration) (Watcher, error)
+    
+    Rank(q string, opts SearchOptions) ([]Hit, error)
+    Results(q string, opts SearchOptions, hits []Hit) []SearchResult
+    Suggest(query string) string
+    Complete(prefix string, limit int) ([]Completion, error)
+    Similar(path string, limit int) ([]SimilarNote, error)
+    Tasks(q TaskQuery) []TaskMatch
+    Properties(q PropertyQuery) []PropertyMatch
+    PeriodicNotes(q PeriodicQuery) []PeriodicNote
+    Resolve(ref, from string) (*Reference, error)
+    Lookup(name string) string
+    
+    IndexedFiles() map[string]time.Time
+    GetIndexedFilesCount() int
+    Stats() (IndexStats, error)
+    Check(rootPath string) (*CheckReport, error)
+    Close() error
+}
+
+// Index is a SearchIndex. It keeps the registry of indexed files, the
+// vocabulary, the tasks, the properties, the references between notes, the
+// formats of periodic notes and the vectors of semantic search itself and
+// leaves full-text search to its engine.
+type Index struct {
+    engine      engine
+    indexPath   string
+    mu          sync.RWMutex
+    regMu       sync.Mutex
+    lastIndexed map[string]time.Time
+    ignore      *ignore.Matcher
+    options     Options
+    detector    *analysis.Detector
+    attachments *extract.Registry
+    extracted   attachmentStore
+    terms       *termDict
+    tasks       *taskList
+    properties  *propertyList
+    refs        *refList
+    periodic    []periodic.Format
+    
+    vecMu     sync.Mutex
+    embedder  embed.Embedder
+    chunkSize int
+    vectors   *vectorStore
+}
+
+// Open opens the index at indexPath with the backend named in opts. The
+// backend and languages are part of the schema and cannot be changed later
+// with SetOptions; opening an index with others rebuilds it. The
+// attachments cannot be changed later either.
+func Open(indexPath string, opts Options) (*Index, error) {
+    if len(opts.Languages) == 0 {
+        opts.Languages = []string{analysis.DefaultLanguage}
+    }
+    backend, err := resolveBackend(opts.Backend)
+    if err != nil {
+        return nil, err
+    }
+    opts.Backend = backend
+    
+    if err := prepareIndexDir(indexPath, schemaSignature(backend, opts.Languages)); err != nil {
+        return nil, err
+    }
+    
+    var eng engine
+    switch backend {
+    case BackendTantivy:
+        eng, err = newTantivyEngine(indexPath, opts.Languages)
+    case BackendBM25:
+        eng, err = newBM25Engine(indexPath)
+    }
+    if err != nil {
+        return nil, err
+    }
+    
+    ix := &Index{
+        engine:      eng,
+        indexPath:   indexPath,
+        lastIndexed: make(map[string]time.Time),
+        options:     opts,
+        detector:    analysis.NewDetector(opts.Languages),
+        attachments: opts.Attachments,
+        extracted:   attachmentStore{dir: filepath.Join(indexPath, attachmentDir)},
+        terms:       newTermDict(),
+        tasks:       newTaskList(),
+        properties:  newPropertyList(),
+        refs:        newRefList(),
+    }
+    
+    // The registry is needed for stats before the first IndexDirectory
+    ix.loadIndexTimestamps()
+    ix.terms.load(filepath.Join(indexPath, ".terms"))
+    ix.tasks.load(filepath.Join(indexPath, ".tasks"))
+    ix.properties.load(filepath.Join(indexPath, ".properties"))
+    ix.refs.load(filepath.Join(indexPath, ".refs"))
+    
+    // An engine that lost its documents, for example after a crash before
+    // they were flushed, must not be trusted by the registry
+    if docs, err := eng.numDocs(); err == nil && docs == 0 && len(ix.lastIndexed) > 0 {
+        slog.Info("Index is empty, reindexing all files", "index", indexPath)
+        ix.lastIndexed = make(map[string]time.Time)
+    }
+    
+    return ix, nil
+}
+
+// resolveBackend returns the backend to use for backend.
+func resolveBackend(backend string) (string, error) {
+    switch backend {
+    case "", BackendAuto:
+        if tantivyAvailable {
+            return BackendTantivy, nil
+        }
+        return BackendBM25, nil
+    case BackendTantivy:
+        if !tantivyAvailable {
+            return "", fmt.Errorf("this binary was built without the tantivy backend, use %s", BackendBM25)
+        }
+        return backend, nil
+    case BackendBM25:
+        return backend, nil
+    }
+    return "", fmt.Errorf("unknown search backend %q (valid: %s)", backend, strings.Join(Backends, ", "))
+}
+
+// Backend returns the search backend the index was opened with.
+func (ix *Index) Backend() string {
+    return ix.options.Backend
+}
+
+// IndexDirectory indexes the notes below rootPath that changed since they
+// were last indexed and drops those that are gone. With an embedder set, the
+// changed notes are embedded afterwards; embedding failures are logged and
+// retried by the next run.
+func (ix *Index) IndexDirectory(rootPath string, numWorkers int) error {
+    return ix.indexAll(rootPath, numWorkers, false)
+}
+
+// Rebuild forgets all timestamps and reindexes every file below rootPath.
+func (ix *Index) Rebuild(rootPath string, numWorkers int) error {
+    return ix.indexAll(rootPath, numWorkers, true)
+}
+
+func (ix *Index) indexAll(rootPath string, numWorkers int, rebuild bool) error {
+    err := ix.indexDirectory(rootPath, numWorkers, rebuild)
+    
+    // Searches must not wait for the embedding provider
+    if vecErr := ix.syncVectors(); vecErr != nil {
+        slog.Warn("Semantic index incomplete", "err", vecErr)
+    }
+    return err
+}
+
+// indexDirectory indexes the changed files below rootPath, or all of them
+// with rebuild, holding the lock throughout so that watcher updates wait
+// for it.
+func (ix *Index) indexDirectory(rootPath string, numWorkers int, rebuild bool) error {
+    ix.mu.Lock()
+    defer ix.mu.Unlock()
+    
+    // Load saved timestamps
+    ix.loadIndexTimestamps()
+    
+    if ix.ignoreMatcher() == nil {
+        matcher, err := ignore.Load(rootPath, nil)
+        if err != nil {
+            return fmt.Errorf("failed to load ignore rules: %w", err)
+        }
+        ix.SetIgnore(matcher)
+    }
+    if ix.periodicFormats() == nil {
+        formats, err := periodic.Load(rootPath)
+        if err != nil {
+            slog.Warn("Failed to load periodic notes settings, using the defaults", "err", err)
+            formats = periodic.Default()
+        }
+        ix.SetPeriodic(formats)
+    }
+    
+    type indexJob struct {
+        path string
+        info os.FileInfo
+    }
+    
+    // Directory traversal
+    seen := make(map[string]bool)
+    var pending []indexJob
+    err := ix.walkVault(rootPath, func(path string, info os.FileInfo) {
+        seen[path] = true
+        
+        // Only index modified files
+        if lastIndexed, exists := ix.indexedAt(path); exists && !rebuild {
+            if info.ModTime().Before(lastIndexed) || info.ModTime().Equal(lastIndexed) {
+                return
+            }
+        }
+        
+        pending = append(pending, indexJob{path: path, info: info})
+    })
+    
+    // Links are resolved against every note found, also those not indexed
+    // yet
+    files := make([]string, 0, len(seen))
+    for path := range seen {
+        files = append(files, path)
+    }
+    resolver := newLinkResolver(ix.ignoreMatcher().Root(), files)
+    resolver.setAliases(ix.properties.aliases())
+    
+    // A file that fails to index again is retried by the next run
+    if rebuild {
+        ix.regMu.Lock()
+        for _, job := range pending {
+            delete(ix.lastIndexed, job.path)
+        }
+        ix.regMu.Unlock()
+    }
+    
+    jobs := make(chan indexJob, 100)
+    var wg sync.WaitGroup
+    
+    // Start workers
+    for i := 0; i < numWorkers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for job := range jobs {
+                ix.indexFile(job.path, job.info, resolver)
+            }
+        }()
+    }
+    changed := make(map[string]bool, len(pending))
+    for _, job := range pending {
+        changed[job.path] = true
+        jobs <- job
+    }
+    close(jobs)
+    wg.Wait()
+    
+    // Embeds may use the aliases just indexed, which the notes of this run
+    // did not know yet
+    resolver.setAliases(ix.properties.aliases())
+    aliases := make(map[string]bool, len(resolver.byAlias))
+    for name := range resolver.byAlias {
+        aliases[name] = true
+    }
+    for _, file := range ix.refs.missing(aliases) {
+        if changed[file] {
+            if info, err := os.Stat(file); err == nil {
+                ix.indexFile(file, info, resolver)
+            }
+        }
+    }
+    
+    // Drop files that were deleted or became ignored since the last run
+    if err == nil {
+        for path := range ix.IndexedFiles() {
+            if !seen[path] {
+                ix.removeFile(path)
+                changed[path] = true
+            }
+        }
+    }
+    ix.updateEmbedders(resolver, changed)
+    
+    // Save timestamps
+    if flushErr := ix.engine.flush(); flushErr != nil {
+        slog.Error("Failed to save index", "err", flushErr)
+    }
+    ix.saveIndexTimestamps()
+    ix.saveTerms()
+    ix.saveTasks()
+    ix.saveProperties()
+    ix.saveRefs()
+    
+    return err
+}
+
+// indexFile indexes the note, canvas or attachment at path. resolver finds the notes
+// its embeds point to.
+func (ix *Index) indexFile(path string, info os.FileInfo, resolver *linkResolver) error {
+    if isCanvas(path) {
+        return ix.indexCanvas(path, info, resolver)
+    }
+    if ix.isAttachment(path) {
+        return ix.indexAttachment(path, info)
+    }
+    content, err := os.ReadFile(path)
+    if err != nil {
+        return err
+    }
+    
+    // NFC so that decomposed characters don't split words
+    text := analysis.Normalize(string(content))
+    
+    // Frontmatter lang overrides the detected language
+    frontmatter, body, bodyLine := markdown.SplitFrontmatter(text)
+    lang := ix.detector.Resolve(frontmatter.String("lang"), body)
+    
+    title := noteTitle(path, body)
+    
+    created := info.ModTime()
+    for _, key := range []string{"created", "date"} {
+        if t, ok := frontmatter.Time(key); ok {
+            created = t
+            break
+        }
+    }
+    
+    // The text as it reads, without link targets, comments and URLs, and
+    // the code apart from it
+    cleaned := markdown.Clean(text)
+    doc := &document{
+        path:     path,
+        lang:     lang,
+        title:    title,
+        content:  cleaned.Text,
+        pathText: analysis.Normalize(ix.relativePath(path)),
+        aliases:  frontmatter.Aliases(),
+        headings: markdown.Headings(body),
+        tags:     markdown.Tags(frontmatter, body),
+        urls:     cleaned.URLs,
+        names:    noteNames(title, frontmatter.Aliases(), path),
+        modified: info.ModTime(),
+        created:  created,
+    }
+    doc.addCode(cleaned.Code)
+    doc.addCallouts(markdown.Callouts(body))
+    if ix.options.ResolveEmbeds {
+        if embedded := transclude(resolver, path, body, 1, map[string]bool{path: true}); embedded != "" {
+            cleaned := markdown.Clean(embedded)
+            doc.content += "\n\n" + cleaned.Text
+            doc.addCode(cleaned.Code)
+        }
+    }
+    if err := ix.engine.add(doc); err != nil {
+        return err
+    }
+    
+    ix.terms.add(path, title+"\n"+markdown.Clean(body).Text)
+    
+    // Task lines count from the top of the file, like snippet lines
+    tasks := markdown.Tasks(body)
+    for i := range tasks {
+        tasks[i].Line += bodyLine
+    }
+    ix.tasks.put(path, tasks)
+    ix.properties.put(path, markdown.Properties(frontmatter, body))
+    ix.refs.put(path, noteRefsOf(resolver, path, body, bodyLine))
+    
+    // Update timestamp
+    ix.regMu.Lock()
+    ix.lastIndexed[path] = info.ModTime()
+    ix.regMu.Unlock()
+    
+    return nil
+}
+
+// noteTitle returns the heading on the first line of body, or else the
+// file name.
+func noteTitle(path, body string) string {
+    first, _, _ := strings.Cut(body, "\n")
+    if strings.HasPrefix(first, "#") {
+        return strings.TrimSpace(strings.TrimPrefix(first, "#"))
+    }
+    return filepath.Base(path)
+}
+
+// schemaSignature identifies the backend and fields an index was built
+// with.
+func schemaSignature(backend string, languages []string) string {
+    return fmt.Sprintf("%d %s %s", schemaVersion, backend, strings.Join(languages, ","))
+}
+
+// prepareIndexDir creates the index directory, or removes the index files in
+// it if they were written with a different schema so the index is rebuilt
+// from scratch. Subdirectories may hold the indexes of other vaults and are
+// left alone.
+func prepareIndexDir(indexPath, signature string) error {
+    if err := os.MkdirAll(indexPath, 0755); err != nil {
+        return fmt.Errorf("failed to create index directory: %w", err)
+    }
+    
+    schemaFile := filepath.Join(indexPath, ".schema")
+    if data, err := os.ReadFile(schemaFile); err == nil && strings.TrimSpace(string(data)) == signature {
+        return nil
+    }
+    
+    entries, err := os.ReadDir(indexPath)
+    if err != nil {
+        return fmt.Errorf("failed to read index directory: %w", err)
+    }
+    cleared := false
+    for _, entry := range entries {
+        if entry.IsDir() && entry.Name() != attachmentDir {
+            continue
+        }
+        if !cleared {
+            slog.Info("Index schema changed, rebuilding index", "index", indexPath)
+            cleared = true
+        }
+        if err := os.RemoveAll(filepath.Join(indexPath, entry.Name())); err != nil {
+            return fmt.Errorf("failed to clear index directory: %w", err)
+        }
+    }
+    
+    return os.WriteFile(schemaFile, []byte(signature+"\n"), 0644)
+}
+
+// vaultPath returns path relative to the vault root in slash form, the way
+// Obsidian refers to notes.
+func (ix *Index) vaultPath(path string) string {
+    if root := ix.ignoreMatcher().Root(); root != "" {
+        if rel, err := filepath.Rel(root, path); err == nil {
+            return filepath.ToSlash(rel)
+        }
+    }
+    return filepath.ToSlash(path)
+}
+
+// relativePath returns path relative to the vault root, with the extension
+// removed, for indexing file and folder names.
+func (ix *Index) relativePath(path string) string {
+    rel := ix.vaultPath(path)
+    return strings.TrimSuffix(rel, filepath.Ext(rel))
+}
+
+// isIndexable reports whether path is a file type the index understands.
+func (ix *Index) isIndexable(path string) bool {
+    return isNote(path) || ix.attachments.Supports(path)
+}
+
+// SetOptions replaces the ranking and formatting options used by Search.
+// The languages and attachments of an open index are kept.
+func (ix *Index) SetOptions(opts Options) {
+    ix.mu.Lock()
+    defer ix.mu.Unlock()
+    opts.Languages = ix.options.Languages
+    opts.Attachments = ix.options.Attachments
+    ix.options = opts
+}
+
+// SetIgnore sets the rules that decide which vault paths stay out of the
+// index. IndexDirectory loads the default rules for its root if none are set.
+func (ix *Index) SetIgnore(matcher *ignore.Matcher) {
+    ix.regMu.Lock()
+    defer ix.regMu.Unlock()
+    ix.ignore = matcher
+}
+
+func (ix *Index) ignoreMatcher() *ignore.Matcher {
+    ix.regMu.Lock()
+    defer ix.regMu.Unlock()
+    return ix.ignore
+}
+
+// shouldIndex reports whether path is an indexable file not excluded by the
+// ignore rules.
+func (ix *Index) shouldIndex(path string) bool {
+    return ix.isIndexable(path) && !ix.ignoreMatcher().Match(path, false)
+}
+
+// walkVault calls fn for every file below rootPath that should be indexed,
+// without descending into ignored directories.
+func (ix *Index) walkVault(rootPath string, fn func(path string, info os.FileInfo)) error {
+    matcher := ix.ignoreMatcher()
+    
+    return godirwalk.Walk(rootPath, &godirwalk.Options{
+        Callback: func(path string, de *godirwalk.Dirent) error {
+            if de.IsDir() {
+                if matcher.Match(path, true) {
+                    return godirwalk.SkipThis
+                }
+                return nil
+            }
+            
+            if !ix.isIndexable(path) || matcher.Match(path, false) {
+                return nil
+            }
+            
+            info, err := os.Stat(path)
+            if err != nil {
+                return nil
+            }
+            
+            fn(path, info)
+            return nil
+        },
+        Unsorted: true,
+        ErrorCallback: func(path string, err error) godirwalk.ErrorAction {
+            slog.Warn("Error walking", "path", path, "err", err)
+            return godirwalk.SkipNode
+        },
+    })
+}
+
+func (ix *Index) indexedAt(path string) (time.Time, bool) {
+    ix.regMu.Lock()
+    defer ix.regMu.Unlock()
+    t, ok := ix.lastIndexed[path]
+    return t, ok
+}
+
+// IndexedFiles returns a snapshot of the file registry, mapping every
+// indexed path to the modification time it was indexed at.
+func (ix *Index) IndexedFiles() map[string]time.Time {
+    ix.regMu.Lock()
+    defer ix.regMu.Unlock()
+    files := make(map[string]time.Time, len(ix.lastIndexed))
+    for path, modTime := range ix.lastIndexed {
+        files[path] = modTime
+    }
+    return files
+}
+
+func (ix *Index) Search(query string, limit int) ([]SearchResult, error) {
+    return ix.SearchWithOptions(query, limit, SearchOptions{})
+}
+
+// MaxHits bounds the matches a search ranks. Below it every match is
+// ranked and counted; a query matching more notes ranks the MaxHits that
+// score best on text.
+const MaxHits = 1000
+
+// semanticHits is the number of notes found by meaning that hybrid search
+// fuses with the keyword matches.
+const semanticHits = 100
+
+// Hit is a ranked match of a search, before its snippet is made.
+type Hit struct {
+    FilePath string
+    Score    float32
+    Language string
+    
+    // chunk is the best passage found by meaning, shown when no line
+    // matches the query words
+    chunk *chunk
+}
+
+// SearchWithOptions runs a query in the syntax described by query.Syntax
+// and returns the best limit results with snippets.
+func (ix *Index) SearchWithOptions(q string, limit int, opts SearchOptions) ([]SearchResult, error) {
+    hits, err := ix.Rank(q, opts)
+    if err != nil {
+        return nil, err
+    }
+    if len(hits) > limit {
+        hits = hits[:limit]
+    }
+    return ix.Results(q, opts, hits), nil
+}
+
+// Rank runs a query in the syntax described by query.Syntax and returns
+// all matching notes, best first, without snippets. It returns at most
+// MaxHits hits; a query with exactly MaxHits hits may match more notes.
+func (ix *Index) Rank(q string, opts SearchOptions) ([]Hit, error) {
+    ix.mu.RLock()
+    defer ix.mu.RUnlock()
+    
+    parsed, err := ix.parseQuery(q, opts)
+    if err != nil {
+        return nil, err
+    }
+    
+    recency := ix.options.Recency
+    if opts.Recency != nil {
+        recency = *opts.Recency
+    }
+    
+    hits, err := ix.engine.search(parsed, fieldBoosts(ix.options.FieldBoosts, opts.Boosts), MaxHits)
+    if err != nil {
+        return nil, err
+    }
+    
+    var chunks map[string]chunk
+    if opts.Hybrid {
+        hits, chunks, err = ix.fuseSemantic(q, hits, semanticHits)
+        if err != nil {
+            return nil, err
+        }
+    }
+    
+    if !opts.Dates.IsZero() {
+        hits = ix.inDates(hits, opts.Dates)
+    }
+    if opts.Folder != "" {
+        hits = ix.inFolder(hits, opts.Folder)
+    }
+    
+    rankResults(hits, recency, time.Now())
+    if len(hits) > MaxHits {
+        hits = hits[:MaxHits]
+    }
+    
+    ranked := make([]Hit, len(hits))
+    for i, hit := range hits {
+        ranked[i] = Hit{FilePath: hit.path, Score: float32(hit.score), Language: hit.lang}
+        if c, ok := chunks[hit.path]; ok {
+            ranked[i].chunk = &c
+        }
+    }
+    return ranked, nil
+}
+
+// inFolder keeps the hits of files below the vault-relative folder.
+func (ix *Index) inFolder(hits []scoredResult, folder string) []scoredResult {
+    folder = strings.Trim(filepath.ToSlash(folder), "/")
+    if folder == "" {
+        return hits
+    }
+    kept := hits[:0]
+    for _, hit := range hits {
+        if strings.HasPrefix(ix.vaultPath(hit.path), folder+"/") {
+            kept = append(kept, hit)
+        }
+    }
+    return kept
+}
+
+// Results makes the snippets of hits that Rank returned for the same query
+// and options. Snippets are read from the notes as they are now.
+func (ix *Index) Results(q string, opts SearchOptions, hits []Hit) []SearchResult {
+    ix.mu.RLock()
+    defer ix.mu.RUnlock()
+    
+    // Snippets show the lines with the words and phrases that were searched,
+    // in the callouts searched for if any
+    var matcher *query.Matcher
+    var calloutTypes []string
+    if parsed, err := ix.parseQuery(q, opts); err == nil {
+        matcher = query.NewMatcher(parsed)
+        calloutTypes = query.FilterValues(parsed, "callout")
+    }
+    
+    var resolver *linkResolver
+    results := make([]SearchResult, 0, len(hits))
+    for _, hit := range hits {
+        // Canvases have cards instead of lines, and attachments may have
+        // pages
+        if isCanvas(hit.FilePath) {
+            snippet, nodes := ix.canvasSnippet(hit.FilePath, matcher, ix.options.SnippetLength)
+            results = append(results, SearchResult{
+                FilePath:    hit.FilePath,
+                Snippet:     snippet,
+                Score:       hit.Score,
+                LineNumbers: []int{},
+                Nodes:       nodes,
+                Language:    hit.Language,
+            })
+            continue
+        }
+        if ix.isAttachment(hit.FilePath) {
+            snippet, lineNumbers, pages := ix.attachmentSnippet(hit.FilePath, matcher, ix.options.SnippetLength)
+            results = append(results, SearchResult{
+                FilePath:    hit.FilePath,
+                Snippet:     snippet,
+                Score:       hit.Score,
+                LineNumbers: lineNumbers,
+                Pages:       pages,
+                Language:    hit.Language,
+            })
+            continue
+        }
+        
+        snippet := ""
+        lineNumbers := []int{}
+        var callouts []markdown.Callout
+        var aliases []string
+        if content, err := os.ReadFile(hit.FilePath); err == nil {
+            frontmatter, _, _ := markdown.SplitFrontmatter(string(content))
+            aliases = frontmatter.Aliases()
+            snippet, lineNumbers, callouts = ix.noteSnippet(string(content), matcher, calloutTypes, ix.options.SnippetLength)
+            
+            // The words may be in an embedded note
+            if snippet == "" && matcher != nil && ix.options.ResolveEmbeds {
+                if resolver == nil {
+                    resolver = ix.linkResolver()
+                }
+                snippet, lineNumbers = ix.embedSnippet(resolver, hit.FilePath, string(content), matcher, ix.options.SnippetLength)
+            }
+            if hit.chunk != nil && snippet == "" {
+                snippet, lineNumbers = chunkSnippet(string(content), *hit.chunk, ix.options.SnippetLength)
+            }
+        }
+        
+        results = append(results, SearchResult{
+            FilePath:    hit.FilePath,
+            Aliases:     aliases,
+            Snippet:     snippet,
+            Score:       hit.Score,
+            LineNumbers: lineNumbers,
+            Callouts:    callouts,
+            Language:    hit.Language,
+        })
+    }
+    return results
+}
+
+// parseQuery parses q and adds the fuzzy variants of its words.
+func (ix *Index) parseQuery(q string, opts SearchOptions) (query.Node, error) {
+    parsed, err := query.Parse(analysis.Normalize(q))
+    if err != nil {
+        return nil, fmt.Errorf("invalid query: %w", err)
+    }
+    if opts.Fuzzy != nil {
+        parsed = ix.expandFuzzy(parsed, *opts.Fuzzy)
+    }
+    return parsed, nil
+}
+
+// fuseSemantic adds the notes closest in meaning to q to the keyword hits
+// and orders both by reciprocal rank fusion. It also returns the best chunk
+// of every note found by meaning, for snippets.
+func (ix *Index) fuseSemantic(q string, hits []scoredResult, candidates int) ([]scoredResult, map[string]chunk, error) {
+    e, store := ix.semantic()
+    if e == nil {
+        return nil, nil, fmt.Errorf("semantic search is not configured")
+    }
+    vectors, err := e.Embed(context.Background(), []string{analysis.Normalize(q)})
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to embed query: %w", err)
+    }
+    if len(vectors) != 1 {
+        return nil, nil, fmt.Errorf("failed to embed query: got %d vectors", len(vectors))
+    }
+    
+    byPath := make(map[string]scoredResult, len(hits))
+    keyword := make([]string, len(hits))
+    for i, hit := range hits {
+        keyword[i] = hit.path
+        byPath[hit.path] = hit
+    }
+    
+    chunks := make(map[string]chunk)
+    var nearest []string
+    for _, hit := range store.search(normalize(vectors[0]), candidates) {
+        nearest = append(nearest, hit.path)
+        chunks[hit.path] = hit.chunk
+        if _, ok := byPath[hit.path]; !ok {
+            modified, _ := ix.indexedAt(hit.path)
+            byPath[hit.path] = scoredResult{path: hit.path, modified: modified}
+        }
+    }
+    
+    order, scores := fuseRanks(keyword, nearest)
+    fused := make([]scoredResult, len(order))
+    for i, path := range order {
+        fused[i] = byPath[path]
+        fused[i].score = scores[path]
+    }
+    return fused, chunks, nil
+}
+
+// createSnippet returns the lines matched by matcher, compared after
+// folding case and diacritics, and the numbers of all matching lines. Lines
+// with the longest phrases are shown first, so a phrase hit is not crowded
+// out by lines that only contain one of its words. The lines are shown in
+// order and as written in content.
+func (ix *Index) createSnippet(content string, matcher *query.Matcher, maxLength int) (string, []int) {
+    return lineSnippet(strings.Split(content, "\n"), nil, nil, matcher, maxLength)
+}
+
+// noteSnippet is createSnippet for the text and code of a note as it
+// reads, see markdown.Clean. Lines are numbered as in the note, and lines
+// in a callout start with its type and title, as "[decision: Use Postgres]".
+// With calloutTypes, only lines in callouts of these types are shown, or
+// the callouts themselves if no line matches. It also returns the
+// callouts of these types, or without calloutTypes those with matches.
+func (ix *Index) noteSnippet(content string, matcher *query.Matcher, calloutTypes []string, maxLength int) (string, []int, []markdown.Callout) {
+    lines, numbers := markdown.Clean(content).Reading()
+    callouts := markdown.Callouts(content)
+    in := calloutLines(callouts, numbers)
+    
+    if len(calloutTypes) == 0 {
+        snippet, matched := lineSnippet(lines, numbers, calloutLabels(in), matcher, maxLength)
+        var found []markdown.Callout
+        for j, n := range numbers {
+            if c := in[j]; c != nil && slices.Contains(matched, n) && !slices.Contains(found, *c) {
+                found = append(found, *c)
+            }
+        }
+        return snippet, matched, found
+    }
+    
+    var typed []markdown.Callout
+    for _, c := range callouts {
+        if slices.Contains(calloutTypes, c.Type) {
+            typed = append(typed, c)
+        }
+    }
+    var keptLines []string
+    var keptNumbers []int
+    var keptIn []*markdown.Callout
+    for j, line := range lines {
+        if c := in[j]; c != nil && slices.Contains(calloutTypes, c.Type) {
+            keptLines = append(keptLines, line)
+            keptNumbers = append(keptNumbers, numbers[j])
+            keptIn = append(keptIn, c)
+        }
+    }
+    snippet, matched := lineSnippet(keptLines, keptNumbers, calloutLabels(keptIn), matcher, maxLength)
+    if snippet == "" {
+        var headers []string
+        for _, c := range typed {
+            headers = append(headers, fmt.Sprintf("L%d: [%s] %s", c.Line, c.Type, c.Title))
+        }
+        if len(headers) > 3 { // Max 3 lines in snippet
+            headers = headers[:3]
+        }
+        snippet = strings.Join(headers, "\n")
+    }
+    return snippet, matched, typed
+}
+
+// embedSnippet is noteSnippet for the notes that the note at file embeds,
+// for words that are only found in them. The embedded lines have no lines
+// in the note, so they are numbered with the line of their embed.
+func (ix *Index) embedSnippet(resolver *linkResolver, file, content string, matcher *query.Matcher, maxLength int) (string, []int) {
+    _, body, offset := markdown.SplitFrontmatter(analysis.Normalize(content))
+    var lines []string
+    var numbers []int
+    var in []*markdown.Callout
+    for _, part := range embeds(resolver, file, body, 1, map[string]bool{file: true}) {
+        partLines, partNumbers := markdown.Clean(part.Text).Reading()
+        lines = append(lines, partLines...)
+        in = append(in, calloutLines(markdown.Callouts(part.Text), partNumbers)...)
+        for range partLines {
+            numbers = append(numbers, offset+part.Line)
+        }
+    }
+    
+    snippet, matched := lineSnippet(lines, numbers, calloutLabels(in), matcher, maxLength)
+    return snippet, slices.Compact(matched)
+}
+
+// calloutLines returns the innermost of callouts that each of the lines
+// numbered by numbers is in, or nil for lines outside of callouts.
+func calloutLines(callouts []markdown.Callout, numbers []int) []*markdown.Callout {
+    // Nested callouts come after the ones they are in
+    in := make([]*markdown.Callout, len(numbers))
+    for i := range callouts {
+        for j, n := range numbers {
+            if n >= callouts[i].Line && n <= callouts[i].End {
+                in[j] = &callouts[i]
+            }
+        }
+    }
+    return in
+}
+
+// calloutLabels returns the labels of lineSnippet for lines in the
+// callouts in, or nil if no line is in one.
+func calloutLabels(in []*markdown.Callout) []string {
+    var labels []string
+    for i, c := range in {
+        if c == nil {
+            continue
+        }
+        if labels == nil {
+            labels = make([]string, len(in))
+        }
+        labels[i] = fmt.Sprintf("[%s: %s] ", c.Type, c.Title)
+    }
+    return labels
+}
+
+// lineSnippet makes the snippet of createSnippet from lines. numbers holds
+// the line number of every line; with nil the lines are numbered from 1.
+// labels, if not nil, holds text shown before every line.
+func lineSnippet(lines []string, numbers []int, labels []string, matcher *query.Matcher, maxLength int) (string, []int) {
+    best, matchedLines := matchLines(lines, matcher)
+    number := func(n int) int {
+        if numbers == nil {
+            return n
+        }
+        return numbers[n-1]
+    }
+    
+    var snippetParts []string
+    for _, n := range best {
+        label := ""
+        if labels != nil {
+            label = labels[n-1]
+        }
+        snippetParts = append(snippetParts, fmt.Sprintf("L%d: %s%s", number(n), label, lines[n-1]))
+    }
+    for i, n := range matchedLines {
+        matchedLines[i] = number(n)
+    }
+    
+    snippet := strings.Join(snippetParts, "\n")
+    if runes := []rune(snippet); len(runes) > maxLength {
+        snippet = string(runes[:maxLength]) + "..."
+    }
+    
+    return snippet, matchedLines
+}
+
+// matchLines returns the numbers of the up to three lines that matcher
+// matches best, in order, and of all lines it matches.
+func matchLines(lines []string, matcher *query.Matcher) ([]int, []int) {
+    var matchedLines []int
+    weights := make(map[int]int)
+    
+    for i, line := range lines {
+        if weight := matcher.Match(line); weight > 0 {
+            matchedLines = append(matchedLines, i+1)
+            weights[i+1] = weight
+        }
+    }
+    
+    best := append([]int(nil), matchedLines...)
+    sort.SliceStable(best, func(i, j int) bool {
+        return weights[best[i]] > weights[best[j]]
+    })
+    if len(best) > 3 { // Max 3 lines in snippet
+        best = best[:3]
+    }
+    sort.Ints(best)
+    return best, matchedLines
+}
+
+func (ix *Index) loadIndexTimestamps() {
+    timestampFile := filepath.Join(ix.indexPath, ".timestamps")
+    data, err := os.ReadFile(timestampFile)
+    if err != nil {
+        return
+    }
+    
+    lines := strings.Split(string(data), "\n")
+    for _, line := range lines {
+        parts := strings.Split(line, "|")
+        if len(parts) == 2 {
+            timestamp, _ := time.Parse(time.RFC3339Nano, parts[1])
+            ix.lastIndexed[parts[0]] = timestamp
+        }
+    }
+}
+
+func (ix *Index) saveIndexTimestamps() {
+    timestampFile := filepath.Join(ix.indexPath, ".timestamps")
+    var lines []string
+    
+    // Nanosecond precision so the registry compares equal to file mtimes
+    for path, timestamp := range ix.IndexedFiles() {
+        lines = append(lines, fmt.Sprintf("%s|%s", path, timestamp.Format(time.RFC3339Nano)))
+    }
+    
+    os.WriteFile(timestampFile, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+func (ix *Index) saveTerms() {
+    if err := ix.terms.save(filepath.Join(ix.indexPath, ".terms")); err != nil {
+        slog.Error("Failed to save term dictionary", "err", err)
+    }
+}
+
+func (ix *Index) UpdateFile(path string) error {
+    ix.mu.Lock()
+    info, err := os.Stat(path)
+    if err == nil {
+        resolver := ix.linkResolver(path)
+        if err = ix.indexFile(path, info, resolver); err == nil {
+            resolver.setAliases(ix.properties.aliases())
+            ix.updateEmbedders(resolver, map[string]bool{path: true})
+        }
+    }
+    ix.mu.Unlock()
+    if err != nil {
+        return err
+    }
+    
+    ix.updateVectors(path)
+    return nil
+}
+
+func (ix *Index) RemoveFile(path string) error {
+    ix.mu.Lock()
+    defer ix.mu.Unlock()
+    
+    if err := ix.removeFile(path); err != nil {
+        return err
+    }
+    ix.updateEmbedders(ix.linkResolver(), map[string]bool{path: true})
+    return nil
+}
+
+func (ix *Index) removeFile(path string) error {
+    err := ix.engine.remove(path)
+    if err != nil {
+        return err
+    }
+    
+    ix.regMu.Lock()
+    delete(ix.lastIndexed, path)
+    ix.regMu.Unlock()
+    ix.terms.remove(path)
+    ix.tasks.remove(path)
+    ix.properties.remove(path)
+    ix.refs.remove(path)
+    ix.extracted.remove(path)
+    if _, store := ix.semantic(); store != nil {
+        store.remove(path)
+    }
+    
+    return nil
+}
+
+func (ix *Index) Close() error {
+    ix.saveIndexTimestamps()
+    ix.saveTerms()
+    ix.saveTasks()
+    ix.saveProperties()
+    ix.saveRefs()
+    if _, store := ix.semantic(); store != nil {
+        if err := store.save(filepath.Join(ix.indexPath, ".vectors")); err != nil {
+            slog.Error("Failed to save vectors", "err", err)
+        }
+    }
+    return ix.engine.close()
+}
+
+func (ix *Index) GetIndexedFilesCount() int {
+    ix.regMu.Lock()
+    defer ix.regMu.Unlock()
+    return len(ix.lastIndexed)
+}
+
+// IndexStats describes the size and contents of an index.
+type IndexStats struct {
+    Files     int    `json:"files"`
+    Documents uint64 `json:"documents"`
+    SizeBytes int64  `json:"size_bytes"`
+}
+
+// Stats reports the number of indexed files and documents and the size of
+// the index on disk.
+func (ix *Index) Stats() (IndexStats, error) {
+    ix.mu.RLock()
+    defer ix.mu.RUnlock()
+    
+    stats := IndexStats{Files: ix.GetIndexedFilesCount()}
+    
+    docs, err := ix.engine.numDocs()
+    if err != nil {
+        return stats, fmt.Errorf("failed to count documents: %w", err)
+    }
+    stats.Documents = docs
+    
+    err = filepath.Walk(ix.indexPath, func(path string, info os.FileInfo, err error) error {
+        if err == nil && !info.IsDir() {
+            stats.SizeBytes += info.Size()
+        }
+        return nil
+    })
+    
+    return stats, err
+}
+
+// CheckReport lists the differences between a vault and its index.
+type CheckReport struct {
+    Missing   []string `json:"missing"`
+    Stale     []string `json:"stale"`
+    Outdated  []string `json:"outdated"`
+    Files     int      `json:"files"`
+    Documents uint64   `json:"documents"`
+}
+
+// Consistent reports whether the index matches the vault exactly. Every
+// indexed file is one document, so a different number of documents means
+// the search engine and the file registry drifted apart.
+func (r *CheckReport) Consistent() bool {
+    return len(r.Missing) == 0 && len(r.Stale) == 0 && len(r.Outdated) == 0 &&
+        r.Documents == uint64(r.Files)
+}
+
+// Check compares the index against the files below rootPath: files that are
+// not indexed, indexed files that no longer exist or are now ignored, and
+// files modified since they were indexed.
+func (ix *Index) Check(rootPath string) (*CheckReport, error) {
+    ix.mu.RLock()
+    defer ix.mu.RUnlock()
+    
+    if _, err := os.Stat(rootPath); err != nil {
+        return nil, fmt.Errorf("vault not accessible: %w", err)
+    }
+    
+    report := &CheckReport{}
+    known := ix.IndexedFiles()
+    report.Files = len(known)
+    
+    err := ix.walkVault(rootPath, func(path string, info os.FileInfo) {
+        modTime, exists := known[path]
+        switch {
+        case !exists:
+            report.Missing = append(report.Missing, path)
+        case info.ModTime().After(modTime):
+            report.Outdated = append(report.Outdated, path)
+        }
+        delete(known, path)
+    })
+    if err != nil {
+        return nil, err
+    }
+    
+    for path := range known {
+        report.Stale = append(report.Stale, path)
+    }
+    sort.Strings(report.Missing)
+    sort.Strings(report.Stale)
+    sort.Strings(report.Outdated)
+    
+    if report.Documents, err = ix.engine.numDocs(); err != nil {
+        return nil, fmt.Errorf("failed to count documents: %w", err)
+    }
+    
+    return report, nil
+}
\ No newline at end of file
@@ -0,0 +1,532 @@
+package index
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/ignore"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+)
+
+// testBackends lists the backends the index tests run against. Tantivy
+// needs its C library, which is only installed in CI.
+func testBackends() []string {
+    backends := []string{BackendBM25}
+    if tantivyAvailable && os.Getenv("CI") != "" {
+        backends = append(backends, BackendTantivy)
+    }
+    return backends
+}
+
+// forEachBackend runs test once per backend in testBackends, with a new
+// index in a temporary directory.
+func forEachBackend(t *testing.T, test func(t *testing.T, index *Index)) {
+    for _, backend := range testBackends() {
+        t.Run(backend, func(t *testing.T) {
+            opts := DefaultOptions()
+            opts.Backend = backend
+            index, err := Open(filepath.Join(t.TempDir(), "test-index"), opts)
+            if err != nil {
+                t.Fatalf("Failed to create index: %v", err)
+            }
+            defer index.Close()
+            test(t, index)
+        })
+    }
+}
+
+func TestOpen(t *testing.T) {
+    forEachBackend(t, func(t *testing.T, index *Index) {
+        if index.GetIndexedFilesCount() != 0 {
+            t.Errorf("Expected 0 indexed files, got %d", index.GetIndexedFilesCount())
+        }
+    })
+}
+
+func TestResolveBackend(t *testing.T) {
+    backend, err := resolveBackend(BackendAuto)
+    if err != nil {
+        t.Fatalf("resolveBackend failed: %v", err)
+    }
+    want := BackendBM25
+    if tantivyAvailable {
+        want = BackendTantivy
+    }
+    if backend != want {
+        t.Errorf("Expected auto to pick %s, got %s", want, backend)
+    }
+    if _, err := resolveBackend("lucene"); err == nil {
+        t.Error("Expected an error for an unknown backend")
+    }
+}
+
+func TestIndexAndSearch(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    
+    // Create test markdown file
+    os.MkdirAll(vaultPath, 0755)
+    testFile := filepath.Join(vaultPath, "test.md")
+    content := `# Test Document
+
+This is a test document for searching.
+It contains some keywords like golang and tantivy.
+`
+    os.WriteFile(testFile, []byte(content), 0644)
+    
+    forEachBackend(t, func(t *testing.T, index *Index) {
+        err := index.IndexDirectory(vaultPath, 1)
+        if err != nil {
+            t.Fatalf("Failed to index directory: %v", err)
+        }
+        
+        // Test search
+        results, err := index.Search("golang", 10)
+        if err != nil {
+            t.Fatalf("Search failed: %v", err)
+        }
+        
+        if len(results) != 1 {
+            t.Errorf("Expected 1 result, got %d", len(results))
+        }
+        
+        if len(results) > 0 && results[0].FilePath != testFile {
+            t.Errorf("Expected file path %s, got %s", testFile, results[0].FilePath)
+        }
+    })
+}
+
+func TestIndexCleansMarkdown(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(vaultPath, 0755)
+    content := strings.Join([]string{
+        "# Reading list",
+        "%% private: salary %%",
+        "",
+        "Started [[Books/Dune|a desert novel]] today.",
+        "Review at https://reviews.example.org/dune",
+    }, "\n")
+    os.WriteFile(filepath.Join(vaultPath, "reading.md"), []byte(content), 0644)
+    
+    forEachBackend(t, func(t *testing.T, index *Index) {
+        if err := index.IndexDirectory(vaultPath, 1); err != nil {
+            t.Fatalf("Failed to index directory: %v", err)
+        }
+        
+        // Comments and link targets are not searched
+        for _, q := range []string{"salary", "Books"} {
+            if results, _ := index.Search(q, 10); len(results) != 0 {
+                t.Errorf("Expected no results for %q, got %+v", q, results)
+            }
+        }
+        
+        // Snippets show the label, with the line number of the note
+        results, err := index.Search("desert", 10)
+        if err != nil || len(results) != 1 {
+            t.Fatalf("Expected one result for the link label, got %+v, %v", results, err)
+        }
+        if r := results[0]; r.Snippet != "L4: Started a desert novel today." || len(r.LineNumbers) != 1 || r.LineNumbers[0] != 4 {
+            t.Errorf("Expected the cleaned line 4, got %+v", r)
+        }
+        
+        // URLs are searched in their own field
+        if results, _ := index.Search("reviews", 10); len(results) != 1 {
+            t.Errorf("Expected the URL to be found, got %+v", results)
+        }
+        noURLs := SearchOptions{Boosts: map[string]float32{"urls": 0}}
+        if results, _ := index.SearchWithOptions("reviews", 10, noURLs); len(results) != 0 {
+            t.Errorf("Expected the URL not to be content, got %+v", results)
+        }
+    })
+}
+
+func TestIndexCode(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(vaultPath, 0755)
+    os.WriteFile(filepath.Join(vaultPath, "client.md"), []byte(strings.Join([]string{
+        "# HTTP client",
+        "Requests give up after a timeout.",
+        "```go",
+        "ctx, cancel := context.WithTimeout(ctx, max_wait)",
+        "defer cancel()",
+        "```",
+    }, "\n")), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "retry.md"), []byte(strings.Join([]string{
+        "# Retries",
+        "Retry with a timeout, see context.WithTimeout in the Go client.",
+        "```python",
+        "def retry(max_wait): ...",
+        "```",
+    }, "\n")), 0644)
+    
+    forEachBackend(t, func(t *testing.T, index *Index) {
+        if err := index.IndexDirectory(vaultPath, 1); err != nil {
+            t.Fatalf("Failed to index directory: %v", err)
+        }
+        paths := func(q string, opts SearchOptions) []string {
+            t.Helper()
+            results, err := index.SearchWithOptions(q, 10, opts)
+            if err != nil {
+                t.Fatalf("Search %q failed: %v", q, err)
+            }
+            var paths []string
+            for _, r := range results {
+                paths = append(paths, filepath.Base(r.FilePath))
+            }
+            return paths
+        }
+        
+        // Identifiers are matched whole in code, and only code is searched
+        // with a zero weight for everything else
+        codeOnly := SearchOptions{Boosts: map[string]float32{"title": 0, "aliases": 0, "headings": 0, "tags": 0, "path": 0, "content": 0, "urls": 0}}
+        if got := paths("max_wait", codeOnly); len(got) != 2 {
+            t.Errorf("Expected max_wait in both notes, got %v", got)
+        }
+        if got := paths("context.WithTimeout", codeOnly); len(got) != 1 || got[0] != "client.md" {
+            t.Errorf("Expected only the note with the call in code, got %v", got)
+        }
+        if got := paths("cancel", SearchOptions{Boosts: map[string]float32{"code": 0}}); len(got) != 0 {
+            t.Errorf("Expected code not to be content, got %v", got)
+        }
+        
+        // Filters on the language of the code
+        if got := paths("lang:go timeout", SearchOptions{}); len(got) != 1 || got[0] != "client.md" {
+            t.Errorf("Expected only the note with Go code, got %v", got)
+        }
+        if got := paths("lang:go lang:Python", SearchOptions{}); len(got) != 2 {
+            t.Errorf("Expected notes with either language, got %v", got)
+        }
+        if got := paths("timeout -lang:go", SearchOptions{}); len(got) != 1 || got[0] != "retry.md" {
+            t.Errorf("Expected the note without Go code, got %v", got)
+        }
+        
+        // Snippets show code lines with their line numbers
+        results, _ := index.Search("lang:go context.WithTimeout", 10)
+        if len(results) != 1 || results[0].Snippet != "L4: ctx, cancel := context.WithTimeout(ctx, max_wait)" || results[0].LineNumbers[0] != 4 {
+            t.Errorf("Expected the line of code, got %+v", results)
+        }
+    })
+}
+
+func TestIndexCallouts(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(filepath.Join(vaultPath, "Projects"), 0755)
+    os.WriteFile(filepath.Join(vaultPath, "Projects", "storage.md"), []byte(strings.Join([]string{
+        "# Storage",
+        "Postgres and SQLite were compared.",
+        "> [!Decision] Use Postgres",
+        "> Postgres replaces SQLite for the sync server.",
+        "",
+        "> [!warning]",
+        "> Migrations need a Postgres backup first.",
+    }, "\n")), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Projects", "cache.md"), []byte(strings.Join([]string{
+        "# Cache",
+        "> [!note] Postgres",
+        "> Postgres is not needed for the cache.",
+    }, "\n")), 0644)
+    os.MkdirAll(filepath.Join(vaultPath, "Archive"), 0755)
+    os.WriteFile(filepath.Join(vaultPath, "Archive", "queue.md"), []byte("> [!decision] Use Redis\n> Redis holds the job queue."), 0644)
+    
+    forEachBackend(t, func(t *testing.T, index *Index) {
+        if err := index.IndexDirectory(vaultPath, 1); err != nil {
+            t.Fatalf("Failed to index directory: %v", err)
+        }
+        
+        // The filter keeps notes with a decision, and the snippet lines in it
+        results, err := index.Search("callout:decision postgres", 10)
+        if err != nil {
+            t.Fatalf("Search failed: %v", err)
+        }
+        if len(results) != 1 || filepath.Base(results[0].FilePath) != "storage.md" {
+            t.Fatalf("Expected only the note with a decision, got %+v", results)
+        }
+        want := "L3: [decision: Use Postgres] Use Postgres\nL4: [decision: Use Postgres] Postgres replaces SQLite for the sync server."
+        if results[0].Snippet != want {
+            t.Errorf("Expected the lines of the decision, got %q", results[0].Snippet)
+        }
+        if c := results[0].Callouts; len(c) != 1 || c[0].Type != "decision" || c[0].Title != "Use Postgres" || c[0].Line != 3 || c[0].End != 4 {
+            t.Errorf("Expected the decision callout, got %+v", c)
+        }
+        
+        // A filter alone lists the titles, in all folders or in one
+        if results, _ = index.Search("callout:decision", 10); len(results) != 2 {
+            t.Errorf("Expected the decisions of all folders, got %+v", results)
+        }
+        results, _ = index.SearchWithOptions("callout:decision", 10, SearchOptions{Folder: "Projects/"})
+        if len(results) != 1 || results[0].Snippet != "L3: [decision] Use Postgres" {
+            t.Errorf("Expected the title of the decision in the folder, got %+v", results)
+        }
+        
+        // Without a filter lines are labeled by the callout they are in
+        results, _ = index.Search("backup", 10)
+        if len(results) != 1 || results[0].Snippet != "L7: [warning: Warning] Migrations need a Postgres backup first." {
+            t.Errorf("Expected the line of the warning, got %+v", results)
+        } else if c := results[0].Callouts; len(c) != 1 || c[0].Type != "warning" {
+            t.Errorf("Expected the warning callout, got %+v", c)
+        }
+    })
+}
+
+func TestIndexAliases(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(vaultPath, 0755)
+    os.WriteFile(filepath.Join(vaultPath, "Kubernetes Cluster Notes.md"), []byte("---\naliases:\n  - K8s\n  - Kube\n---\n# Nodes\nThree nodes per region."), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Ops.md"), []byte("Upgrade [[K8s]] before mentioning kube in the k8s channel.\n![[k8s#Nodes]]"), 0644)
+    
+    forEachBackend(t, func(t *testing.T, index *Index) {
+        if err := index.IndexDirectory(vaultPath, 1); err != nil {
+            t.Fatalf("Failed to index directory: %v", err)
+        }
+        
+        // The note named by an alias ranks first and lists its aliases
+        results, err := index.Search("k8s", 10)
+        if err != nil {
+            t.Fatalf("Search failed: %v", err)
+        }
+        if len(results) != 2 || filepath.Base(results[0].FilePath) != "Kubernetes Cluster Notes.md" {
+            t.Fatalf("Expected the note with the alias first, got %+v", results)
+        }
+        if a := results[0].Aliases; len(a) != 2 || a[0] != "K8s" || a[1] != "Kube" {
+            t.Errorf("Expected the aliases with the result, got %v", a)
+        }
+        if results[1].Aliases != nil {
+            t.Errorf("Expected no aliases for a note without, got %v", results[1].Aliases)
+        }
+        
+        // Links and names resolve by alias
+        r, err := index.Resolve("[[kube#Nodes]]", "")
+        if err != nil || r.Path != "Kubernetes Cluster Notes.md" || r.Text != "# Nodes\nThree nodes per region." {
+            t.Errorf("Expected the section of the aliased note, got %+v, %v", r, err)
+        }
+        if got := index.Lookup("K8s"); filepath.Base(got) != "Kubernetes Cluster Notes.md" {
+            t.Errorf("Expected the note of the alias, got %q", got)
+        }
+        if got := index.Lookup("Ops"); filepath.Base(got) != "Ops.md" {
+            t.Errorf("Expected the note of the name, got %q", got)
+        }
+        
+        // An embed by alias is followed, also on the first run
+        if results, _ := index.Search("region", 10); len(results) != 2 {
+            t.Errorf("Expected the note embedding the section by alias, got %+v", results)
+        }
+    })
+}
+
+func TestCheck(t *testing.T) {
+    forEachBackend(t, func(t *testing.T, index *Index) {
+        // The vault is changed below, so every backend gets its own
+        vaultPath := filepath.Join(t.TempDir(), "vault")
+        os.MkdirAll(filepath.Join(vaultPath, "Drafts"), 0755)
+        write := func(name, content string) string {
+            path := filepath.Join(vaultPath, name)
+            os.WriteFile(path, []byte(content), 0644)
+            return path
+        }
+        plan := write("Plan.md", "# Plan\nShip the release.")
+        retro := write("Retro.md", "What went well.")
+        draft := write("Drafts/Idea.md", "Half an idea.")
+        write("Board.canvas", `{"nodes": [{"id": "1", "type": "text", "text": "Release board"}]}`)
+        
+        if err := index.IndexDirectory(vaultPath, 1); err != nil {
+            t.Fatalf("Failed to index directory: %v", err)
+        }
+        
+        // Every file is one document, also after it is indexed again
+        if err := index.UpdateFile(plan); err != nil {
+            t.Fatalf("Failed to update file: %v", err)
+        }
+        report, err := index.Check(vaultPath)
+        if err != nil {
+            t.Fatalf("Check failed: %v", err)
+        }
+        if !report.Consistent() || report.Files != 4 || report.Documents != 4 {
+            t.Errorf("Expected a consistent index of 4 files, got %+v", report)
+        }
+        
+        // New, deleted, newly ignored and modified files
+        added := write("Todo.md", "Write the changelog.")
+        os.Remove(retro)
+        matcher, err := ignore.Load(vaultPath, []string{"Drafts/"})
+        if err != nil {
+            t.Fatalf("Failed to load ignore rules: %v", err)
+        }
+        index.SetIgnore(matcher)
+        later := time.Now().Add(time.Hour)
+        os.Chtimes(plan, later, later)
+        
+        report, err = index.Check(vaultPath)
+        if err != nil {
+            t.Fatalf("Check failed: %v", err)
+        }
+        if len(report.Missing) != 1 || report.Missing[0] != added {
+            t.Errorf("Expected the new file to be missing, got %v", report.Missing)
+        }
+        if len(report.Stale) != 2 || report.Stale[0] != draft || report.Stale[1] != retro {
+            t.Errorf("Expected the ignored and deleted files to be stale, got %v", report.Stale)
+        }
+        if len(report.Outdated) != 1 || report.Outdated[0] != plan {
+            t.Errorf("Expected the touched file to be outdated, got %v", report.Outdated)
+        }
+        if report.Consistent() {
+            t.Error("Expected an inconsistent index")
+        }
+    })
+}
+
+func TestRebuild(t *testing.T) {
+    forEachBackend(t, func(t *testing.T, index *Index) {
+        vaultPath := filepath.Join(t.TempDir(), "vault")
+        os.MkdirAll(vaultPath, 0755)
+        kept := filepath.Join(vaultPath, "Plan.md")
+        deleted := filepath.Join(vaultPath, "Retro.md")
+        os.WriteFile(kept, []byte("Ship the release."), 0644)
+        os.WriteFile(deleted, []byte("What went well."), 0644)
+        if err := index.IndexDirectory(vaultPath, 1); err != nil {
+            t.Fatalf("Failed to index directory: %v", err)
+        }
+        
+        // A file the watcher updates during the rebuild is indexed once
+        os.Remove(deleted)
+        added := filepath.Join(vaultPath, "Todo.md")
+        os.WriteFile(added, []byte("Write the changelog."), 0644)
+        done := make(chan error)
+        go func() { done <- index.UpdateFile(added) }()
+        if err := index.Rebuild(vaultPath, 2); err != nil {
+            t.Fatalf("Rebuild failed: %v", err)
+        }
+        if err := <-done; err != nil {
+            t.Fatalf("Failed to update file: %v", err)
+        }
+        
+        report, err := index.Check(vaultPath)
+        if err != nil {
+            t.Fatalf("Check failed: %v", err)
+        }
+        if !report.Consistent() || report.Files != 2 || report.Documents != 2 {
+            t.Errorf("Expected the kept and added files only, got %+v", report)
+        }
+        if results, _ := index.Search("well", 10); len(results) != 0 {
+            t.Errorf("Expected the deleted file to be gone, got %+v", results)
+        }
+    })
+}
+
+func TestConsistent(t *testing.T) {
+    tests := []struct {
+        report CheckReport
+        want   bool
+    }{
+        {CheckReport{Files: 2, Documents: 2}, true},
+        {CheckReport{}, true},
+        {CheckReport{Missing: []string{"a.md"}, Files: 1, Documents: 1}, false},
+        {CheckReport{Stale: []string{"a.md"}, Files: 1, Documents: 1}, false},
+        {CheckReport{Outdated: []string{"a.md"}, Files: 1, Documents: 1}, false},
+        // A document left behind by a file that is no longer registered
+        {CheckReport{Files: 1, Documents: 2}, false},
+    }
+    for _, tt := range tests {
+        if got := tt.report.Consistent(); got != tt.want {
+            t.Errorf("Consistent(%+v) = %v, want %v", tt.report, got, tt.want)
+        }
+    }
+}
+
+func TestPrepareIndexDirResetsOnSchemaChange(t *testing.T) {
+    indexPath := t.TempDir()
+    os.WriteFile(filepath.Join(indexPath, "meta.json"), []byte("{}"), 0644)
+    os.Mkdir(filepath.Join(indexPath, "other-vault"), 0755)
+    
+    if err := prepareIndexDir(indexPath, schemaSignature(BackendTantivy, []string{"en"})); err != nil {
+        t.Fatalf("prepareIndexDir failed: %v", err)
+    }
+    if _, err := os.Stat(filepath.Join(indexPath, "meta.json")); !os.IsNotExist(err) {
+        t.Error("Expected index files of an unversioned index to be removed")
+    }
+    if _, err := os.Stat(filepath.Join(indexPath, "other-vault")); err != nil {
+        t.Error("Expected subdirectories to be kept")
+    }
+    
+    // Same schema keeps the index
+    os.WriteFile(filepath.Join(indexPath, "meta.json"), []byte("{}"), 0644)
+    prepareIndexDir(indexPath, schemaSignature(BackendTantivy, []string{"en"}))
+    if _, err := os.Stat(filepath.Join(indexPath, "meta.json")); err != nil {
+        t.Error("Expected index to be kept when the schema is unchanged")
+    }
+    
+    // Adding a language changes the schema
+    prepareIndexDir(indexPath, schemaSignature(BackendTantivy, []string{"en", "de"}))
+    if _, err := os.Stat(filepath.Join(indexPath, "meta.json")); !os.IsNotExist(err) {
+        t.Error("Expected index to be reset when languages change")
+    }
+    
+    // So does another backend
+    prepareIndexDir(indexPath, schemaSignature(BackendTantivy, []string{"en", "de"}))
+    os.WriteFile(filepath.Join(indexPath, "meta.json"), []byte("{}"), 0644)
+    prepareIndexDir(indexPath, schemaSignature(BackendBM25, []string{"en", "de"}))
+    if _, err := os.Stat(filepath.Join(indexPath, "meta.json")); !os.IsNotExist(err) {
+        t.Error("Expected index to be reset when the backend changes")
+    }
+}
+
+func TestCreateSnippetFoldsDiacritics(t *testing.T) {
+    ix := &Index{}
+    content := "# Reise\nAnkunft in München am Montag\nWeiter nach Köln"
+    
+    snippet, lines := ix.createSnippet(content, testMatcher(t, "Munchen"), 150)
+    if len(lines) != 1 || lines[0] != 2 {
+        t.Fatalf("Expected a match on line 2, got %v", lines)
+    }
+    if snippet != "L2: Ankunft in München am Montag" {
+        t.Errorf("Expected the original line in the snippet, got %q", snippet)
+    }
+    
+    if _, lines := ix.createSnippet(content, testMatcher(t, "KÖLN"), 150); len(lines) != 1 || lines[0] != 3 {
+        t.Errorf("Expected a case-insensitive match on line 3, got %v", lines)
+    }
+}
+
+func testMatcher(t *testing.T, q string) *query.Matcher {
+    t.Helper()
+    parsed, err := query.Parse(q)
+    if err != nil {
+        t.Fatalf("Parse(%q) failed: %v", q, err)
+    }
+    return query.NewMatcher(parsed)
+}
+
+func TestCreateSnippetPrefersPhrases(t *testing.T) {
+    ix := &Index{}
+    content := strings.Join([]string{
+        "# Notes",
+        "The budget is tight",
+        "Review the plan",
+        "Budget planning starts Monday",
+        "Budget again",
+        "Planning poker",
+        "Quarterly budget planning",
+    }, "\n")
+    
+    snippet, lines := ix.createSnippet(content, testMatcher(t, `"budget planning" OR review`), 500)
+    if len(lines) != 3 || lines[0] != 3 || lines[1] != 4 || lines[2] != 7 {
+        t.Errorf("Expected matches on lines 3, 4 and 7, got %v", lines)
+    }
+    want := "L3: Review the plan\nL4: Budget planning starts Monday\nL7: Quarterly budget planning"
+    if snippet != want {
+        t.Errorf("snippet = %q, want %q", snippet, want)
+    }
+    
+    // Lines with the whole phrase come before lines with single words
+    snippet, _ = ix.createSnippet(content, testMatcher(t, `budget "budget planning"`), 500)
+    want = "L2: The budget is tight\nL4: Budget planning starts Monday\nL7: Quarterly budget planning"
+    if snippet != want {
+        t.Errorf("Expected both phrase lines and the first budget line, got %q", snippet)
+    }
+    
+    // Excluded words never select lines
+    if _, lines := ix.createSnippet(content, testMatcher(t, "review -budget"), 500); len(lines) != 1 || lines[0] != 3 {
+        t.Errorf("Expected only the review line, got %v", lines)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,186 @@
+package index
+
+import (
+    "path"
+    "path/filepath"
+    "slices"
+    "sort"
+    "strings"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+)
+
+// linkResolver finds the notes that links point to, the way Obsidian does.
+// A target with a folder is a path relative to the linking note or else to
+// the vault root; a bare name is the note of that name, preferring one in
+// the linking note's folder and then the one with the shortest path. A name
+// no note has is looked up among the aliases of the notes in the same way.
+// Names are compared case-insensitively and the ".md" extension is
+// optional.
+type linkResolver struct {
+    root    string
+    byPath  map[string]string
+    byName  map[string][]string
+    byAlias map[string][]string
+    aliases map[string][]string
+}
+
+// newLinkResolver indexes files, the absolute paths of the notes below root.
+func newLinkResolver(root string, files []string) *linkResolver {
+    r := &linkResolver{
+        root:    root,
+        byPath:  make(map[string]string, len(files)),
+        byName:  make(map[string][]string, len(files)),
+        byAlias: make(map[string][]string),
+        aliases: make(map[string][]string),
+    }
+    sort.Slice(files, func(i, j int) bool {
+        if len(files[i]) != len(files[j]) {
+            return len(files[i]) < len(files[j])
+        }
+        return files[i] < files[j]
+    })
+    for _, file := range files {
+        key := linkKey(r.rel(file))
+        r.byPath[key] = file
+        name := path.Base(key)
+        r.byName[name] = append(r.byName[name], file)
+    }
+    return r
+}
+
+// setAliases sets the aliases of notes, by file, that links are resolved
+// by. aliases holds those of all notes, so the aliases set before are
+// dropped, also those of notes that no longer have any. Aliases of files
+// the resolver does not know are left out.
+func (r *linkResolver) setAliases(aliases map[string][]string) {
+    r.byAlias = make(map[string][]string)
+    r.aliases = make(map[string][]string)
+    files := make([]string, 0, len(aliases))
+    for file := range aliases {
+        if _, ok := r.byPath[linkKey(r.rel(file))]; ok {
+            files = append(files, file)
+        }
+    }
+    sort.Slice(files, func(i, j int) bool {
+        if len(files[i]) != len(files[j]) {
+            return len(files[i]) < len(files[j])
+        }
+        return files[i] < files[j]
+    })
+    for _, file := range files {
+        r.aliases[file] = aliases[file]
+        for _, alias := range aliases[file] {
+            key := strings.ToLower(alias)
+            if !slices.Contains(r.byAlias[key], file) {
+                r.byAlias[key] = append(r.byAlias[key], file)
+            }
+        }
+    }
+}
+
+// linkResolver returns a resolver for the notes in the index and the extra
+// notes that are about to be added, with the aliases of indexed notes.
+func (ix *Index) linkResolver(extra ...string) *linkResolver {
+    indexed := ix.IndexedFiles()
+    files := make([]string, 0, len(indexed)+len(extra))
+    for file := range indexed {
+        files = append(files, file)
+    }
+    for _, file := range extra {
+        if _, ok := indexed[file]; !ok {
+            files = append(files, file)
+        }
+    }
+    r := newLinkResolver(ix.ignoreMatcher().Root(), files)
+    r.setAliases(ix.properties.aliases())
+    return r
+}
+
+// rel returns file relative to the vault root in slash form.
+func (r *linkResolver) rel(file string) string {
+    if rel, err := filepath.Rel(r.root, file); err == nil {
+        return filepath.ToSlash(rel)
+    }
+    return filepath.ToSlash(file)
+}
+
+// linkKey lowercases target and removes a ".md" extension.
+func linkKey(target string) string {
+    key := strings.ToLower(strings.TrimPrefix(target, "/"))
+    return strings.TrimSuffix(key, ".md")
+}
+
+// file returns the indexed file at the vault-relative path rel, or "" if
+// there is none. Canvases refer to files by their exact path.
+func (r *linkResolver) file(rel string) string {
+    return r.byPath[linkKey(rel)]
+}
+
+// resolve returns the note that link in the note from points to, or "" if
+// it points to no indexed note. Links within the note resolve to from.
+func (r *linkResolver) resolve(from string, link markdown.Link) string {
+    if link.Target == "" {
+        return from
+    }
+    key := linkKey(link.Target)
+    dir := path.Dir(linkKey(r.rel(from)))
+    
+    if strings.Contains(key, "/") {
+        if file, ok := r.byPath[path.Join(dir, key)]; ok {
+            return file
+        }
+        return r.byPath[path.Clean(key)]
+    }
+    
+    candidates := r.byName[key]
+    if len(candidates) == 0 {
+        candidates = r.byAlias[strings.ToLower(strings.TrimPrefix(link.Target, "/"))]
+    }
+    for _, file := range candidates {
+        if path.Dir(linkKey(r.rel(file))) == dir {
+            return file
+        }
+    }
+    if len(candidates) > 0 {
+        return candidates[0]
+    }
+    return ""
+}
+
+// Lookup returns the file of the note that name refers to, the way a link
+// [[name]] from the vault root would: a vault-relative path, a file name or
+// an alias. It returns "" if name refers to no indexed note.
+func (ix *Index) Lookup(name string) string {
+    return ix.linkResolver().resolve("", markdown.Link{Target: name})
+}
+
+// linkedNotes returns the notes that the note or canvas at file links to,
+// and the notes that an arrow on a canvas connects it with.
+func (ix *Index) linkedNotes(resolver *linkResolver, file string) map[string]bool {
+    linked := make(map[string]bool)
+    if isCanvas(file) {
+        if c, err := readCanvas(file); err == nil {
+            linked = canvasLinks(resolver, file, c)
+        }
+    } else if !isNote(file) {
+        // Attachments link nowhere
+    } else if _, body, _, err := readNote(file); err == nil {
+        linked = resolver.linkedNotes(file, body)
+    }
+    for _, other := range ix.refs.connected(file) {
+        linked[other] = true
+    }
+    return linked
+}
+
+// linkedNotes returns the notes that the links in body point to.
+func (r *linkResolver) linkedNotes(from, body string) map[string]bool {
+    linked := make(map[string]bool)
+    for _, link := range markdown.Links(body) {
+        if file := r.resolve(from, link); file != "" {
+            linked[file] = true
+        }
+    }
+    return linked
+}
\ No newline at end of file
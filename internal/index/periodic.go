@@ -0,0 +1,102 @@
+package index
+
+import (
+    "slices"
+    "sort"
+    "strings"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/periodic"
+)
+
+// PeriodicNote is a daily, weekly, monthly, quarterly or yearly note with
+// the first and last day of the period it is about.
+type PeriodicNote struct {
+    Vault    string    `json:"vault,omitempty"`
+    Path     string    `json:"path"`
+    FilePath string    `json:"file_path"`
+    Period   string    `json:"period"`
+    Start    time.Time `json:"start"`
+    End      time.Time `json:"end"`
+}
+
+// PeriodicQuery selects periodic notes. Periods lists the accepted periods,
+// all if empty, and Dates the days their period must overlap.
+type PeriodicQuery struct {
+    Periods []string
+    Dates   DateRange
+}
+
+// SetPeriodic sets the formats that periodic notes are named in.
+// IndexDirectory loads the settings of its root if none are set.
+func (ix *Index) SetPeriodic(formats []periodic.Format) {
+    ix.regMu.Lock()
+    defer ix.regMu.Unlock()
+    ix.periodic = formats
+}
+
+func (ix *Index) periodicFormats() []periodic.Format {
+    ix.regMu.Lock()
+    defer ix.regMu.Unlock()
+    return ix.periodic
+}
+
+// periodicDate returns the period of the note at path if it is a periodic
+// note. Notes are dated by their name, so a change of the settings needs
+// no reindex.
+func (ix *Index) periodicDate(formats []periodic.Format, path string) (periodic.Date, bool) {
+    if !strings.HasSuffix(path, ".md") {
+        return periodic.Date{}, false
+    }
+    return periodic.Match(formats, strings.TrimSuffix(ix.vaultPath(path), ".md"))
+}
+
+// PeriodicNotes returns the indexed periodic notes matching q, ordered by
+// the first day of their period and then from the shortest period.
+func (ix *Index) PeriodicNotes(q PeriodicQuery) []PeriodicNote {
+    formats := ix.periodicFormats()
+    var notes []PeriodicNote
+    for file := range ix.IndexedFiles() {
+        d, ok := ix.periodicDate(formats, file)
+        if !ok || len(q.Periods) > 0 && !slices.Contains(q.Periods, d.Period) || !q.Dates.Overlaps(d.Start, d.End) {
+            continue
+        }
+        notes = append(notes, PeriodicNote{
+            Path:     ix.vaultPath(file),
+            FilePath: file,
+            Period:   d.Period,
+            Start:    d.Start,
+            End:      d.End,
+        })
+    }
+    
+    SortPeriodic(notes)
+    return notes
+}
+
+// SortPeriodic orders notes by the first day of their period, then from
+// the shortest period, then by path.
+func SortPeriodic(notes []PeriodicNote) {
+    sort.SliceStable(notes, func(i, j int) bool {
+        a, b := notes[i], notes[j]
+        if !a.Start.Equal(b.Start) {
+            return a.Start.Before(b.Start)
+        }
+        if a.Period != b.Period {
+            return slices.Index(periodic.Periods, a.Period) < slices.Index(periodic.Periods, b.Period)
+        }
+        return a.Path < b.Path
+    })
+}
+
+// inDates keeps the hits of periodic notes whose period overlaps dates.
+func (ix *Index) inDates(hits []scoredResult, dates DateRange) []scoredResult {
+    formats := ix.periodicFormats()
+    kept := hits[:0]
+    for _, hit := range hits {
+        if d, ok := ix.periodicDate(formats, hit.path); ok && dates.Overlaps(d.Start, d.End) {
+            kept = append(kept, hit)
+        }
+    }
+    return kept
+}
\ No newline at end of file
@@ -0,0 +1,79 @@
+package index
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/periodic"
+)
+
+func TestPeriodicNotes(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(filepath.Join(vaultPath, ".obsidian"), 0755)
+    os.WriteFile(filepath.Join(vaultPath, ".obsidian", "daily-notes.json"), []byte(`{"folder": "Daily", "format": "YYYY/YYYY-MM-DD"}`), 0644)
+    notes := map[string]string{
+        "Daily/2025/2025-06-01.md": "Planned the release.",
+        "Daily/2025/2025-06-03.md": "Release went out, wrote the retro.",
+        "Daily/2025/2025-06-09.md": "Retro follow-ups.",
+        "Weekly/2025-W23.md":       "Release week retro.",
+        "2025-06-02.md":            "Not in the daily folder, no retro.",
+    }
+    for path, content := range notes {
+        os.MkdirAll(filepath.Join(vaultPath, filepath.Dir(path)), 0755)
+        os.WriteFile(filepath.Join(vaultPath, path), []byte(content), 0644)
+    }
+    
+    opts := DefaultOptions()
+    opts.Backend = BackendBM25
+    index, err := Open(filepath.Join(t.TempDir(), "index"), opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    if err := index.IndexDirectory(vaultPath, 2); err != nil {
+        t.Fatalf("Failed to index directory: %v", err)
+    }
+    
+    day := func(s string) time.Time {
+        d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+        return d
+    }
+    paths := func(q PeriodicQuery) []string {
+        var out []string
+        for _, note := range index.PeriodicNotes(q) {
+            out = append(out, note.Path)
+        }
+        return out
+    }
+    
+    // The settings in .obsidian are read when none are set
+    week := DateRange{From: day("2025-06-02"), To: day("2025-06-08")}
+    if got := paths(PeriodicQuery{Dates: week}); len(got) != 1 || got[0] != "Daily/2025/2025-06-03.md" {
+        t.Errorf("Expected the daily note of the week, got %v", got)
+    }
+    
+    weekly, _ := periodic.NewFormat(periodic.Weekly, "Weekly", "GGGG-[W]WW")
+    index.SetPeriodic(append(index.periodicFormats(), weekly))
+    got := index.PeriodicNotes(PeriodicQuery{Dates: week})
+    if len(got) != 2 || got[0].Path != "Weekly/2025-W23.md" || got[0].Period != periodic.Weekly || !got[0].End.Equal(day("2025-06-08")) || got[1].Path != "Daily/2025/2025-06-03.md" {
+        t.Errorf("Expected the weekly note first, got %+v", got)
+    }
+    if got := paths(PeriodicQuery{Periods: []string{periodic.Daily}}); len(got) != 3 {
+        t.Errorf("Expected all daily notes, got %v", got)
+    }
+    
+    // Searches within dates only find periodic notes
+    results, err := index.SearchWithOptions("retro", 10, SearchOptions{Dates: week})
+    if err != nil {
+        t.Fatalf("Search failed: %v", err)
+    }
+    if len(results) != 2 {
+        t.Errorf("Expected the retro notes of the week, got %+v", results)
+    }
+    for _, r := range results {
+        if base := filepath.Base(r.FilePath); base != "2025-W23.md" && base != "2025-06-03.md" {
+            t.Errorf("Expected only notes of the week, got %s", r.FilePath)
+        }
+    }
+}
\ No newline at end of file
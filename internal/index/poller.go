@@ -0,0 +1,242 @@
+package index
+
+import (
+    "fmt"
+    "log/slog"
+    "os"
+    "sync"
+    "time"
+)
+
+// DefaultPollInterval is used when no poll interval is configured.
+const DefaultPollInterval = 10 * time.Second
+
+// settleTime is how old an unreported change must be before the auto
+// watcher concludes that fsnotify missed it rather than is about to deliver it.
+const settleTime = 2 * time.Second
+
+// PollingWatcher detects changes by periodically comparing file modification
+// times against the index's file registry. It works on filesystems that don't
+// deliver fsnotify events, such as Docker Desktop bind mounts and SMB/NFS shares.
+type PollingWatcher struct {
+    index    *Index
+    rootPath string
+    interval time.Duration
+    stop     chan struct{}
+    stopOnce sync.Once
+}
+
+func NewPollingWatcher(index *Index, rootPath string, interval time.Duration) *PollingWatcher {
+    if interval <= 0 {
+        interval = DefaultPollInterval
+    }
+    
+    return &PollingWatcher{
+        index:    index,
+        rootPath: rootPath,
+        interval: interval,
+        stop:     make(chan struct{}),
+    }
+}
+
+func (pw *PollingWatcher) Start() {
+    ticker := time.NewTicker(pw.interval)
+    defer ticker.Stop()
+    
+    for {
+        select {
+        case <-pw.stop:
+            return
+        
+        case <-ticker.C:
+            updated, removed, err := pw.detectChanges()
+            if err != nil {
+                slog.Warn("Polling watcher error", "err", err)
+                continue
+            }
+            pw.apply(updated, removed)
+        }
+    }
+}
+
+func (pw *PollingWatcher) Stop() error {
+    pw.stopOnce.Do(func() {
+        close(pw.stop)
+    })
+    return nil
+}
+
+// detectChanges walks the vault and diffs it against the file registry. It
+// returns new or modified files with their modification times, and registered
+// files that no longer exist.
+func (pw *PollingWatcher) detectChanges() (map[string]time.Time, []string, error) {
+    // An unreachable vault (e.g. an unmounted share) must not look like every
+    // file was deleted
+    if _, err := os.Stat(pw.rootPath); err != nil {
+        return nil, nil, fmt.Errorf("vault not accessible: %w", err)
+    }
+    
+    known := pw.index.IndexedFiles()
+    updated := make(map[string]time.Time)
+    
+    err := pw.index.walkVault(pw.rootPath, func(path string, info os.FileInfo) {
+        if modTime, exists := known[path]; !exists || !info.ModTime().Equal(modTime) {
+            updated[path] = info.ModTime()
+        }
+        delete(known, path)
+    })
+    if err != nil {
+        return nil, nil, err
+    }
+    
+    removed := make([]string, 0, len(known))
+    for path := range known {
+        removed = append(removed, path)
+    }
+    
+    return updated, removed, nil
+}
+
+func (pw *PollingWatcher) apply(updated map[string]time.Time, removed []string) {
+    for path := range updated {
+        if err := pw.index.UpdateFile(path); err != nil {
+            slog.Warn("Failed to update file", "path", path, "err", err)
+            continue
+        }
+        slog.Debug("Updated file", "path", path)
+    }
+    
+    for _, path := range removed {
+        if err := pw.index.RemoveFile(path); err != nil {
+            slog.Warn("Failed to remove file", "path", path, "err", err)
+            continue
+        }
+        slog.Debug("Removed file", "path", path)
+    }
+}
+
+// AutoWatcher starts with fsnotify and verifies it against a periodic
+// registry scan. As soon as the scan finds a change fsnotify never reported,
+// it switches to polling for the rest of its lifetime.
+type AutoWatcher struct {
+    poller   *PollingWatcher
+    mu       sync.Mutex
+    notify   *FileWatcher
+    stop     chan struct{}
+    stopOnce sync.Once
+    // reported holds the time of the last fsnotify event of every path
+    // that was still out of date at the last scan
+    reported map[string]time.Time
+}
+
+func NewAutoWatcher(index *Index, rootPath string, interval time.Duration) *AutoWatcher {
+    return &AutoWatcher{
+        poller:   NewPollingWatcher(index, rootPath, interval),
+        stop:     make(chan struct{}),
+        reported: make(map[string]time.Time),
+    }
+}
+
+func (aw *AutoWatcher) Start() {
+    fw, err := NewFileWatcher(aw.poller.index, aw.poller.rootPath)
+    if err != nil {
+        if fw != nil {
+            fw.Stop()
+        }
+        slog.Warn("fsnotify unavailable, using polling watcher", "err", err)
+        aw.poller.Start()
+        return
+    }
+    
+    aw.mu.Lock()
+    aw.notify = fw
+    aw.mu.Unlock()
+    go fw.Start()
+    
+    ticker := time.NewTicker(aw.poller.interval)
+    defer ticker.Stop()
+    
+    for {
+        select {
+        case <-aw.stop:
+            return
+        
+        case <-ticker.C:
+            if !aw.missedChanges(fw) {
+                continue
+            }
+            
+            slog.Warn("fsnotify events are not being delivered, switching to polling watcher", "path", aw.poller.rootPath)
+            aw.mu.Lock()
+            aw.notify = nil
+            aw.mu.Unlock()
+            fw.Stop()
+            
+            aw.poller.Start()
+            return
+        }
+    }
+}
+
+// missedChanges scans for changes and reports whether any of them should
+// have produced an fsnotify event by now but didn't. A file that stays out
+// of date because indexing it failed had its event, so it only counts once
+// it is modified after that. Missed changes are applied so that nothing is
+// lost during the switch.
+func (aw *AutoWatcher) missedChanges(fw *FileWatcher) bool {
+    updated, removed, err := aw.poller.detectChanges()
+    if err != nil {
+        slog.Warn("Polling watcher error", "err", err)
+        return false
+    }
+    
+    reported := make(map[string]time.Time)
+    for path, at := range fw.takeObserved() {
+        reported[path] = at
+    }
+    for path, at := range aw.reported {
+        if _, ok := reported[path]; !ok {
+            reported[path] = at
+        }
+    }
+    cutoff := time.Now().Add(-settleTime)
+    missed := false
+    aw.reported = make(map[string]time.Time)
+    
+    for path, modTime := range updated {
+        at, seen := reported[path]
+        if seen {
+            aw.reported[path] = at
+        }
+        if (!seen || modTime.After(at)) && modTime.Before(cutoff) {
+            missed = true
+        }
+    }
+    for _, path := range removed {
+        at, seen := reported[path]
+        if seen {
+            aw.reported[path] = at
+        } else {
+            missed = true
+        }
+    }
+    
+    if missed {
+        aw.poller.apply(updated, removed)
+    }
+    return missed
+}
+
+func (aw *AutoWatcher) Stop() error {
+    aw.stopOnce.Do(func() {
+        close(aw.stop)
+    })
+    aw.poller.Stop()
+    
+    aw.mu.Lock()
+    defer aw.mu.Unlock()
+    if aw.notify != nil {
+        return aw.notify.Stop()
+    }
+    return nil
+}
\ No newline at end of file
@@ -0,0 +1,101 @@
+package index
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestPollingWatcherDetectChanges(t *testing.T) {
+    vaultPath := t.TempDir()
+    unchanged := filepath.Join(vaultPath, "unchanged.md")
+    modified := filepath.Join(vaultPath, "modified.md")
+    added := filepath.Join(vaultPath, "added.md")
+    deleted := filepath.Join(vaultPath, "deleted.md")
+    
+    for _, path := range []string{unchanged, modified, added} {
+        os.WriteFile(path, []byte("# Note\n"), 0644)
+    }
+    os.WriteFile(filepath.Join(vaultPath, "image.png"), []byte{0}, 0644)
+    
+    info, _ := os.Stat(unchanged)
+    
+    // The registry is all the poller needs, so no tantivy context is required
+    index := &Index{
+        lastIndexed: map[string]time.Time{
+            unchanged: info.ModTime(),
+            modified:  info.ModTime().Add(-time.Hour),
+            deleted:   info.ModTime(),
+        },
+    }
+    
+    pw := NewPollingWatcher(index, vaultPath, time.Second)
+    updated, removed, err := pw.detectChanges()
+    if err != nil {
+        t.Fatalf("detectChanges failed: %v", err)
+    }
+    
+    if len(updated) != 2 {
+        t.Errorf("Expected 2 updated files, got %d: %v", len(updated), updated)
+    }
+    for _, path := range []string{modified, added} {
+        if _, ok := updated[path]; !ok {
+            t.Errorf("Expected %s to be reported as updated", path)
+        }
+    }
+    
+    if len(removed) != 1 || removed[0] != deleted {
+        t.Errorf("Expected only %s to be removed, got %v", deleted, removed)
+    }
+}
+
+func TestPollingWatcherMissingVault(t *testing.T) {
+    index := &Index{
+        lastIndexed: map[string]time.Time{"/gone/note.md": time.Now()},
+    }
+    
+    pw := NewPollingWatcher(index, filepath.Join(t.TempDir(), "gone"), time.Second)
+    if _, _, err := pw.detectChanges(); err == nil {
+        t.Error("Expected an error for an inaccessible vault instead of mass removal")
+    }
+}
+
+func TestAutoWatcherFailedUpdate(t *testing.T) {
+    vaultPath := t.TempDir()
+    broken := filepath.Join(vaultPath, "broken.md")
+    os.WriteFile(broken, []byte("# Broken\n"), 0644)
+    
+    opts := DefaultOptions()
+    opts.Backend = BackendBM25
+    index, err := Open(filepath.Join(t.TempDir(), "index"), opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    defer index.Close()
+    if err := index.IndexDirectory(vaultPath, 1); err != nil {
+        t.Fatalf("Failed to index directory: %v", err)
+    }
+    
+    // fsnotify reported the change, but indexing it failed, so the file
+    // stays out of date in the registry
+    now := time.Now()
+    os.Chtimes(broken, now.Add(-2*time.Minute), now.Add(-2*time.Minute))
+    index.regMu.Lock()
+    index.lastIndexed[broken] = now.Add(-time.Hour)
+    index.regMu.Unlock()
+    
+    aw := NewAutoWatcher(index, vaultPath, time.Second)
+    fw := &FileWatcher{observed: map[string]time.Time{broken: now.Add(-time.Minute)}}
+    for i := 0; i < 2; i++ {
+        if aw.missedChanges(fw) {
+            t.Fatalf("Scan %d: expected the reported change not to count as missed", i+1)
+        }
+    }
+    
+    // A later change without an event is missed
+    os.Chtimes(broken, now.Add(-10*time.Second), now.Add(-10*time.Second))
+    if !aw.missedChanges(fw) {
+        t.Error("Expected the unreported change to be missed")
+    }
+}
\ No newline at end of file
@@ -0,0 +1,439 @@
+package index
+
+import (
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "unicode"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+)
+
+// propertyList holds the frontmatter and inline field properties of every
+// indexed note. It is stored next to the index as .properties.
+type propertyList struct {
+    mu    sync.RWMutex
+    files map[string]map[string]markdown.Value
+}
+
+func newPropertyList() *propertyList {
+    return &propertyList{files: make(map[string]map[string]markdown.Value)}
+}
+
+// put replaces the properties recorded for path.
+func (l *propertyList) put(path string, props map[string]markdown.Value) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if len(props) == 0 {
+        delete(l.files, path)
+        return
+    }
+    l.files[path] = props
+}
+
+func (l *propertyList) remove(path string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    delete(l.files, path)
+}
+
+// aliases returns the aliases of every note that has some, from the aliases
+// property or its older singular form, alias.
+func (l *propertyList) aliases() map[string][]string {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    aliases := make(map[string][]string)
+    for file, props := range l.files {
+        for _, key := range []string{"aliases", "alias"} {
+            value, ok := props[key]
+            if !ok {
+                continue
+            }
+            items := []markdown.Value{value}
+            if value.Type == markdown.PropertyList {
+                items = value.List
+            }
+            for _, item := range items {
+                if name := strings.TrimSpace(item.String()); name != "" {
+                    aliases[file] = append(aliases[file], name)
+                }
+            }
+        }
+    }
+    return aliases
+}
+
+func (l *propertyList) load(path string) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return
+    }
+    
+    var files map[string]map[string]markdown.Value
+    if err := json.Unmarshal(data, &files); err != nil {
+        return
+    }
+    
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.files = files
+}
+
+func (l *propertyList) save(path string) error {
+    l.mu.RLock()
+    data, err := json.Marshal(l.files)
+    l.mu.RUnlock()
+    if err != nil {
+        return err
+    }
+    
+    // Write and rename so a crash never leaves a truncated list
+    tmp := path + ".tmp"
+    if err := os.WriteFile(tmp, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, path)
+}
+
+// Property filter operators. Exists and Missing take no value.
+const (
+    OpEqual        = "="
+    OpNotEqual     = "!="
+    OpLess         = "<"
+    OpLessEqual    = "<="
+    OpGreater      = ">"
+    OpGreaterEqual = ">="
+    OpContains     = "contains"
+    OpExists       = "exists"
+    OpMissing      = "missing"
+)
+
+// PropertyFilter is one condition on a property. Key is normalized with
+// markdown.PropertyKey.
+type PropertyFilter struct {
+    Key   string
+    Op    string
+    Value string
+}
+
+// PropertyQuery selects notes by their properties. A note has to pass all
+// Filters and be below Folder if it is set. Notes are sorted by the Sort
+// property, notes without it last, or else by path.
+type PropertyQuery struct {
+    Filters []PropertyFilter
+    Folder  string
+    Sort    string
+    Desc    bool
+}
+
+// PropertyMatch is a note with all its properties.
+type PropertyMatch struct {
+    Vault      string                    `json:"vault,omitempty"`
+    Path       string                    `json:"path"`
+    FilePath   string                    `json:"file_path"`
+    Properties map[string]markdown.Value `json:"properties"`
+}
+
+// ParsePropertyFilters parses conditions such as
+//
+//	type = book and rating >= 4 and finished exists
+//
+// Each condition is a property, an operator (=, !=, <, <=, >, >=,
+// contains) and a value, or a property followed by exists or missing.
+// Values that contain "and" or start with an operator are quoted.
+func ParsePropertyFilters(where string) ([]PropertyFilter, error) {
+    tokens, err := filterTokens(where)
+    if err != nil {
+        return nil, err
+    }
+    
+    var filters []PropertyFilter
+    for len(tokens) > 0 {
+        if len(tokens) < 2 {
+            return nil, fmt.Errorf("condition %q has no operator", tokens[0].text)
+        }
+        key := markdown.PropertyKey(tokens[0].text)
+        if tokens[0].op || key == "" {
+            return nil, fmt.Errorf("expected a property name, got %q", tokens[0].text)
+        }
+        
+        filter := PropertyFilter{Key: key, Op: strings.ToLower(tokens[1].text)}
+        tokens = tokens[2:]
+        switch {
+        case filter.Op == OpExists || filter.Op == OpMissing:
+        case valueOp(filter.Op):
+            var words []string
+            for len(tokens) > 0 && !tokens[0].isAnd() {
+                if tokens[0].op {
+                    return nil, fmt.Errorf("unexpected %q in the value of %s, quote values that contain operators", tokens[0].text, key)
+                }
+                words = append(words, tokens[0].text)
+                tokens = tokens[1:]
+            }
+            if len(words) == 0 {
+                return nil, fmt.Errorf("%s %s needs a value", key, filter.Op)
+            }
+            filter.Value = strings.Join(words, " ")
+        default:
+            return nil, fmt.Errorf("unknown operator %q after %s (valid: =, !=, <, <=, >, >=, contains, exists, missing)", filter.Op, key)
+        }
+        filters = append(filters, filter)
+        
+        if len(tokens) > 0 {
+            if !tokens[0].isAnd() {
+                return nil, fmt.Errorf("expected and before %q", tokens[0].text)
+            }
+            tokens = tokens[1:]
+            if len(tokens) == 0 {
+                return nil, fmt.Errorf("expected a condition after and")
+            }
+        }
+    }
+    return filters, nil
+}
+
+// valueOp reports whether op is an operator taking a value.
+func valueOp(op string) bool {
+    switch op {
+    case OpEqual, OpNotEqual, OpLess, OpLessEqual, OpGreater, OpGreaterEqual, OpContains:
+        return true
+    }
+    return false
+}
+
+type filterToken struct {
+    text   string
+    op     bool
+    quoted bool
+}
+
+func (t filterToken) isAnd() bool {
+    return !t.quoted && strings.EqualFold(t.text, "and")
+}
+
+// filterTokens splits a filter expression into words, quoted strings and
+// comparison operators, which need no spaces around them. A quote only
+// starts a string at the start of a word, so "O'Brien" stays a word.
+func filterTokens(s string) ([]filterToken, error) {
+    var tokens []filterToken
+    runes := []rune(s)
+    for i := 0; i < len(runes); {
+        r := runes[i]
+        switch {
+        case unicode.IsSpace(r):
+            i++
+        case r == '"' || r == '\'':
+            end := i + 1
+            for end < len(runes) && runes[end] != r {
+                end++
+            }
+            if end == len(runes) {
+                return nil, fmt.Errorf("unterminated quote in %q", s)
+            }
+            tokens = append(tokens, filterToken{text: string(runes[i+1 : end]), quoted: true})
+            i = end + 1
+        case strings.ContainsRune("=!<>", r):
+            end := i + 1
+            if end < len(runes) && runes[end] == '=' {
+                end++
+            }
+            op := string(runes[i:end])
+            if op == "==" {
+                op = OpEqual
+            }
+            if op == "!" {
+                return nil, fmt.Errorf("unexpected ! in %q", s)
+            }
+            tokens = append(tokens, filterToken{text: op, op: true})
+            i = end
+        default:
+            end := i
+            for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("=!<>", runes[end]) {
+                end++
+            }
+            tokens = append(tokens, filterToken{text: string(runes[i:end])})
+            i = end
+        }
+    }
+    return tokens, nil
+}
+
+// Properties returns the notes whose properties match q.
+func (ix *Index) Properties(q PropertyQuery) []PropertyMatch {
+    folder := strings.Trim(filepath.ToSlash(q.Folder), "/")
+    
+    ix.properties.mu.RLock()
+    var matches []PropertyMatch
+    for file, props := range ix.properties.files {
+        path := ix.vaultPath(file)
+        if folder != "" && !strings.HasPrefix(path, folder+"/") {
+            continue
+        }
+        if q.matches(props) {
+            matches = append(matches, PropertyMatch{Path: path, FilePath: file, Properties: props})
+        }
+    }
+    ix.properties.mu.RUnlock()
+    
+    SortProperties(matches, q.Sort, q.Desc)
+    return matches
+}
+
+func (q PropertyQuery) matches(props map[string]markdown.Value) bool {
+    for _, f := range q.Filters {
+        if !f.matches(props) {
+            return false
+        }
+    }
+    return true
+}
+
+// matches applies the filter. Only Missing matches a note without the
+// property. A list matches if one of its items does, or for != if none
+// is equal.
+func (f PropertyFilter) matches(props map[string]markdown.Value) bool {
+    value, ok := props[f.Key]
+    switch f.Op {
+    case OpExists:
+        return ok
+    case OpMissing:
+        return !ok
+    }
+    if !ok {
+        return false
+    }
+    
+    want := markdown.ParseValue(f.Value)
+    items := []markdown.Value{value}
+    if value.Type == markdown.PropertyList {
+        items = value.List
+    }
+    if f.Op == OpNotEqual {
+        for _, item := range items {
+            if c, ok := compareValues(item, want); ok && c == 0 {
+                return false
+            }
+        }
+        return true
+    }
+    for _, item := range items {
+        if f.Op == OpContains {
+            if value.Type == markdown.PropertyList {
+                if c, ok := compareValues(item, want); ok && c == 0 {
+                    return true
+                }
+            } else if strings.Contains(strings.ToLower(item.String()), strings.ToLower(f.Value)) {
+                return true
+            }
+            continue
+        }
+        
+        c, ok := compareValues(item, want)
+        if !ok {
+            continue
+        }
+        switch f.Op {
+        case OpEqual:
+            ok = c == 0
+        case OpLess:
+            ok = c < 0
+        case OpLessEqual:
+            ok = c <= 0
+        case OpGreater:
+            ok = c > 0
+        case OpGreaterEqual:
+            ok = c >= 0
+        }
+        if ok {
+            return true
+        }
+    }
+    return false
+}
+
+// compareValues orders two values of the same type. A string compares
+// with anything by text, case-insensitively and with links reduced to
+// their target, so "author = Tolkien" matches [[Tolkien]]. Other mixed
+// types don't compare.
+func compareValues(a, b markdown.Value) (int, bool) {
+    if a.Type == markdown.PropertyList && len(a.List) > 0 {
+        a = a.List[0]
+    }
+    if b.Type == markdown.PropertyList && len(b.List) > 0 {
+        b = b.List[0]
+    }
+    if a.Type == b.Type {
+        switch a.Type {
+        case markdown.PropertyNumber:
+            switch {
+            case a.Number < b.Number:
+                return -1, true
+            case a.Number > b.Number:
+                return 1, true
+            }
+            return 0, true
+        case markdown.PropertyDate:
+            return a.Date.Compare(b.Date), true
+        case markdown.PropertyBool:
+            switch {
+            case a.Bool == b.Bool:
+                return 0, true
+            case b.Bool:
+                return -1, true
+            }
+            return 1, true
+        }
+    }
+    if a.Type != markdown.PropertyString && b.Type != markdown.PropertyString {
+        return 0, false
+    }
+    return strings.Compare(linkText(a.String()), linkText(b.String())), true
+}
+
+// linkText lowers s and reduces a wiki link to its target.
+func linkText(s string) string {
+    if strings.HasPrefix(s, "[[") && strings.HasSuffix(s, "]]") {
+        s = strings.TrimSuffix(strings.TrimPrefix(s, "[["), "]]")
+        if i := strings.Index(s, "|"); i >= 0 {
+            s = s[:i]
+        }
+    }
+    return strings.ToLower(strings.TrimSpace(s))
+}
+
+// SortProperties orders matches by the property key, notes without it
+// last, then by path. Without a key they are ordered by path alone.
+func SortProperties(matches []PropertyMatch, key string, desc bool) {
+    key = markdown.PropertyKey(key)
+    sort.SliceStable(matches, func(i, j int) bool {
+        a, b := matches[i], matches[j]
+        if key != "" {
+            va, okA := a.Properties[key]
+            vb, okB := b.Properties[key]
+            if okA != okB {
+                return okA
+            }
+            if okA {
+                c, ok := compareValues(va, vb)
+                if !ok {
+                    c = strings.Compare(strings.ToLower(va.String()), strings.ToLower(vb.String()))
+                }
+                if c != 0 {
+                    return c < 0 != desc
+                }
+            }
+        }
+        return a.Path < b.Path
+    })
+}
+
+func (ix *Index) saveProperties() {
+    if err := ix.properties.save(filepath.Join(ix.indexPath, ".properties")); err != nil {
+        slog.Error("Failed to save properties", "err", err)
+    }
+}
\ No newline at end of file
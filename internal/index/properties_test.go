@@ -0,0 +1,130 @@
+package index
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestParsePropertyFilters(t *testing.T) {
+    tests := []struct {
+        where string
+        want  []PropertyFilter
+    }{
+        {"type = book and rating >= 4", []PropertyFilter{
+            {Key: "type", Op: OpEqual, Value: "book"},
+            {Key: "rating", Op: OpGreaterEqual, Value: "4"},
+        }},
+        {"rating>=4 AND finished exists", []PropertyFilter{
+            {Key: "rating", Op: OpGreaterEqual, Value: "4"},
+            {Key: "finished", Op: OpExists},
+        }},
+        {`title = "War and Peace" and Author == O'Brien`, []PropertyFilter{
+            {Key: "title", Op: OpEqual, Value: "War and Peace"},
+            {Key: "author", Op: OpEqual, Value: "O'Brien"},
+        }},
+        {"genres contains fantasy and summary missing", []PropertyFilter{
+            {Key: "genres", Op: OpContains, Value: "fantasy"},
+            {Key: "summary", Op: OpMissing},
+        }},
+        {"status != done deal", []PropertyFilter{
+            {Key: "status", Op: OpNotEqual, Value: "done deal"},
+        }},
+        {"", nil},
+    }
+    for _, tt := range tests {
+        got, err := ParsePropertyFilters(tt.where)
+        if err != nil {
+            t.Errorf("ParsePropertyFilters(%q) failed: %v", tt.where, err)
+            continue
+        }
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("ParsePropertyFilters(%q) = %+v, want %+v", tt.where, got, tt.want)
+        }
+    }
+    
+    for _, where := range []string{"rating", "rating 4", "rating >=", "type = book and", "type = book and and x = 1", "rating ~ 4", `title = "open`, "= 4", "a = b < c"} {
+        if _, err := ParsePropertyFilters(where); err == nil {
+            t.Errorf("Expected ParsePropertyFilters(%q) to fail", where)
+        }
+    }
+}
+
+func TestProperties(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(filepath.Join(vaultPath, "Books"), 0755)
+    os.WriteFile(filepath.Join(vaultPath, "Books", "hobbit.md"), []byte("---\ntype: book\nrating: 5\nfinished: 2025-03-01\ngenres: [fantasy, classic]\n---\nauthor:: [[Tolkien]]\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Books", "dune.md"), []byte("---\ntype: book\nrating: 4\nfinished: 2025-01-15\ngenres: [scifi]\n---\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Books", "meh.md"), []byte("---\ntype: book\n---\nRead it [rating:: 2].\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Books", "next.md"), []byte("---\ntype: book\nrating: 4\n---\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Home.md"), []byte("status:: active\n"), 0644)
+    
+    opts := DefaultOptions()
+    opts.Backend = BackendBM25
+    indexPath := filepath.Join(t.TempDir(), "index")
+    index, err := Open(indexPath, opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    if err := index.IndexDirectory(vaultPath, 2); err != nil {
+        t.Fatalf("Failed to index directory: %v", err)
+    }
+    
+    paths := func(where, sort string, desc bool) []string {
+        filters, err := ParsePropertyFilters(where)
+        if err != nil {
+            t.Fatalf("Failed to parse %q: %v", where, err)
+        }
+        var out []string
+        for _, m := range index.Properties(PropertyQuery{Filters: filters, Sort: sort, Desc: desc}) {
+            out = append(out, m.Path)
+        }
+        return out
+    }
+    
+    tests := []struct {
+        where string
+        sort  string
+        desc  bool
+        want  []string
+    }{
+        {"type = book and rating >= 4", "finished", true, []string{"Books/hobbit.md", "Books/dune.md", "Books/next.md"}},
+        {"type = Book and rating < 4", "", false, []string{"Books/meh.md"}},
+        {"finished > 2025-02-01", "", false, []string{"Books/hobbit.md"}},
+        {"genres contains fantasy", "", false, []string{"Books/hobbit.md"}},
+        {"genres = scifi", "", false, []string{"Books/dune.md"}},
+        {"genres != scifi", "", false, []string{"Books/hobbit.md"}},
+        {"author = Tolkien", "", false, []string{"Books/hobbit.md"}},
+        {"type = book and finished missing", "", false, []string{"Books/meh.md", "Books/next.md"}},
+        {"status exists", "", false, []string{"Home.md"}},
+        {"rating = high", "", false, nil},
+        {"type = book", "rating", false, []string{"Books/meh.md", "Books/dune.md", "Books/next.md", "Books/hobbit.md"}},
+    }
+    for _, tt := range tests {
+        if got := paths(tt.where, tt.sort, tt.desc); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%q sorted by %q: got %q, want %q", tt.where, tt.sort, got, tt.want)
+        }
+    }
+    
+    matches := index.Properties(PropertyQuery{Folder: "Books", Filters: []PropertyFilter{{Key: "author", Op: OpExists}}})
+    if len(matches) != 1 || matches[0].Properties["rating"].Number != 5 || matches[0].Properties["author"].Text != "[[Tolkien]]" {
+        t.Errorf("Expected the properties of the hobbit, got %+v", matches)
+    }
+    
+    // Properties survive a restart and leave with their note
+    index.Close()
+    index, err = Open(indexPath, opts)
+    if err != nil {
+        t.Fatalf("Failed to reopen index: %v", err)
+    }
+    defer index.Close()
+    if got := paths("rating >= 4", "", false); len(got) != 3 {
+        t.Errorf("Expected 3 notes after reopening, got %q", got)
+    }
+    os.Remove(filepath.Join(vaultPath, "Books", "next.md"))
+    index.IndexDirectory(vaultPath, 1)
+    if got := paths("rating >= 4", "", false); len(got) != 2 {
+        t.Errorf("Expected the deleted note to be gone, got %q", got)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,100 @@
+package index
+
+import (
+    "math"
+    "sort"
+    "time"
+)
+
+// Ranking
+//
+// Tantivy scores every matching field with BM25 and multiplies the field's
+// score by its boost; the text score of a note is the sum over its fields.
+// Recency then scales the text score by how recently the note was changed
+// or created:
+//
+//    score = text score × (1 + weight × 0.5^(age / half life))
+//
+// A note from today gets up to 1+weight times its text score, one that is a
+// half life old 1+weight/2 times, and old notes keep their text score. With
+// a weight of 0 the order is the text order. Recency reorders all matches,
+// or the MaxHits best of the text search if there are more.
+
+// RecencyOptions configure the recency boost. Date is "modified" for the
+// file modification time or "created" for the created or date property of
+// the frontmatter, falling back to the modification time.
+type RecencyOptions struct {
+    Weight   float64
+    HalfLife time.Duration
+    Date     string
+}
+
+// Recency dates.
+const (
+    DateModified = "modified"
+    DateCreated  = "created"
+)
+
+func DefaultRecency() RecencyOptions {
+    return RecencyOptions{
+        HalfLife: 30 * 24 * time.Hour,
+        Date:     DateModified,
+    }
+}
+
+// enabled reports whether r changes the ranking.
+func (r RecencyOptions) enabled() bool {
+    return r.Weight > 0 && r.HalfLife > 0
+}
+
+// factor returns the multiplier for a note dated date.
+func (r RecencyOptions) factor(date, now time.Time) float64 {
+    if !r.enabled() || date.IsZero() {
+        return 1
+    }
+    age := now.Sub(date)
+    if age < 0 {
+        age = 0
+    }
+    return 1 + r.Weight*math.Pow(0.5, float64(age)/float64(r.HalfLife))
+}
+
+// scoredResult is a search hit before snippets are made.
+type scoredResult struct {
+    path     string
+    lang     string
+    score    float64
+    modified time.Time
+    created  time.Time
+}
+
+// date returns the date that recency is computed from.
+func (s scoredResult) date(recency RecencyOptions) time.Time {
+    if recency.Date == DateCreated && !s.created.IsZero() {
+        return s.created
+    }
+    return s.modified
+}
+
+// rankResults applies recency to the text scores and sorts the results,
+// best first. Equal scores keep the text order.
+func rankResults(results []scoredResult, recency RecencyOptions, now time.Time) {
+    for i := range results {
+        results[i].score *= recency.factor(results[i].date(recency), now)
+    }
+    sort.SliceStable(results, func(i, j int) bool {
+        return results[i].score > results[j].score
+    })
+}
+
+// fieldBoosts merges per-search boosts over the configured ones.
+func fieldBoosts(configured, override map[string]float32) map[string]float32 {
+    boosts := make(map[string]float32, len(configured)+len(override))
+    for field, boost := range configured {
+        boosts[field] = boost
+    }
+    for field, boost := range override {
+        boosts[field] = boost
+    }
+    return boosts
+}
\ No newline at end of file
@@ -0,0 +1,158 @@
+package index
+
+import (
+    "math"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestRecencyFactor(t *testing.T) {
+    now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+    recency := RecencyOptions{Weight: 1, HalfLife: 30 * 24 * time.Hour, Date: DateModified}
+    
+    tests := []struct {
+        name string
+        date time.Time
+        want float64
+    }{
+        {"today", now, 2},
+        {"one half life", now.Add(-30 * 24 * time.Hour), 1.5},
+        {"two half lives", now.Add(-60 * 24 * time.Hour), 1.25},
+        {"future", now.Add(time.Hour), 2},
+        {"no date", time.Time{}, 1},
+    }
+    for _, tt := range tests {
+        if got := recency.factor(tt.date, now); math.Abs(got-tt.want) > 1e-9 {
+            t.Errorf("%s: factor = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+    
+    recency.Weight = 0
+    if got := recency.factor(now, now); got != 1 {
+        t.Errorf("Expected weight 0 to leave scores alone, got %v", got)
+    }
+}
+
+func TestRankResults(t *testing.T) {
+    now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+    old := now.AddDate(-3, 0, 0)
+    recent := now.AddDate(0, 0, -7)
+    recency := RecencyOptions{Weight: 1, HalfLife: 30 * 24 * time.Hour, Date: DateModified}
+    
+    results := []scoredResult{
+        {path: "old.md", score: 2, modified: old},
+        {path: "recent.md", score: 2, modified: recent},
+    }
+    rankResults(results, recency, now)
+    if results[0].path != "recent.md" {
+        t.Errorf("Expected the recent note first on equal text scores, got %+v", results)
+    }
+    
+    results = []scoredResult{
+        {path: "old.md", score: 2.4, modified: old},
+        {path: "recent.md", score: 2, modified: recent},
+    }
+    rankResults(results, recency, now)
+    if results[0].path != "recent.md" {
+        t.Errorf("Expected the recent note to overtake a slightly better match, got %+v", results)
+    }
+    
+    results = []scoredResult{
+        {path: "old.md", score: 10, modified: old},
+        {path: "recent.md", score: 2, modified: recent},
+    }
+    rankResults(results, recency, now)
+    if results[0].path != "old.md" {
+        t.Errorf("Expected a much better match to stay first, got %+v", results)
+    }
+    
+    recency.Date = DateCreated
+    results = []scoredResult{
+        {path: "created-recently.md", score: 2, modified: old, created: recent},
+        {path: "modified-recently.md", score: 2, modified: recent, created: old},
+    }
+    rankResults(results, recency, now)
+    if results[0].path != "created-recently.md" {
+        t.Errorf("Expected the created date to be used, got %+v", results)
+    }
+    
+    results = []scoredResult{
+        {path: "a.md", score: 1, modified: old},
+        {path: "b.md", score: 1, modified: recent},
+    }
+    rankResults(results, RecencyOptions{}, now)
+    if results[0].path != "a.md" {
+        t.Errorf("Expected the text order without recency, got %+v", results)
+    }
+}
+
+func TestFieldBoosts(t *testing.T) {
+    configured := map[string]float32{"title": 2, "content": 1}
+    boosts := fieldBoosts(configured, map[string]float32{"title": 0, "tags": 3})
+    
+    if boosts["title"] != 0 || boosts["content"] != 1 || boosts["tags"] != 3 {
+        t.Errorf("Unexpected boosts %v", boosts)
+    }
+    if configured["title"] != 2 {
+        t.Errorf("Expected the configured boosts to be left alone, got %v", configured)
+    }
+}
+
+// TestRankingOrder indexes testdata/ranking, where "budget" appears in the
+// title, an alias, a heading, a tag or only the text of a note, and two
+// meeting notes differ only in their modification time.
+func TestRankingOrder(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    if err := os.CopyFS(vaultPath, os.DirFS(filepath.Join("testdata", "ranking"))); err != nil {
+        t.Fatalf("Failed to copy fixture vault: %v", err)
+    }
+    now := time.Now()
+    old := now.AddDate(-3, 0, 0)
+    recent := now.AddDate(0, 0, -7)
+    os.Chtimes(filepath.Join(vaultPath, "Meetings", "2022-03-07.md"), old, old)
+    os.Chtimes(filepath.Join(vaultPath, "Meetings", "2025-06-02.md"), recent, recent)
+    
+    forEachBackend(t, func(t *testing.T, index *Index) {
+        if err := index.IndexDirectory(vaultPath, 1); err != nil {
+            t.Fatalf("Failed to index directory: %v", err)
+        }
+        
+        search := func(q string, opts SearchOptions) map[string]int {
+            results, err := index.SearchWithOptions(q, 10, opts)
+            if err != nil {
+                t.Fatalf("Search %q failed: %v", q, err)
+            }
+            ranks := make(map[string]int, len(results))
+            for i, r := range results {
+                rel, _ := filepath.Rel(vaultPath, r.FilePath)
+                ranks[filepath.ToSlash(rel)] = i
+            }
+            return ranks
+        }
+        before := func(ranks map[string]int, a, b string) {
+            t.Helper()
+            ra, okA := ranks[a]
+            rb, okB := ranks[b]
+            if !okA || !okB || ra >= rb {
+                t.Errorf("Expected %s before %s, got %v", a, b, ranks)
+            }
+        }
+        
+        ranks := search("budget", SearchOptions{})
+        for _, better := range []string{"Budget.md", "Finance/plan.md"} {
+            for _, worse := range []string{"Finance/overview.md", "Finance/spreadsheet.md"} {
+                before(ranks, better, worse)
+            }
+        }
+        before(ranks, "Finance/overview.md", "groceries.md")
+        before(ranks, "Finance/spreadsheet.md", "groceries.md")
+        
+        ranks = search("budget", SearchOptions{Boosts: map[string]float32{"tags": 20}})
+        before(ranks, "Finance/spreadsheet.md", "Budget.md")
+        
+        ranks = search("weekly sync", SearchOptions{Recency: &RecencyOptions{Weight: 1, HalfLife: 30 * 24 * time.Hour, Date: DateModified}})
+        before(ranks, "Meetings/2025-06-02.md", "Meetings/2022-03-07.md")
+    })
+}
\ No newline at end of file
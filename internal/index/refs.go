@@ -0,0 +1,345 @@
+package index
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+    "sync"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+)
+
+// noteRefs are what links into and out of a note need: its blocks, the
+// notes it embeds and the names of embedded notes that did not exist when
+// it was indexed, so it can be updated when one of them changes or
+// appears. For a canvas, Edges are the pairs of notes its arrows connect.
+type noteRefs struct {
+    Blocks  []markdown.Block `json:"blocks,omitempty"`
+    Embeds  []string         `json:"embeds,omitempty"`
+    Missing []string         `json:"missing,omitempty"`
+    Edges   [][2]string      `json:"edges,omitempty"`
+}
+
+// refList holds the references of every indexed note. It is stored next to
+// the index as .refs.
+type refList struct {
+    mu    sync.RWMutex
+    files map[string]noteRefs
+}
+
+func newRefList() *refList {
+    return &refList{files: make(map[string]noteRefs)}
+}
+
+// put replaces the references recorded for path.
+func (l *refList) put(path string, refs noteRefs) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if len(refs.Blocks) == 0 && len(refs.Embeds) == 0 && len(refs.Missing) == 0 && len(refs.Edges) == 0 {
+        delete(l.files, path)
+        return
+    }
+    l.files[path] = refs
+}
+
+func (l *refList) remove(path string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    delete(l.files, path)
+}
+
+func (l *refList) blocks(path string) []markdown.Block {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    return l.files[path].Blocks
+}
+
+// connected returns the notes that an arrow on a canvas connects with the
+// note at file.
+func (l *refList) connected(file string) []string {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    var files []string
+    for _, refs := range l.files {
+        for _, edge := range refs.Edges {
+            switch file {
+            case edge[0]:
+                files = append(files, edge[1])
+            case edge[1]:
+                files = append(files, edge[0])
+            }
+        }
+    }
+    return files
+}
+
+// embedders returns the notes outside changed that embed a note in changed,
+// or embedded a missing note named like one in names.
+func (l *refList) embedders(changed, names map[string]bool) []string {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    var files []string
+    for file, refs := range l.files {
+        if !changed[file] && refs.embedsAny(changed, names) {
+            files = append(files, file)
+        }
+    }
+    return files
+}
+
+// missing returns the notes with an embed of one of names that pointed to
+// no note when they were indexed.
+func (l *refList) missing(names map[string]bool) []string {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    var files []string
+    for file, refs := range l.files {
+        if refs.embedsAny(nil, names) {
+            files = append(files, file)
+        }
+    }
+    return files
+}
+
+func (r noteRefs) embedsAny(files, names map[string]bool) bool {
+    for _, target := range r.Embeds {
+        if files[target] {
+            return true
+        }
+    }
+    for _, name := range r.Missing {
+        if names[name] {
+            return true
+        }
+    }
+    return false
+}
+
+func (l *refList) load(path string) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return
+    }
+    
+    var files map[string]noteRefs
+    if err := json.Unmarshal(data, &files); err != nil {
+        return
+    }
+    
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.files = files
+}
+
+func (l *refList) save(path string) error {
+    l.mu.RLock()
+    data, err := json.Marshal(l.files)
+    l.mu.RUnlock()
+    if err != nil {
+        return err
+    }
+    
+    // Write and rename so a crash never leaves a truncated list
+    tmp := path + ".tmp"
+    if err := os.WriteFile(tmp, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, path)
+}
+
+func (ix *Index) saveRefs() {
+    if err := ix.refs.save(filepath.Join(ix.indexPath, ".refs")); err != nil {
+        slog.Error("Failed to save references", "err", err)
+    }
+}
+
+// noteRefsOf returns the references of the note at file with the given body.
+// Block lines count from the top of the file.
+func noteRefsOf(resolver *linkResolver, file, body string, bodyLine int) noteRefs {
+    refs := noteRefs{Blocks: markdown.Blocks(body)}
+    for i := range refs.Blocks {
+        refs.Blocks[i].Line += bodyLine
+    }
+    for _, link := range markdown.Links(body) {
+        if !link.Embed || link.Target == "" {
+            continue
+        }
+        if target := resolver.resolve(file, link); target != "" {
+            refs.Embeds = append(refs.Embeds, target)
+        } else {
+            refs.Missing = append(refs.Missing, path.Base(linkKey(link.Target)))
+        }
+    }
+    return refs
+}
+
+// maxEmbedDepth bounds how deep embeds within embedded notes are followed.
+const maxEmbedDepth = 3
+
+// embedPart is the text that an embed on Line of a note points to.
+type embedPart struct {
+    Line int
+    Text string
+}
+
+// transclude returns the text that the embeds in body, a part of the note
+// at from, point to: whole notes without their frontmatter, sections and
+// blocks, with the embeds in them followed up to maxEmbedDepth. seen holds
+// the notes on the way, so embeds that form a cycle end.
+func transclude(resolver *linkResolver, from, body string, depth int, seen map[string]bool) string {
+    var parts []string
+    for _, e := range embeds(resolver, from, body, depth, seen) {
+        parts = append(parts, e.Text)
+    }
+    return strings.Join(parts, "\n\n")
+}
+
+// embeds returns the text of every embed in body like transclude, with the
+// line of body the embed is on.
+func embeds(resolver *linkResolver, from, body string, depth int, seen map[string]bool) []embedPart {
+    var found []embedPart
+    for _, link := range markdown.Links(body) {
+        if !link.Embed {
+            continue
+        }
+        file := resolver.resolve(from, link)
+        if file == "" || seen[file] || !isNote(file) {
+            continue
+        }
+        text, ok := embedText(file, link.Subpath)
+        if !ok {
+            continue
+        }
+        if depth < maxEmbedDepth {
+            seen[file] = true
+            if nested := transclude(resolver, file, text, depth+1, seen); nested != "" {
+                text += "\n\n" + nested
+            }
+            delete(seen, file)
+        }
+        found = append(found, embedPart{Line: link.Line, Text: text})
+    }
+    return found
+}
+
+// embedText reads the part of the note at file that subpath points to, or
+// all of it without the frontmatter if subpath is empty.
+func embedText(file, subpath string) (string, bool) {
+    _, body, _, err := readNote(file)
+    if err != nil {
+        return "", false
+    }
+    if subpath == "" {
+        return strings.TrimSpace(body), true
+    }
+    text, _, ok := markdown.Section(body, subpath)
+    return text, ok
+}
+
+// updateEmbedders reindexes the notes that embed one of the changed notes,
+// and the notes embedding those in turn, so the text they index for their
+// embeds is current. They are added to changed.
+func (ix *Index) updateEmbedders(resolver *linkResolver, changed map[string]bool) {
+    if !ix.options.ResolveEmbeds {
+        return
+    }
+    for {
+        names := make(map[string]bool, len(changed))
+        for file := range changed {
+            names[path.Base(linkKey(resolver.rel(file)))] = true
+            for _, alias := range resolver.aliases[file] {
+                names[strings.ToLower(alias)] = true
+            }
+        }
+        embedders := ix.refs.embedders(changed, names)
+        if len(embedders) == 0 {
+            return
+        }
+        for _, file := range embedders {
+            changed[file] = true
+            if info, err := os.Stat(file); err == nil {
+                ix.indexFile(file, info, resolver)
+            }
+        }
+    }
+}
+
+// ErrNoNote is returned by Resolve for links to notes that don't exist.
+var ErrNoNote = errors.New("no such note")
+
+// Reference is the text a link points to: a note, a section below a
+// heading or a block, or an attachment or a page of it. Line is the line of
+// the file it starts on.
+type Reference struct {
+    Vault    string `json:"vault,omitempty"`
+    Path     string `json:"path"`
+    FilePath string `json:"file_path"`
+    Subpath  string `json:"subpath,omitempty"`
+    Line     int    `json:"line"`
+    Page     int    `json:"page,omitempty"`
+    Text     string `json:"text"`
+}
+
+// Resolve returns the text that ref points to, a wiki link such as
+// [[note]], [[note#heading]], [[note#^id]] or [[note^id]], with or without
+// its brackets. Relative links and links within a note are resolved from
+// the note at the vault-relative path from, which may be empty otherwise.
+func (ix *Index) Resolve(ref, from string) (*Reference, error) {
+    link := markdown.ParseLink(ref)
+    resolver := ix.linkResolver()
+    
+    fromFile := ""
+    if from != "" {
+        fromFile = filepath.Join(resolver.root, filepath.FromSlash(from))
+        if !ix.isIndexable(fromFile) {
+            fromFile += ".md"
+        }
+    } else if link.Target == "" {
+        return nil, fmt.Errorf("%s points into the note it is in, name that note in from", ref)
+    }
+    
+    file := resolver.resolve(fromFile, link)
+    if file == "" {
+        return nil, fmt.Errorf("%w: %q", ErrNoNote, link.Target)
+    }
+    content, err := os.ReadFile(file)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %q", ErrNoNote, ix.vaultPath(file))
+    }
+    
+    r := &Reference{Path: ix.vaultPath(file), FilePath: file, Subpath: link.Subpath}
+    if ix.isAttachment(file) {
+        return ix.resolveAttachment(r, file, link.Subpath)
+    }
+    _, body, bodyLine := markdown.SplitFrontmatter(string(content))
+    switch {
+    case link.Subpath == "":
+        r.Text, r.Line = string(content), 1
+    case strings.HasPrefix(link.Subpath, "^"):
+        blocks := ix.refs.blocks(file)
+        var ids []string
+        for _, block := range blocks {
+            if strings.EqualFold(block.ID, link.Subpath[1:]) {
+                r.Text, r.Line = block.Text, block.Line
+                return r, nil
+            }
+            ids = append(ids, "^"+block.ID)
+        }
+        if len(ids) == 0 {
+            return nil, fmt.Errorf("no block %s in %s, it has no block IDs", link.Subpath, r.Path)
+        }
+        return nil, fmt.Errorf("no block %s in %s (blocks: %s)", link.Subpath, r.Path, strings.Join(ids, ", "))
+    default:
+        text, line, ok := markdown.Section(body, link.Subpath)
+        if !ok {
+            return nil, fmt.Errorf("no heading %q in %s", link.Subpath, r.Path)
+        }
+        r.Text, r.Line = text, line+bodyLine
+    }
+    return r, nil
+}
\ No newline at end of file
@@ -0,0 +1,185 @@
+package index
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestEmbeds(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(filepath.Join(vaultPath, "Shared"), 0755)
+    writes := 0
+    write := func(name, content string) {
+        file := filepath.Join(vaultPath, name)
+        os.WriteFile(file, []byte(content), 0644)
+        // Every write must look newer than the last indexing run
+        writes++
+        later := time.Now().Add(time.Duration(writes) * time.Second)
+        os.Chtimes(file, later, later)
+    }
+    write("Shared/Boilerplate.md", "---\ntags: [shared]\n---\n# Boilerplate\nThe quarterly zeppelin review.\n## Contacts\nAsk the harbourmaster. ^contact\n")
+    write("Report.md", "# Report\n![[Boilerplate]]\n")
+    write("Summary.md", "# Summary\n![[Boilerplate#^contact]] and ![[Report]] and ![[Summary]]\n")
+    write("Cover.md", "# Cover\n![[Appendix]]\n")
+    
+    opts := DefaultOptions()
+    opts.Backend = BackendBM25
+    index, err := Open(filepath.Join(t.TempDir(), "index"), opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    defer index.Close()
+    if err := index.IndexDirectory(vaultPath, 2); err != nil {
+        t.Fatalf("Failed to index directory: %v", err)
+    }
+    
+    found := func(q string) map[string]string {
+        results, err := index.Search(q, 10)
+        if err != nil {
+            t.Fatalf("Search(%q) failed: %v", q, err)
+        }
+        snippets := make(map[string]string)
+        for _, r := range results {
+            snippets[index.vaultPath(r.FilePath)] = r.Snippet
+        }
+        return snippets
+    }
+    
+    got := found("zeppelin")
+    if len(got) != 3 || !strings.Contains(got["Report.md"], "zeppelin") {
+        t.Errorf("Expected the note, its embedder and the embedder's embedder with snippets, got %v", got)
+    }
+    if got := found("harbourmaster"); len(got) != 3 {
+        t.Errorf("Expected the embedded block to be found in all three notes, got %v", got)
+    }
+    
+    // Changing an embedded note updates the notes embedding it, directly or
+    // through another note
+    write("Shared/Boilerplate.md", "# Boilerplate\nThe annual airship review.\n")
+    index.UpdateFile(filepath.Join(vaultPath, "Shared", "Boilerplate.md"))
+    if got := found("zeppelin"); len(got) != 0 {
+        t.Errorf("Expected the old embedded text to be gone, got %v", got)
+    }
+    if got := found("airship"); len(got) != 3 {
+        t.Errorf("Expected the new embedded text in all embedders, got %v", got)
+    }
+    
+    // A note that was missing is picked up by the note embedding it
+    write("Appendix.md", "Tables of gooseberry yields.\n")
+    index.IndexDirectory(vaultPath, 1)
+    if got := found("gooseberry"); len(got) != 2 {
+        t.Errorf("Expected the embed of the new note to resolve, got %v", got)
+    }
+    os.Remove(filepath.Join(vaultPath, "Appendix.md"))
+    index.RemoveFile(filepath.Join(vaultPath, "Appendix.md"))
+    if got := found("gooseberry"); len(got) != 0 {
+        t.Errorf("Expected the text of the deleted note to be gone, got %v", got)
+    }
+    
+    opts.ResolveEmbeds = false
+    index.SetOptions(opts)
+    index.Rebuild(vaultPath, 1)
+    if got := found("airship"); len(got) != 1 {
+        t.Errorf("Expected only the note itself without embed resolution, got %v", got)
+    }
+}
+
+func TestEmbedSnippets(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(vaultPath, 0755)
+    os.WriteFile(filepath.Join(vaultPath, "k8s.md"), []byte("# Cluster\nThree nodes.\n## Networking\nPods talk through the mesh.\n> [!decision] Use Cilium\n> Routing runs in eBPF.\n\nCilium replaces kube-proxy.\n## Storage\nCeph for volumes.\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Budget.md"), []byte("---\ntags: [budget]\n---\n# Budget\nHosting costs.\n![[k8s#Networking]]\n"), 0644)
+    
+    opts := DefaultOptions()
+    opts.Backend = BackendBM25
+    index, err := Open(filepath.Join(t.TempDir(), "index"), opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    defer index.Close()
+    if err := index.IndexDirectory(vaultPath, 1); err != nil {
+        t.Fatalf("Failed to index directory: %v", err)
+    }
+    
+    results, err := index.Search("cilium", 10)
+    if err != nil {
+        t.Fatalf("Search failed: %v", err)
+    }
+    var budget *SearchResult
+    for i := range results {
+        if filepath.Base(results[i].FilePath) == "Budget.md" {
+            budget = &results[i]
+        }
+    }
+    if budget == nil {
+        t.Fatalf("Expected the note embedding the section, got %+v", results)
+    }
+    
+    // Embedded lines are numbered with the line of their embed, below the
+    // frontmatter, and keep their callout
+    want := "L6: [decision: Use Cilium] Use Cilium\nL6: Cilium replaces kube-proxy."
+    if budget.Snippet != want {
+        t.Errorf("snippet = %q, want %q", budget.Snippet, want)
+    }
+    if len(budget.LineNumbers) != 1 || budget.LineNumbers[0] != 6 {
+        t.Errorf("Expected the line of the embed, got %v", budget.LineNumbers)
+    }
+}
+
+func TestResolve(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(filepath.Join(vaultPath, "Projects"), 0755)
+    plan := "---\nstatus: draft\n---\n# Plan\n## Risks\nBudget might slip.\n### Mitigation\nWeekly reviews ^reviews\n## Budget\nPlenty\n"
+    os.WriteFile(filepath.Join(vaultPath, "Projects", "Plan.md"), []byte(plan), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Home.md"), []byte("See [[Plan#Risks]]\n"), 0644)
+    
+    opts := DefaultOptions()
+    opts.Backend = BackendBM25
+    index, err := Open(filepath.Join(t.TempDir(), "index"), opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    defer index.Close()
+    if err := index.IndexDirectory(vaultPath, 1); err != nil {
+        t.Fatalf("Failed to index directory: %v", err)
+    }
+    
+    tests := []struct {
+        ref, from string
+        text      string
+        line      int
+    }{
+        {"[[Plan#Risks]]", "", "## Risks\nBudget might slip.\n### Mitigation\nWeekly reviews ^reviews", 5},
+        {"Plan#Risks#Mitigation", "", "### Mitigation\nWeekly reviews ^reviews", 7},
+        {"![[Plan#^reviews|x]]", "", "Weekly reviews", 8},
+        {"Plan^reviews", "", "Weekly reviews", 8},
+        {"[[#Budget]]", "Projects/Plan", "## Budget\nPlenty", 9},
+        {"[[Projects/Plan]]", "", plan, 1},
+    }
+    for _, tt := range tests {
+        r, err := index.Resolve(tt.ref, tt.from)
+        if err != nil {
+            t.Errorf("Resolve(%q) failed: %v", tt.ref, err)
+            continue
+        }
+        if r.Text != tt.text || r.Line != tt.line || r.Path != "Projects/Plan.md" {
+            t.Errorf("Resolve(%q) = %q at %s:%d, want %q at line %d", tt.ref, r.Text, r.Path, r.Line, tt.text, tt.line)
+        }
+    }
+    
+    errors := map[string]string{
+        "[[Nowhere]]":  "no such note",
+        "Plan#Costs":   "no heading",
+        "Plan#^nope":   "blocks: ^reviews",
+        "Home#^nope":   "has no block IDs",
+        "[[#Budget]]":  "name that note in from",
+    }
+    for ref, want := range errors {
+        if _, err := index.Resolve(ref, ""); err == nil || !strings.Contains(err.Error(), want) {
+            t.Errorf("Resolve(%q) = %v, want an error containing %q", ref, err, want)
+        }
+    }
+}
\ No newline at end of file
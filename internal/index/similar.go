@@ -0,0 +1,168 @@
+package index
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+)
+
+// SimilarNote is a note that shares distinctive words with another note.
+// Score is the share of the other note's term weight found in this one,
+// between 0 and 1. SharedTerms lists the shared words, most distinctive
+// first.
+type SimilarNote struct {
+    Vault       string   `json:"vault,omitempty"`
+    FilePath    string   `json:"file_path"`
+    Score       float64  `json:"score"`
+    SharedTerms []string `json:"shared_terms"`
+}
+
+const (
+    // maxDistinctiveTerms is the number of words a note is compared by.
+    maxDistinctiveTerms = 25
+    
+    // minTermLength keeps short words, mostly abbreviations and numbers,
+    // from making notes look alike.
+    minTermLength = 3
+)
+
+// weightedTerm is a word with its TF-IDF weight in a note.
+type weightedTerm struct {
+    term   string
+    weight float64
+}
+
+// distinctiveTerms returns the words of text that best tell the note at
+// path apart from the other notes, weighted by TF-IDF:
+//
+//    weight = (1 + ln tf) × ln(notes / notes containing the word)
+//
+// Stopwords, words shorter than minTermLength, numbers and words that no
+// other note contains are left out.
+func (d *termDict) distinctiveTerms(path, text string, limit int) []weightedTerm {
+    tf := make(map[string]int)
+    for _, term := range analysis.Tokenize(text) {
+        if len(term) >= minTermLength && len(term) <= maxTermLength && !analysis.IsStopword(term) && !isNumber(term) {
+            tf[term]++
+        }
+    }
+    
+    d.mu.RLock()
+    notes := len(d.docTerms)
+    _, indexed := d.docTerms[path]
+    if !indexed {
+        notes++
+    }
+    terms := make([]weightedTerm, 0, len(tf))
+    for term, count := range tf {
+        df := d.docFreq[term]
+        if !indexed {
+            df++
+        }
+        if df < 2 || df >= notes {
+            continue
+        }
+        idf := math.Log(float64(notes) / float64(df))
+        terms = append(terms, weightedTerm{term, (1 + math.Log(float64(count))) * idf})
+    }
+    d.mu.RUnlock()
+    
+    sort.Slice(terms, func(i, j int) bool {
+        if terms[i].weight != terms[j].weight {
+            return terms[i].weight > terms[j].weight
+        }
+        return terms[i].term < terms[j].term
+    })
+    if len(terms) > limit {
+        terms = terms[:limit]
+    }
+    return terms
+}
+
+func isNumber(term string) bool {
+    for _, r := range term {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+    return true
+}
+
+// similarNotes scores every note other than path by the terms it shares,
+// best first. Notes sharing fewer than two terms are left out, unless
+// there is only one term to share.
+func (d *termDict) similarNotes(path string, terms []weightedTerm) []SimilarNote {
+    total := 0.0
+    for _, t := range terms {
+        total += t.weight
+    }
+    minShared := min(2, len(terms))
+    if total == 0 {
+        return nil
+    }
+    
+    var notes []SimilarNote
+    d.eachDoc(func(other string, docTerms []string) {
+        if other == path {
+            return
+        }
+        
+        var shared []string
+        weight := 0.0
+        for _, t := range terms {
+            if i := sort.SearchStrings(docTerms, t.term); i < len(docTerms) && docTerms[i] == t.term {
+                shared = append(shared, t.term)
+                weight += t.weight
+            }
+        }
+        if len(shared) >= minShared {
+            notes = append(notes, SimilarNote{
+                FilePath:    other,
+                Score:       math.Round(weight/total*1000) / 1000,
+                SharedTerms: shared,
+            })
+        }
+    })
+    
+    sort.Slice(notes, func(i, j int) bool {
+        if notes[i].Score != notes[j].Score {
+            return notes[i].Score > notes[j].Score
+        }
+        return notes[i].FilePath < notes[j].FilePath
+    })
+    return notes
+}
+
+// Similar returns up to limit notes that share distinctive words with the
+// note at path, best first. Notes that the note links to and notes that
+// link to it, also through a canvas, are left out, since the connection is
+// already known.
+func (ix *Index) Similar(path string, limit int) ([]SimilarNote, error) {
+    _, body, _, err := ix.readText(path)
+    if err != nil {
+        return nil, err
+    }
+    
+    terms := ix.terms.distinctiveTerms(path, noteTitle(path, body)+"\n"+body, maxDistinctiveTerms)
+    if len(terms) == 0 {
+        return nil, fmt.Errorf("no distinctive words in %s", ix.vaultPath(path))
+    }
+    
+    resolver := ix.linkResolver()
+    linked := ix.linkedNotes(resolver, path)
+    
+    var results []SimilarNote
+    for _, note := range ix.terms.similarNotes(path, terms) {
+        if len(results) >= limit {
+            break
+        }
+        if linked[note.FilePath] || ix.linkedNotes(resolver, note.FilePath)[path] {
+            continue
+        }
+        note.FilePath = ix.vaultPath(note.FilePath)
+        results = append(results, note)
+    }
+    return results, nil
+}
\ No newline at end of file
@@ -0,0 +1,148 @@
+package index
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/ignore"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+)
+
+// similarIndex returns an index with only the term dictionary, the file
+// registry and the references filled in for notes, which is all that
+// Similar needs besides empty properties.
+func similarIndex(t *testing.T, notes map[string]string) (*Index, string) {
+    t.Helper()
+    root := t.TempDir()
+    matcher, err := ignore.Load(root, nil)
+    if err != nil {
+        t.Fatalf("Failed to load ignore rules: %v", err)
+    }
+    ix := &Index{
+        lastIndexed: make(map[string]time.Time),
+        ignore:      matcher,
+        terms:       newTermDict(),
+        refs:        newRefList(),
+        properties:  newPropertyList(),
+    }
+    for rel, content := range notes {
+        path := filepath.Join(root, filepath.FromSlash(rel))
+        os.MkdirAll(filepath.Dir(path), 0755)
+        if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+            t.Fatal(err)
+        }
+        ix.terms.add(path, noteTitle(path, content)+"\n"+content)
+        ix.lastIndexed[path] = time.Now()
+    }
+    return ix, root
+}
+
+func TestSimilar(t *testing.T) {
+    ix, root := similarIndex(t, map[string]string{
+        "Projects/garden.md": "# Garden\nPlanting tomatoes and basil in the raised beds.\nCompost for the tomatoes. See [[Seeds]].\n",
+        "Seeds.md":           "# Seeds\nOrdered tomatoes, basil and compost.\n",
+        "Harvest.md":         "# Harvest\nThe tomatoes and basil from the raised beds.\n",
+        "Kitchen.md":         "# Kitchen\nA recipe with tomatoes and basil from the [[garden]].\n",
+        "Work.md":            "# Work\nQuarterly budget review with the team.\n",
+        "Taxes.md":           "# Taxes\nThe budget and the receipts.\n",
+    })
+    
+    results, err := ix.Similar(filepath.Join(root, "Projects", "garden.md"), 10)
+    if err != nil {
+        t.Fatalf("Similar failed: %v", err)
+    }
+    if len(results) != 1 || results[0].FilePath != "Harvest.md" {
+        t.Fatalf("Expected only the unlinked harvest note, got %+v", results)
+    }
+    if want := []string{"beds", "raised", "tomatoes", "basil"}; !reflect.DeepEqual(results[0].SharedTerms, want) {
+        t.Errorf("Shared terms = %q, want %q", results[0].SharedTerms, want)
+    }
+    if results[0].Score <= 0 || results[0].Score >= 1 {
+        t.Errorf("Expected a score between 0 and 1, got %v", results[0].Score)
+    }
+}
+
+func TestDistinctiveTerms(t *testing.T) {
+    d := newTermDict()
+    d.add("a.md", "the budget budget review")
+    d.add("b.md", "the budget and more")
+    d.add("c.md", "the review of 2024")
+    d.add("d.md", "the end")
+    
+    terms := d.distinctiveTerms("a.md", "the budget budget review 2024 unique", 10)
+    var got []string
+    for _, term := range terms {
+        got = append(got, term.term)
+    }
+    // "the" is a stopword in every note, "2024" a number and "unique"
+    // occurs in no other note
+    if want := []string{"budget", "review"}; !reflect.DeepEqual(got, want) {
+        t.Errorf("distinctiveTerms() = %q, want %q", got, want)
+    }
+    if terms[0].weight <= terms[1].weight {
+        t.Errorf("Expected the repeated word to weigh more, got %+v", terms)
+    }
+}
+
+
+func TestResolveLinks(t *testing.T) {
+    root := filepath.FromSlash("/vault")
+    file := func(rel string) string { return filepath.Join(root, filepath.FromSlash(rel)) }
+    r := newLinkResolver(root, []string{
+        file("Projects/Roadmap.md"),
+        file("Archive/Projects/Roadmap.md"),
+        file("Archive/notes.md"),
+        file("notes.md"),
+        file("Daily/2024-01-02.md"),
+    })
+    r.setAliases(map[string][]string{
+        file("Archive/Projects/Roadmap.md"): {"Plan"},
+        file("Projects/Roadmap.md"):         {"Plan", "Notes"},
+        file("Missing.md"):                  {"Ghost"},
+    })
+    
+    tests := []struct {
+        from, target, want string
+    }{
+        {"notes.md", "roadmap", "Projects/Roadmap.md"},
+        {"Archive/notes.md", "Roadmap", "Projects/Roadmap.md"},
+        {"Archive/Projects/Roadmap.md", "notes", "notes.md"},
+        {"Archive/notes.md", "notes.md", "Archive/notes.md"},
+        {"notes.md", "Archive/Projects/Roadmap", "Archive/Projects/Roadmap.md"},
+        {"Archive/notes.md", "Projects/Roadmap.md", "Archive/Projects/Roadmap.md"},
+        {"Daily/2024-01-02.md", "../notes.md", "notes.md"},
+        {"notes.md", "missing", ""},
+        {"notes.md", "plan", "Projects/Roadmap.md"},
+        {"Archive/Projects/notes.md", "Plan", "Archive/Projects/Roadmap.md"},
+        {"Daily/2024-01-02.md", "Notes", "notes.md"},
+        {"notes.md", "Ghost", ""},
+    }
+    for _, tt := range tests {
+        got := r.resolve(file(tt.from), markdown.Link{Target: tt.target})
+        if tt.want == "" {
+            if got != "" {
+                t.Errorf("%s -> %s: expected no note, got %s", tt.from, tt.target, got)
+            }
+        } else if got != file(tt.want) {
+            t.Errorf("%s -> %s = %s, want %s", tt.from, tt.target, got, file(tt.want))
+        }
+    }
+    
+    // Aliases a note no longer has, or that notes without aliases had, stop
+    // resolving
+    r.setAliases(map[string][]string{
+        file("Projects/Roadmap.md"): {"Next quarter"},
+    })
+    if got := r.resolve(file("notes.md"), markdown.Link{Target: "Plan"}); got != "" {
+        t.Errorf("Expected the removed alias not to resolve, got %s", got)
+    }
+    if got := r.resolve(file("notes.md"), markdown.Link{Target: "next quarter"}); got != file("Projects/Roadmap.md") {
+        t.Errorf("Expected the new alias to resolve, got %s", got)
+    }
+    if a := r.aliases[file("Archive/Projects/Roadmap.md")]; a != nil {
+        t.Errorf("Expected no aliases for a note without, got %v", a)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,146 @@
+package index
+
+import (
+    "path/filepath"
+    "sort"
+    "strings"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+)
+
+// namesAnalyzer splits note names into words and indexes every prefix of
+// each word, so a name is found while it is still being typed.
+const namesAnalyzer = "edge_ngram_names"
+
+// Edge n-gram bounds: a single letter already completes, and words are
+// indexed up to maxNameGram letters, beyond which typing narrows nothing.
+const (
+    minNameGram = 1
+    maxNameGram = 20
+)
+
+// Completion is a note whose title, alias or file name matches the text
+// typed so far.
+type Completion struct {
+    Vault    string `json:"vault,omitempty"`
+    Title    string `json:"title"`
+    Path     string `json:"path"`
+    FilePath string `json:"file_path"`
+    Match    string `json:"match"`
+    
+    rank int
+}
+
+// Completion ranks, best first.
+const (
+    rankExact      = iota // a name equals the typed text
+    rankNamePrefix        // a name starts with the typed text
+    rankWordPrefix        // every typed word starts a word of one name
+    rankOther             // typed words are spread over several names
+)
+
+// noteNames returns the names a note can be completed by: its title, its
+// aliases and its file name, without duplicates.
+func noteNames(title string, aliases []string, path string) []string {
+    base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+    seen := make(map[string]bool)
+    var names []string
+    for _, name := range append(append([]string{title}, aliases...), base) {
+        name = strings.TrimSpace(name)
+        if key := analysis.Fold(name); name != "" && !seen[key] {
+            seen[key] = true
+            names = append(names, name)
+        }
+    }
+    return names
+}
+
+// Complete returns up to limit notes whose names start with prefix, best
+// matches first. Every word of prefix must start a word of the title, an
+// alias or the file name; exact and whole-name matches rank first.
+func (ix *Index) Complete(prefix string, limit int) ([]Completion, error) {
+    prefix = strings.TrimSpace(analysis.Normalize(prefix))
+    if prefix == "" || limit <= 0 {
+        return nil, nil
+    }
+    
+    ix.mu.RLock()
+    defer ix.mu.RUnlock()
+    
+    // Fetch extra candidates, the final order is decided by rankCompletions
+    matches, err := ix.engine.complete(prefix, max(limit*5, 50))
+    if err != nil {
+        return nil, err
+    }
+    
+    completions := make([]Completion, 0, len(matches))
+    for _, m := range matches {
+        rank, match := bestName(prefix, m.names)
+        completions = append(completions, Completion{
+            Title:    m.names[0],
+            Path:     ix.vaultPath(m.path),
+            FilePath: m.path,
+            Match:    match,
+            rank:     rank,
+        })
+    }
+    
+    rankCompletions(completions)
+    if len(completions) > limit {
+        completions = completions[:limit]
+    }
+    return completions, nil
+}
+
+// bestName returns the rank of the best matching name and the name itself.
+func bestName(prefix string, names []string) (int, string) {
+    folded := analysis.Fold(prefix)
+    words := analysis.Tokenize(prefix)
+    
+    best, match := rankOther, names[0]
+    for _, name := range names {
+        rank := nameRank(folded, words, name)
+        if rank < best || (rank == best && rank < rankOther && len(name) < len(match)) {
+            best, match = rank, name
+        }
+    }
+    return best, match
+}
+
+func nameRank(folded string, words []string, name string) int {
+    foldedName := analysis.Fold(name)
+    switch {
+    case foldedName == folded:
+        return rankExact
+    case strings.HasPrefix(foldedName, folded):
+        return rankNamePrefix
+    }
+    
+    nameWords := analysis.Tokenize(name)
+    for _, word := range words {
+        found := false
+        for _, nameWord := range nameWords {
+            if strings.HasPrefix(nameWord, word) {
+                found = true
+                break
+            }
+        }
+        if !found {
+            return rankOther
+        }
+    }
+    return rankWordPrefix
+}
+
+// rankCompletions orders completions by match rank, then by the length of
+// the matched name so that shorter, closer names come first. Ties keep the
+// index order.
+func rankCompletions(completions []Completion) {
+    sort.SliceStable(completions, func(i, j int) bool {
+        a, b := completions[i], completions[j]
+        if a.rank != b.rank {
+            return a.rank < b.rank
+        }
+        return len([]rune(a.Match)) < len([]rune(b.Match))
+    })
+}
\ No newline at end of file
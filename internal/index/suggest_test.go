@@ -0,0 +1,97 @@
+package index
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestNoteNames(t *testing.T) {
+    names := noteNames("Roadmap 2025", []string{"Plan", "roadmap 2025", " "}, "/vault/Projects/roadmap-2025.md")
+    want := []string{"Roadmap 2025", "Plan", "roadmap-2025"}
+    if len(names) != len(want) {
+        t.Fatalf("noteNames() = %q, want %q", names, want)
+    }
+    for i := range want {
+        if names[i] != want[i] {
+            t.Errorf("noteNames()[%d] = %q, want %q", i, names[i], want[i])
+        }
+    }
+}
+
+func TestRankCompletions(t *testing.T) {
+    candidates := [][]string{
+        {"Weekly planning", "weekly-planning"},
+        {"Project kickoff", "Kickoff", "project-kickoff"},
+        {"Planning poker"},
+        {"Plan"},
+        {"Release plan", "Plan B"},
+    }
+    
+    var completions []Completion
+    for _, names := range candidates {
+        rank, match := bestName("plan", names)
+        completions = append(completions, Completion{Title: names[0], Match: match, rank: rank})
+    }
+    rankCompletions(completions)
+    
+    want := []string{"Plan", "Release plan", "Planning poker", "Weekly planning", "Project kickoff"}
+    for i, c := range completions {
+        if c.Title != want[i] {
+            t.Errorf("completion %d = %q, want %q (order %v)", i, c.Title, want[i], completions)
+        }
+    }
+    if completions[1].Match != "Plan B" {
+        t.Errorf("Expected the alias to be reported as the match, got %q", completions[1].Match)
+    }
+}
+
+func TestRankCompletionsAbbreviatedWords(t *testing.T) {
+    rank, match := bestName("mtg not", []string{"Meeting notes", "mtg-notes"})
+    if rank != rankWordPrefix || match != "mtg-notes" {
+        t.Errorf("bestName() = %d, %q; want a word prefix match on the file name", rank, match)
+    }
+    
+    if rank, _ := bestName("münch", []string{"Munchen trip"}); rank != rankNamePrefix {
+        t.Errorf("Expected diacritics to be folded, got rank %d", rank)
+    }
+}
+
+func TestComplete(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(filepath.Join(vaultPath, "Projects"), 0755)
+    os.WriteFile(filepath.Join(vaultPath, "Projects", "roadmap.md"),
+        []byte("---\naliases: [Plan 2025]\n---\n# Product Roadmap\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "road trip.md"), []byte("Packing list\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "budget.md"), []byte("# Budget\n"), 0644)
+    
+    forEachBackend(t, func(t *testing.T, index *Index) {
+        if err := index.IndexDirectory(vaultPath, 1); err != nil {
+            t.Fatalf("Failed to index directory: %v", err)
+        }
+        
+        completions, err := index.Complete("road", 10)
+        if err != nil {
+            t.Fatalf("Complete failed: %v", err)
+        }
+        if len(completions) != 2 {
+            t.Fatalf("Expected the roadmap and the road trip, got %+v", completions)
+        }
+        
+        completions, err = index.Complete("road t", 10)
+        if err != nil {
+            t.Fatalf("Complete failed: %v", err)
+        }
+        if len(completions) != 1 || completions[0].Path != "road trip.md" {
+            t.Errorf("Expected only the road trip, got %+v", completions)
+        }
+        
+        completions, err = index.Complete("pla", 10)
+        if err != nil {
+            t.Fatalf("Complete failed: %v", err)
+        }
+        if len(completions) != 1 || completions[0].Path != "Projects/roadmap.md" || completions[0].Match != "Plan 2025" {
+            t.Errorf("Expected the roadmap by its alias, got %+v", completions)
+        }
+    })
+}
\ No newline at end of file
@@ -1,32 +1,64 @@
+//go:build !purego
+
 package index
 
 import (
+    "encoding/json"
     "fmt"
-    "os"
-    "path/filepath"
+    "strconv"
     "strings"
-    "sync"
     "time"
     
     tantivy "github.com/anyproto/tantivy-go"
-    "github.com/karrick/godirwalk"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
 )
 
-type SearchResult struct {
-    FilePath    string   `json:"file_path"`
-    Snippet     string   `json:"snippet"`
-    Score       float32  `json:"score"`
-    LineNumbers []int    `json:"line_numbers"`
+// tantivyAvailable reports whether the binary was built with the Tantivy
+// backend, which needs CGO and the Tantivy C library. Build with the
+// purego tag to leave it out.
+const tantivyAvailable = true
+
+// textFields are analyzed once per configured language, as <field>_<lang>.
+var textFields = []string{"title", "aliases", "headings", "content"}
+
+// searchFields returns the index fields searched for a boost field.
+func searchFields(field string, languages []string) []string {
+    switch field {
+    case "path":
+        return []string{"path_text"}
+    case "tags":
+        return []string{"tags"}
+    case "urls":
+        return []string{"urls"}
+    case "code":
+        return []string{"code"}
+    }
+    fields := make([]string, len(languages))
+    for i, lang := range languages {
+        fields[i] = languageField(field, lang)
+    }
+    return fields
+}
+
+func languageField(field, lang string) string {
+    return field + "_" + lang
+}
+
+func languageAnalyzer(lang string) string {
+    return tantivy.TokenizerSimple + "_" + lang
 }
 
-type TantivyIndex struct {
-    context     *tantivy.TantivyContext
-    indexPath   string
-    mu          sync.RWMutex
-    lastIndexed map[string]time.Time
+// tantivyEngine keeps the notes in a Tantivy index, with a stemmer for
+// every configured language.
+type tantivyEngine struct {
+    context   *tantivy.TantivyContext
+    languages []string
 }
 
-func NewTantivyIndex(indexPath string) (*TantivyIndex, error) {
+// newTantivyEngine opens the Tantivy index in indexPath. The languages are
+// part of the schema.
+func newTantivyEngine(indexPath string, languages []string) (engine, error) {
     // Initialize tantivy library
     err := tantivy.LibInit(false, false, "info")
     if err != nil {
@@ -43,7 +75,7 @@ func NewTantivyIndex(indexPath string) (*TantivyIndex, error) {
     err = builder.AddTextField(
         "path",
         true,  // stored
-        false, // indexed as text
+        true,  // indexed whole, so documents can be deleted by path
         false, // fast
         tantivy.IndexRecordOptionBasic,
         tantivy.TokenizerRaw,
@@ -53,15 +85,125 @@ func NewTantivyIndex(indexPath string) (*TantivyIndex, error) {
     }
     
     err = builder.AddTextField(
-        "content",
-        false, // not stored (we'll read from file)
+        "lang",
+        true,  // stored
+        true,  // indexed as text
+        false, // fast
+        tantivy.IndexRecordOptionBasic,
+        tantivy.TokenizerRaw,
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to add lang field: %w", err)
+    }
+    
+    // One field per text field and language, each with its own stemmer.
+    // A note fills only the fields of its own language.
+    for _, lang := range languages {
+        for _, field := range textFields {
+            err = builder.AddTextField(
+                languageField(field, lang),
+                field == "title", // only titles are stored, content is read from the file
+                true,             // indexed as text
+                false,            // fast
+                tantivy.IndexRecordOptionWithFreqsAndPositions,
+                languageAnalyzer(lang),
+            )
+            if err != nil {
+                return nil, fmt.Errorf("failed to add %s field: %w", field, err)
+            }
+        }
+    }
+    
+    // Tags, split at '/' into words; they are not in any language
+    err = builder.AddTextField(
+        "tags",
+        false, // not stored
+        true,  // indexed as text
+        false, // fast
+        tantivy.IndexRecordOptionWithFreqsAndPositions,
+        languageAnalyzer(languages[0]),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to add tags field: %w", err)
+    }
+    
+    // Vault-relative path, folded like titles so file names are searchable
+    err = builder.AddTextField(
+        "path_text",
+        false, // not stored
+        true,  // indexed as text
+        false, // fast
+        tantivy.IndexRecordOptionWithFreqsAndPositions,
+        languageAnalyzer(languages[0]),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to add path_text field: %w", err)
+    }
+    
+    // Web addresses linked from the note, apart from its text
+    err = builder.AddTextField(
+        "urls",
+        false, // not stored
+        true,  // indexed as text
+        false, // fast
+        tantivy.IndexRecordOptionWithFreqsAndPositions,
+        languageAnalyzer(languages[0]),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to add urls field: %w", err)
+    }
+    
+    // Code, one identifier per value so that names like snake_case and
+    // pkg.Func are kept whole; see analysis.TokenizeCode
+    err = builder.AddTextField(
+        "code",
+        false, // not stored
+        true,  // indexed as text
+        false, // fast
+        tantivy.IndexRecordOptionWithFreqsAndPositions,
+        tantivy.TokenizerRaw,
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to add code field: %w", err)
+    }
+    
+    // Languages of the code blocks, for lang: filters
+    err = builder.AddTextField(
+        "code_lang",
+        false, // not stored
+        true,  // indexed as text
+        false, // fast
+        tantivy.IndexRecordOptionBasic,
+        tantivy.TokenizerRaw,
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to add code_lang field: %w", err)
+    }
+    
+    // Types of the callouts, for callout: filters
+    err = builder.AddTextField(
+        "callout_type",
+        false, // not stored
+        true,  // indexed as text
+        false, // fast
+        tantivy.IndexRecordOptionBasic,
+        tantivy.TokenizerRaw,
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to add callout_type field: %w", err)
+    }
+    
+    // Title, aliases and file name, one per line, for completion as you type
+    err = builder.AddTextField(
+        "names",
+        true,  // stored
         true,  // indexed as text
         false, // fast
         tantivy.IndexRecordOptionWithFreqsAndPositions,
-        tantivy.TokenizerSimple,
+        namesAnalyzer,
     )
     if err != nil {
-        return nil, fmt.Errorf("failed to add content field: %w", err)
+        return nil, fmt.Errorf("failed to add names field: %w", err)
     }
     
     // For now, store modified as text field since AddI64Field is not available
@@ -77,16 +219,17 @@ func NewTantivyIndex(indexPath string) (*TantivyIndex, error) {
         return nil, fmt.Errorf("failed to add modified field: %w", err)
     }
     
+    // Unix time of the created or date property, or the modification time
     err = builder.AddTextField(
-        "title",
+        "created",
         true,  // stored
-        true,  // indexed as text
+        false, // not indexed as text
         false, // fast
-        tantivy.IndexRecordOptionWithFreqsAndPositions,
-        tantivy.TokenizerSimple,
+        tantivy.IndexRecordOptionBasic,
+        tantivy.TokenizerRaw,
     )
     if err != nil {
-        return nil, fmt.Errorf("failed to add title field: %w", err)
+        return nil, fmt.Errorf("failed to add created field: %w", err)
     }
     
     // Build schema
@@ -96,19 +239,22 @@ func NewTantivyIndex(indexPath string) (*TantivyIndex, error) {
     }
     
     // Create or open index
-    if _, err := os.Stat(indexPath); os.IsNotExist(err) {
-        os.MkdirAll(indexPath, 0755)
-    }
-    
     context, err := tantivy.NewTantivyContextWithSchema(indexPath, schema)
     if err != nil {
         return nil, fmt.Errorf("failed to create index context: %w", err)
     }
     
     // Register tokenizers
-    err = context.RegisterTextAnalyzerSimple(tantivy.TokenizerSimple, 10000, tantivy.English)
+    for _, lang := range languages {
+        err = context.RegisterTextAnalyzerSimple(languageAnalyzer(lang), 10000, tantivy.Language(lang))
+        if err != nil {
+            return nil, fmt.Errorf("failed to register %s analyzer: %w", lang, err)
+        }
+    }
+    
+    err = context.RegisterTextAnalyzerEdgeNgram(namesAnalyzer, minNameGram, maxNameGram, 1000)
     if err != nil {
-        return nil, fmt.Errorf("failed to register simple analyzer: %w", err)
+        return nil, fmt.Errorf("failed to register names analyzer: %w", err)
     }
     
     err = context.RegisterTextAnalyzerRaw(tantivy.TokenizerRaw)
@@ -116,288 +262,239 @@ func NewTantivyIndex(indexPath string) (*TantivyIndex, error) {
         return nil, fmt.Errorf("failed to register raw analyzer: %w", err)
     }
     
-    return &TantivyIndex{
-        context:     context,
-        indexPath:   indexPath,
-        lastIndexed: make(map[string]time.Time),
-    }, nil
+    return &tantivyEngine{context: context, languages: languages}, nil
 }
 
-func (ti *TantivyIndex) IndexDirectory(rootPath string, numWorkers int) error {
-    ti.mu.Lock()
-    defer ti.mu.Unlock()
-    
-    // Load saved timestamps
-    ti.loadIndexTimestamps()
-    
-    type indexJob struct {
-        path string
-        info os.FileInfo
-    }
-    
-    jobs := make(chan indexJob, 100)
-    var wg sync.WaitGroup
-    
-    // Start workers
-    for i := 0; i < numWorkers; i++ {
-        wg.Add(1)
-        go func() {
-            defer wg.Done()
-            for job := range jobs {
-                ti.indexFile(job.path, job.info)
-            }
-        }()
-    }
-    
-    // Directory traversal
-    err := godirwalk.Walk(rootPath, &godirwalk.Options{
-        Callback: func(path string, de *godirwalk.Dirent) error {
-            if !strings.HasSuffix(path, ".md") {
-                return nil
-            }
-            
-            info, err := os.Stat(path)
-            if err != nil {
-                return nil
-            }
-            
-            // Only index modified files
-            if lastIndexed, exists := ti.lastIndexed[path]; exists {
-                if info.ModTime().Before(lastIndexed) || info.ModTime().Equal(lastIndexed) {
-                    return nil
-                }
-            }
-            
-            jobs <- indexJob{path: path, info: info}
-            return nil
-        },
-        Unsorted: true,
-        ErrorCallback: func(path string, err error) godirwalk.ErrorAction {
-            fmt.Printf("Error walking %s: %v\n", path, err)
-            return godirwalk.SkipNode
-        },
-    })
-    
-    close(jobs)
-    wg.Wait()
-    
-    // Save timestamps
-    ti.saveIndexTimestamps()
-    
-    return err
-}
-
-func (ti *TantivyIndex) indexFile(path string, info os.FileInfo) error {
-    content, err := os.ReadFile(path)
-    if err != nil {
-        return err
-    }
-    
-    // Extract title from first line
-    lines := strings.Split(string(content), "\n")
-    title := filepath.Base(path)
-    if len(lines) > 0 && strings.HasPrefix(lines[0], "#") {
-        title = strings.TrimSpace(strings.TrimPrefix(lines[0], "#"))
-    }
-    
-    // Delete old document if exists
-    err = ti.context.DeleteDocuments("path", path)
-    if err != nil {
-        // Ignore error - document might not exist
+func (e *tantivyEngine) add(doc *document) error {
+    // Replace the document of an earlier version
+    if err := e.context.DeleteDocuments("path", doc.path); err != nil {
+        return fmt.Errorf("failed to delete old document: %w", err)
     }
     
     // Create new document
-    doc := tantivy.NewDocument()
-    if doc == nil {
+    d := tantivy.NewDocument()
+    if d == nil {
         return fmt.Errorf("failed to create document")
     }
     
-    // Add fields
-    err = doc.AddField(path, ti.context, "path")
-    if err != nil {
-        return fmt.Errorf("failed to add path field: %w", err)
-    }
-    
-    err = doc.AddField(string(content), ti.context, "content")
-    if err != nil {
-        return fmt.Errorf("failed to add content field: %w", err)
-    }
-    
-    err = doc.AddField(fmt.Sprintf("%d", info.ModTime().Unix()), ti.context, "modified")
-    if err != nil {
-        return fmt.Errorf("failed to add modified field: %w", err)
+    fields := []struct {
+        name  string
+        value string
+    }{
+        {"path", doc.path},
+        {"lang", doc.lang},
+        {languageField("content", doc.lang), doc.content},
+        {"path_text", doc.pathText},
+        {"names", strings.Join(doc.names, "\n")},
+        {"modified", fmt.Sprintf("%d", doc.modified.Unix())},
+        {"created", fmt.Sprintf("%d", doc.created.Unix())},
+        // Structure that is ranked apart from the content
+        {languageField("aliases", doc.lang), strings.Join(doc.aliases, "\n")},
+        {languageField("headings", doc.lang), strings.Join(doc.headings, "\n")},
+        {"tags", strings.Join(doc.tags, " ")},
+        {"urls", strings.Join(doc.urls, "\n")},
+        {languageField("title", doc.lang), doc.title},
+    }
+    for _, field := range fields {
+        if field.value == "" {
+            continue
+        }
+        if err := d.AddField(field.value, e.context, field.name); err != nil {
+            return fmt.Errorf("failed to add %s field: %w", field.name, err)
+        }
     }
     
-    err = doc.AddField(title, ti.context, "title")
-    if err != nil {
-        return fmt.Errorf("failed to add title field: %w", err)
+    // Fields with a value per word, which the raw tokenizer keeps whole
+    var code []string
+    for _, block := range doc.code {
+        code = append(code, analysis.TokenizeCode(block)...)
+    }
+    for _, field := range []struct {
+        name   string
+        values []string
+    }{
+        {"code", code},
+        {"code_lang", doc.codeLangs},
+        {"callout_type", doc.calloutTypes},
+    } {
+        for _, value := range field.values {
+            if err := d.AddField(value, e.context, field.name); err != nil {
+                return fmt.Errorf("failed to add %s field: %w", field.name, err)
+            }
+        }
     }
     
     // Add document
-    err = ti.context.AddAndConsumeDocuments(doc)
-    if err != nil {
+    if err := e.context.AddAndConsumeDocuments(d); err != nil {
         return fmt.Errorf("failed to add document: %w", err)
     }
-    
-    // Update timestamp
-    ti.lastIndexed[path] = info.ModTime()
-    
     return nil
 }
 
-func (ti *TantivyIndex) Search(query string, limit int) ([]SearchResult, error) {
-    ti.mu.RLock()
-    defer ti.mu.RUnlock()
-    
+func (e *tantivyEngine) remove(path string) error {
+    return e.context.DeleteDocuments("path", path)
+}
+
+func (e *tantivyEngine) search(q query.Node, boosts map[string]float32, limit int) ([]scoredResult, error) {
     // Build search context
-    searchCtx := tantivy.NewSearchContextBuilder().
-        SetQuery(query).
+    builder := tantivy.NewSearchContextBuilder().
+        SetQuery(query.Tantivy(foldQuery(q))).
         SetDocsLimit(uintptr(limit)).
-        SetWithHighlights(true).
-        AddFieldDefaultWeight("content").
-        AddFieldDefaultWeight("title").
-        Build()
+        SetWithHighlights(true)
+    // The query is analyzed by the stemmer of every configured language
+    for _, field := range BoostFields {
+        boost, ok := boosts[field]
+        if ok && boost == 0 {
+            continue
+        }
+        for _, name := range searchFields(field, e.languages) {
+            if ok {
+                builder.AddField(name, boost)
+            } else {
+                builder.AddFieldDefaultWeight(name)
+            }
+        }
+    }
+    searchCtx := builder.Build()
     
     // Search
-    searchResult, err := ti.context.Search(searchCtx)
+    searchResult, err := e.context.Search(searchCtx)
     if err != nil {
         return nil, fmt.Errorf("search failed: %w", err)
     }
     defer searchResult.Free()
     
-    results := make([]SearchResult, 0)
-    
     size, err := searchResult.GetSize()
     if err != nil {
         return nil, fmt.Errorf("failed to get result size: %w", err)
     }
     
+    var hits []scoredResult
     for i := uint64(0); i < size; i++ {
         doc, err := searchResult.Get(i)
         if err != nil {
             continue
         }
         
-        // Get JSON representation
         // Get fields manually since GetSchema is not available
-        jsonStr, err := doc.ToJson(ti.context, "path", "title")
+        jsonStr, err := doc.ToJson(e.context, "path", "lang", "modified", "created")
+        doc.Free()
         if err != nil {
-            doc.Free()
             continue
         }
         
-        // Parse path from JSON (simple extraction)
-        pathStart := strings.Index(jsonStr, `"path":"`) + 8
-        pathEnd := strings.Index(jsonStr[pathStart:], `"`)
-        path := jsonStr[pathStart : pathStart+pathEnd]
-        
-        // Get snippet from highlights
-        snippet := ""
-        lineNumbers := []int{}
-        
-        // For now, create a simple snippet
-        if content, err := os.ReadFile(path); err == nil {
-            snippet, lineNumbers = ti.createSnippet(string(content), query, 150)
+        var stored struct {
+            Path     string  `json:"path"`
+            Lang     string  `json:"lang"`
+            Modified string  `json:"modified"`
+            Created  string  `json:"created"`
+            Score    float64 `json:"score"`
+        }
+        if err := json.Unmarshal([]byte(jsonStr), &stored); err != nil {
+            continue
         }
         
-        results = append(results, SearchResult{
-            FilePath:    path,
-            Snippet:     snippet,
-            Score:       1.0, // tantivy-go doesn't expose scores directly
-            LineNumbers: lineNumbers,
+        hits = append(hits, scoredResult{
+            path:     stored.Path,
+            lang:     stored.Lang,
+            score:    stored.Score,
+            modified: parseUnix(stored.Modified),
+            created:  parseUnix(stored.Created),
         })
-        
-        doc.Free()
     }
-    
-    return results, nil
+    return hits, nil
 }
 
-func (ti *TantivyIndex) createSnippet(content, query string, maxLength int) (string, []int) {
-    lines := strings.Split(content, "\n")
-    queryLower := strings.ToLower(query)
-    var matchedLines []int
-    var snippetParts []string
-    
-    for i, line := range lines {
-        if strings.Contains(strings.ToLower(line), queryLower) {
-            matchedLines = append(matchedLines, i+1)
-            if len(snippetParts) < 3 { // Max 3 lines in snippet
-                snippetParts = append(snippetParts, fmt.Sprintf("L%d: %s", i+1, line))
-            }
-        }
-    }
-    
-    snippet := strings.Join(snippetParts, "\n")
-    if len(snippet) > maxLength {
-        snippet = snippet[:maxLength] + "..."
+// parseUnix parses a stored Unix time, returning the zero time for
+// documents indexed without it.
+func parseUnix(value string) time.Time {
+    seconds, err := strconv.ParseInt(value, 10, 64)
+    if err != nil {
+        return time.Time{}
     }
-    
-    return snippet, matchedLines
+    return time.Unix(seconds, 0)
 }
 
-func (ti *TantivyIndex) loadIndexTimestamps() {
-    timestampFile := filepath.Join(ti.indexPath, ".timestamps")
-    data, err := os.ReadFile(timestampFile)
+func (e *tantivyEngine) complete(prefix string, limit int) ([]nameMatch, error) {
+    // The analyzer turns the prefix into the same edge n-grams as the names
+    qb := tantivy.NewQueryBuilder()
+    qb.Query(tantivy.Must, "names", prefix, tantivy.EveryTermQuery, 1.0)
+    query := qb.Build()
+    
+    searchCtx := tantivy.NewSearchContextBuilder().
+        SetQueryFromJson(&query).
+        SetDocsLimit(uintptr(limit)).
+        Build()
+    
+    searchResult, err := e.context.SearchJson(searchCtx)
     if err != nil {
-        return
+        return nil, fmt.Errorf("completion failed: %w", err)
     }
+    defer searchResult.Free()
     
-    lines := strings.Split(string(data), "\n")
-    for _, line := range lines {
-        parts := strings.Split(line, "|")
-        if len(parts) == 2 {
-            timestamp, _ := time.Parse(time.RFC3339, parts[1])
-            ti.lastIndexed[parts[0]] = timestamp
-        }
+    size, err := searchResult.GetSize()
+    if err != nil {
+        return nil, fmt.Errorf("failed to get result size: %w", err)
     }
-}
-
-func (ti *TantivyIndex) saveIndexTimestamps() {
-    timestampFile := filepath.Join(ti.indexPath, ".timestamps")
-    var lines []string
     
-    for path, timestamp := range ti.lastIndexed {
-        lines = append(lines, fmt.Sprintf("%s|%s", path, timestamp.Format(time.RFC3339)))
+    var matches []nameMatch
+    for i := uint64(0); i < size; i++ {
+        doc, err := searchResult.Get(i)
+        if err != nil {
+            continue
+        }
+        
+        jsonStr, err := doc.ToJson(e.context, "path", "names")
+        doc.Free()
+        if err != nil {
+            continue
+        }
+        
+        var stored struct {
+            Path  string `json:"path"`
+            Names string `json:"names"`
+        }
+        if err := json.Unmarshal([]byte(jsonStr), &stored); err != nil {
+            continue
+        }
+        matches = append(matches, nameMatch{path: stored.Path, names: strings.Split(stored.Names, "\n")})
     }
-    
-    os.WriteFile(timestampFile, []byte(strings.Join(lines, "\n")), 0644)
+    return matches, nil
 }
 
-func (ti *TantivyIndex) UpdateFile(path string) error {
-    ti.mu.Lock()
-    defer ti.mu.Unlock()
-    
-    info, err := os.Stat(path)
-    if err != nil {
-        return err
-    }
-    
-    return ti.indexFile(path, info)
+func (e *tantivyEngine) numDocs() (uint64, error) {
+    return e.context.NumDocs()
 }
 
-func (ti *TantivyIndex) RemoveFile(path string) error {
-    ti.mu.Lock()
-    defer ti.mu.Unlock()
-    
-    err := ti.context.DeleteDocuments("path", path)
-    if err != nil {
-        return err
-    }
-    
-    delete(ti.lastIndexed, path)
-    
+// flush does nothing, every document is committed as it is added.
+func (e *tantivyEngine) flush() error {
     return nil
 }
 
-func (ti *TantivyIndex) Close() error {
-    ti.saveIndexTimestamps()
-    ti.context.Free()
+func (e *tantivyEngine) close() error {
+    e.context.Free()
     return nil
 }
 
-func (ti *TantivyIndex) GetIndexedFilesCount() int {
-    ti.mu.RLock()
-    defer ti.mu.RUnlock()
-    return len(ti.lastIndexed)
+// foldQuery returns a copy of node with its words folded like the index
+// folds code. The language analyzers fold words themselves, but the code
+// field is matched by the raw tokenizer.
+func foldQuery(node query.Node) query.Node {
+    switch n := node.(type) {
+    case *query.Term:
+        return &query.Term{Text: analysis.Fold(n.Text), Boost: n.Boost}
+    case *query.Phrase:
+        words := make([]string, len(n.Words))
+        for i, word := range n.Words {
+            words[i] = analysis.Fold(word)
+        }
+        return &query.Phrase{Words: words, Slop: n.Slop}
+    case *query.Bool:
+        folded := &query.Bool{Clauses: make([]query.Clause, len(n.Clauses))}
+        for i, c := range n.Clauses {
+            folded.Clauses[i] = query.Clause{Occur: c.Occur, Node: foldQuery(c.Node)}
+        }
+        return folded
+    }
+    return node
 }
\ No newline at end of file
@@ -0,0 +1,13 @@
+//go:build purego
+
+package index
+
+import "fmt"
+
+// tantivyAvailable is false in pure-Go builds, which only have the BM25
+// backend.
+const tantivyAvailable = false
+
+func newTantivyEngine(indexPath string, languages []string) (engine, error) {
+    return nil, fmt.Errorf("this binary was built without the tantivy backend")
+}
\ No newline at end of file
@@ -0,0 +1,219 @@
+package index
+
+import (
+    "encoding/json"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+)
+
+// taskList holds the tasks of every indexed note. It is stored next to the
+// index as .tasks.
+type taskList struct {
+    mu    sync.RWMutex
+    files map[string][]markdown.Task
+}
+
+func newTaskList() *taskList {
+    return &taskList{files: make(map[string][]markdown.Task)}
+}
+
+// put replaces the tasks recorded for path.
+func (l *taskList) put(path string, tasks []markdown.Task) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if len(tasks) == 0 {
+        delete(l.files, path)
+        return
+    }
+    l.files[path] = tasks
+}
+
+func (l *taskList) remove(path string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    delete(l.files, path)
+}
+
+func (l *taskList) load(path string) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return
+    }
+    
+    var files map[string][]markdown.Task
+    if err := json.Unmarshal(data, &files); err != nil {
+        return
+    }
+    
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.files = files
+}
+
+func (l *taskList) save(path string) error {
+    l.mu.RLock()
+    data, err := json.Marshal(l.files)
+    l.mu.RUnlock()
+    if err != nil {
+        return err
+    }
+    
+    // Write and rename so a crash never leaves a truncated list
+    tmp := path + ".tmp"
+    if err := os.WriteFile(tmp, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, path)
+}
+
+// DateRange is an inclusive range of days. A zero From or To leaves that
+// end open.
+type DateRange struct {
+    From time.Time
+    To   time.Time
+}
+
+// IsZero reports whether the range is open at both ends and so matches
+// everything.
+func (r DateRange) IsZero() bool {
+    return r.From.IsZero() && r.To.IsZero()
+}
+
+// Contains reports whether the day of t lies in r. The zero time, a date
+// that is not set, only lies in the zero range.
+func (r DateRange) Contains(t time.Time) bool {
+    if r.IsZero() {
+        return true
+    }
+    if t.IsZero() {
+        return false
+    }
+    day := t.Format("2006-01-02")
+    if !r.From.IsZero() && day < r.From.Format("2006-01-02") {
+        return false
+    }
+    if !r.To.IsZero() && day > r.To.Format("2006-01-02") {
+        return false
+    }
+    return true
+}
+
+// Overlaps reports whether a day from start to end lies in r.
+func (r DateRange) Overlaps(start, end time.Time) bool {
+    if !r.From.IsZero() && end.Format("2006-01-02") < r.From.Format("2006-01-02") {
+        return false
+    }
+    if !r.To.IsZero() && start.Format("2006-01-02") > r.To.Format("2006-01-02") {
+        return false
+    }
+    return true
+}
+
+// TaskQuery selects tasks. Fields left empty don't filter: Statuses lists
+// the accepted statuses, Tags the tags a task must all have (a tag also
+// matches its nested tags), and Folder the vault-relative folder the note
+// must be in.
+type TaskQuery struct {
+    Statuses  []string
+    Due       DateRange
+    Scheduled DateRange
+    Done      DateRange
+    Tags      []string
+    Folder    string
+}
+
+// TaskMatch is a task together with the note it is in.
+type TaskMatch struct {
+    Vault    string `json:"vault,omitempty"`
+    Path     string `json:"path"`
+    FilePath string `json:"file_path"`
+    markdown.Task
+}
+
+// Tasks returns the tasks matching q, ordered by due date with undated
+// tasks last, then by note and line.
+func (ix *Index) Tasks(q TaskQuery) []TaskMatch {
+    folder := strings.Trim(filepath.ToSlash(q.Folder), "/")
+    
+    ix.tasks.mu.RLock()
+    var matches []TaskMatch
+    for file, tasks := range ix.tasks.files {
+        path := ix.vaultPath(file)
+        if folder != "" && !strings.HasPrefix(path, folder+"/") {
+            continue
+        }
+        for _, task := range tasks {
+            if q.matches(task) {
+                matches = append(matches, TaskMatch{Path: path, FilePath: file, Task: task})
+            }
+        }
+    }
+    ix.tasks.mu.RUnlock()
+    
+    SortTasks(matches)
+    return matches
+}
+
+func (q TaskQuery) matches(task markdown.Task) bool {
+    if len(q.Statuses) > 0 && !contains(q.Statuses, task.Status) {
+        return false
+    }
+    if !q.Due.Contains(task.Due) || !q.Scheduled.Contains(task.Scheduled) || !q.Done.Contains(task.Done) {
+        return false
+    }
+    for _, want := range q.Tags {
+        want = strings.ToLower(strings.Trim(strings.TrimPrefix(want, "#"), "/"))
+        found := false
+        for _, tag := range task.Tags {
+            tag = strings.ToLower(tag)
+            if tag == want || strings.HasPrefix(tag, want+"/") {
+                found = true
+                break
+            }
+        }
+        if !found {
+            return false
+        }
+    }
+    return true
+}
+
+// SortTasks orders tasks by due date with undated tasks last, then by note
+// and line.
+func SortTasks(tasks []TaskMatch) {
+    sort.SliceStable(tasks, func(i, j int) bool {
+        a, b := tasks[i], tasks[j]
+        if !a.Due.Equal(b.Due) {
+            if a.Due.IsZero() || b.Due.IsZero() {
+                return b.Due.IsZero()
+            }
+            return a.Due.Before(b.Due)
+        }
+        if a.Path != b.Path {
+            return a.Path < b.Path
+        }
+        return a.Line < b.Line
+    })
+}
+
+func contains(list []string, value string) bool {
+    for _, item := range list {
+        if item == value {
+            return true
+        }
+    }
+    return false
+}
+
+func (ix *Index) saveTasks() {
+    if err := ix.tasks.save(filepath.Join(ix.indexPath, ".tasks")); err != nil {
+        slog.Error("Failed to save tasks", "err", err)
+    }
+}
\ No newline at end of file
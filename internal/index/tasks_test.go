@@ -0,0 +1,110 @@
+package index
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+)
+
+func TestDateRange(t *testing.T) {
+    day := func(s string) time.Time {
+        d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+        return d
+    }
+    r := DateRange{From: day("2025-06-01"), To: day("2025-06-07")}
+    
+    for date, want := range map[string]bool{"2025-05-31": false, "2025-06-01": true, "2025-06-07": true, "2025-06-08": false} {
+        if got := r.Contains(day(date)); got != want {
+            t.Errorf("Contains(%s) = %v, want %v", date, got, want)
+        }
+    }
+    if r.Contains(time.Time{}) {
+        t.Error("Expected an unset date to be outside the range")
+    }
+    if !(DateRange{}).Contains(time.Time{}) {
+        t.Error("Expected the zero range to match unset dates")
+    }
+    if !(DateRange{From: day("2025-06-01")}).Contains(day("2030-01-01")) {
+        t.Error("Expected a range without end to be open")
+    }
+    if !r.Overlaps(day("2025-05-26"), day("2025-06-01")) || r.Overlaps(day("2025-06-08"), day("2025-06-14")) {
+        t.Error("Expected periods to overlap the range by a day")
+    }
+}
+
+func TestTasks(t *testing.T) {
+    vaultPath := filepath.Join(t.TempDir(), "vault")
+    os.MkdirAll(filepath.Join(vaultPath, "Projects"), 0755)
+    os.WriteFile(filepath.Join(vaultPath, "Projects", "launch.md"), []byte("---\ntags: [work]\n---\n# Launch\n"+
+        "- [ ] Book venue #event 📅 2025-06-03\n"+
+        "- [x] Send invites #event ✅ 2025-05-20\n"+
+        "- [ ] Print flyers #event/print 📅 2025-06-20\n"), 0644)
+    os.WriteFile(filepath.Join(vaultPath, "Home.md"), []byte("- [/] Water plants ⏳ 2025-06-02\n- [ ] Call mum\n"), 0644)
+    
+    opts := DefaultOptions()
+    opts.Backend = BackendBM25
+    indexPath := filepath.Join(t.TempDir(), "index")
+    index, err := Open(indexPath, opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    if err := index.IndexDirectory(vaultPath, 2); err != nil {
+        t.Fatalf("Failed to index directory: %v", err)
+    }
+    
+    texts := func(q TaskQuery) []string {
+        var out []string
+        for _, task := range index.Tasks(q) {
+            out = append(out, task.Text)
+        }
+        return out
+    }
+    day := func(s string) time.Time {
+        d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+        return d
+    }
+    
+    open := []string{markdown.TaskOpen, markdown.TaskInProgress}
+    if got := texts(TaskQuery{Statuses: open}); len(got) != 4 || got[0] != "Book venue #event" || got[1] != "Print flyers #event/print" {
+        t.Errorf("Expected open tasks by due date, undated last, got %q", got)
+    }
+    if got := texts(TaskQuery{Statuses: open, Due: DateRange{To: day("2025-06-07")}}); len(got) != 1 || got[0] != "Book venue #event" {
+        t.Errorf("Expected the task due this week, got %q", got)
+    }
+    if got := texts(TaskQuery{Scheduled: DateRange{From: day("2025-06-02"), To: day("2025-06-02")}}); len(got) != 1 || got[0] != "Water plants" {
+        t.Errorf("Expected the scheduled task, got %q", got)
+    }
+    if got := texts(TaskQuery{Tags: []string{"#event"}, Statuses: []string{markdown.TaskOpen}}); len(got) != 2 {
+        t.Errorf("Expected the open event tasks including nested tags, got %q", got)
+    }
+    if got := texts(TaskQuery{Tags: []string{"event/print"}}); len(got) != 1 {
+        t.Errorf("Expected only the nested tag, got %q", got)
+    }
+    if got := texts(TaskQuery{Folder: "Projects/", Statuses: []string{markdown.TaskDone}}); len(got) != 1 || got[0] != "Send invites #event" {
+        t.Errorf("Expected the done task in Projects, got %q", got)
+    }
+    
+    tasks := index.Tasks(TaskQuery{Folder: "Projects"})
+    if len(tasks) != 3 || tasks[0].Path != "Projects/launch.md" || tasks[0].Line != 5 {
+        t.Errorf("Expected vault paths and file line numbers, got %+v", tasks)
+    }
+    
+    // Tasks survive a restart and leave with their note
+    index.Close()
+    index, err = Open(indexPath, opts)
+    if err != nil {
+        t.Fatalf("Failed to reopen index: %v", err)
+    }
+    defer index.Close()
+    if got := texts(TaskQuery{}); len(got) != 5 {
+        t.Errorf("Expected 5 tasks after reopening, got %q", got)
+    }
+    os.Remove(filepath.Join(vaultPath, "Home.md"))
+    index.IndexDirectory(vaultPath, 1)
+    if got := texts(TaskQuery{}); len(got) != 3 {
+        t.Errorf("Expected the tasks of the deleted note to be gone, got %q", got)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,138 @@
+package index
+
+import (
+    "encoding/json"
+    "os"
+    "sort"
+    "sync"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+)
+
+// maxTermLength keeps hashes, URLs and base64 blobs out of the vocabulary.
+const maxTermLength = 40
+
+// termDict is the vocabulary of the index: every folded, unstemmed word and
+// the number of notes it occurs in. tantivy-go does not expose its term
+// dictionary, so the index keeps its own for fuzzy matching and "did you
+// mean" suggestions. It is stored next to the index as .terms.
+type termDict struct {
+    mu       sync.RWMutex
+    docFreq  map[string]int
+    docTerms map[string][]string
+}
+
+func newTermDict() *termDict {
+    return &termDict{
+        docFreq:  make(map[string]int),
+        docTerms: make(map[string][]string),
+    }
+}
+
+// add records the distinct words of text for path, replacing what was
+// recorded for it before.
+func (d *termDict) add(path, text string) {
+    seen := make(map[string]bool)
+    var terms []string
+    for _, term := range analysis.Tokenize(text) {
+        if len(term) <= maxTermLength && !seen[term] {
+            seen[term] = true
+            terms = append(terms, term)
+        }
+    }
+    sort.Strings(terms)
+    
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.removeLocked(path)
+    d.docTerms[path] = terms
+    for _, term := range terms {
+        d.docFreq[term]++
+    }
+}
+
+func (d *termDict) remove(path string) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.removeLocked(path)
+}
+
+func (d *termDict) removeLocked(path string) {
+    for _, term := range d.docTerms[path] {
+        if d.docFreq[term]--; d.docFreq[term] <= 0 {
+            delete(d.docFreq, term)
+        }
+    }
+    delete(d.docTerms, path)
+}
+
+// freq returns the number of notes containing term.
+func (d *termDict) freq(term string) int {
+    d.mu.RLock()
+    defer d.mu.RUnlock()
+    return d.docFreq[term]
+}
+
+// each calls fn for every term in the dictionary.
+func (d *termDict) each(fn func(term string, freq int)) {
+    d.mu.RLock()
+    defer d.mu.RUnlock()
+    for term, freq := range d.docFreq {
+        fn(term, freq)
+    }
+}
+
+// docCount returns the number of notes in the dictionary.
+func (d *termDict) docCount() int {
+    d.mu.RLock()
+    defer d.mu.RUnlock()
+    return len(d.docTerms)
+}
+
+// eachDoc calls fn for every note with its sorted, distinct words. fn must
+// not modify terms.
+func (d *termDict) eachDoc(fn func(path string, terms []string)) {
+    d.mu.RLock()
+    defer d.mu.RUnlock()
+    for path, terms := range d.docTerms {
+        fn(path, terms)
+    }
+}
+
+func (d *termDict) load(path string) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return
+    }
+    
+    var docTerms map[string][]string
+    if err := json.Unmarshal(data, &docTerms); err != nil {
+        return
+    }
+    
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.docTerms = docTerms
+    d.docFreq = make(map[string]int)
+    for _, terms := range docTerms {
+        for _, term := range terms {
+            d.docFreq[term]++
+        }
+    }
+}
+
+func (d *termDict) save(path string) error {
+    d.mu.RLock()
+    data, err := json.Marshal(d.docTerms)
+    d.mu.RUnlock()
+    if err != nil {
+        return err
+    }
+    
+    // Write and rename so a crash never leaves a truncated dictionary
+    tmp := path + ".tmp"
+    if err := os.WriteFile(tmp, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, path)
+}
\ No newline at end of file
@@ -0,0 +1,380 @@
+package index
+
+import (
+    "bytes"
+    "context"
+    "encoding/gob"
+    "fmt"
+    "log/slog"
+    "math"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/embed"
+)
+
+// DefaultChunkSize is the number of characters a chunk grows to before a
+// new one is started at the next paragraph.
+const DefaultChunkSize = 1000
+
+// chunk is a passage of a note with its normalized embedding.
+type chunk struct {
+    Line   int // first line, counting from 1
+    Lines  int
+    Vector []float32
+}
+
+type vectorFile struct {
+    ModTime time.Time
+    Chunks  []chunk
+}
+
+// vectorStore is the semantic index: the embedded chunks of every note,
+// stored next to the Tantivy index as .vectors. A note is embedded again
+// when its modification time differs from the registry's, so the store
+// follows the same change detection as IndexDirectory.
+type vectorStore struct {
+    mu    sync.RWMutex
+    Model string
+    Files map[string]vectorFile
+}
+
+func newVectorStore(model string) *vectorStore {
+    return &vectorStore{Model: model, Files: make(map[string]vectorFile)}
+}
+
+// loadVectorStore reads the store at path. A missing or unreadable store,
+// or one built with another model, starts empty.
+func loadVectorStore(path, model string) *vectorStore {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return newVectorStore(model)
+    }
+    s := newVectorStore(model)
+    if err := gob.NewDecoder(bytes.NewReader(data)).Decode(s); err != nil || s.Model != model {
+        return newVectorStore(model)
+    }
+    return s
+}
+
+func (s *vectorStore) save(path string) error {
+    s.mu.RLock()
+    var buf bytes.Buffer
+    err := gob.NewEncoder(&buf).Encode(s)
+    s.mu.RUnlock()
+    if err != nil {
+        return err
+    }
+    
+    tmp := path + ".tmp"
+    if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, path)
+}
+
+func (s *vectorStore) modTime(path string) (time.Time, bool) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    f, ok := s.Files[path]
+    return f.ModTime, ok
+}
+
+func (s *vectorStore) put(path string, f vectorFile) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.Files[path] = f
+}
+
+func (s *vectorStore) remove(path string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.Files, path)
+}
+
+// paths returns the notes in the store.
+func (s *vectorStore) paths() []string {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    paths := make([]string, 0, len(s.Files))
+    for path := range s.Files {
+        paths = append(paths, path)
+    }
+    return paths
+}
+
+// vectorHit is the best matching chunk of a note.
+type vectorHit struct {
+    path  string
+    chunk chunk
+    score float64
+}
+
+// search returns the limit notes whose best chunk is closest to vector,
+// best first.
+func (s *vectorStore) search(vector []float32, limit int) []vectorHit {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    
+    var hits []vectorHit
+    for path, f := range s.Files {
+        best := vectorHit{path: path, score: math.Inf(-1)}
+        for _, c := range f.Chunks {
+            if score := dot(vector, c.Vector); score > best.score {
+                best.chunk, best.score = c, score
+            }
+        }
+        if len(f.Chunks) > 0 {
+            hits = append(hits, best)
+        }
+    }
+    
+    sort.Slice(hits, func(i, j int) bool {
+        if hits[i].score != hits[j].score {
+            return hits[i].score > hits[j].score
+        }
+        return hits[i].path < hits[j].path
+    })
+    if len(hits) > limit {
+        hits = hits[:limit]
+    }
+    return hits
+}
+
+func dot(a, b []float32) float64 {
+    if len(a) != len(b) {
+        return math.Inf(-1)
+    }
+    sum := 0.0
+    for i := range a {
+        sum += float64(a[i]) * float64(b[i])
+    }
+    return sum
+}
+
+// normalize scales vector to unit length, so that the dot product of two
+// vectors is their cosine similarity.
+func normalize(vector []float32) []float32 {
+    sum := 0.0
+    for _, v := range vector {
+        sum += float64(v) * float64(v)
+    }
+    if sum == 0 {
+        return vector
+    }
+    norm := math.Sqrt(sum)
+    out := make([]float32, len(vector))
+    for i, v := range vector {
+        out[i] = float32(float64(v) / norm)
+    }
+    return out
+}
+
+// passage is a chunk of a note before it is embedded.
+type passage struct {
+    line  int
+    lines int
+    text  string
+}
+
+// chunkNote splits the body of a note, which starts at line offset+1 of
+// the file, into passages of about size characters. Passages end at blank
+// lines and before headings where possible, and a single long paragraph is
+// split at line ends.
+func chunkNote(body string, offset, size int) []passage {
+    var passages []passage
+    var current []string
+    start, length := 0, 0
+    
+    flush := func() {
+        text := strings.TrimSpace(strings.Join(current, "\n"))
+        if text != "" {
+            passages = append(passages, passage{line: start + 1, lines: len(current), text: text})
+        }
+        current, length = nil, 0
+    }
+    
+    lines := strings.Split(body, "\n")
+    for i, line := range lines {
+        trimmed := strings.TrimSpace(line)
+        boundary := trimmed == "" || strings.HasPrefix(trimmed, "#")
+        if length > 0 && (length+len(line) > size || boundary && length >= size/2) {
+            flush()
+        }
+        if len(current) == 0 {
+            if trimmed == "" {
+                continue
+            }
+            start = offset + i
+        }
+        current = append(current, line)
+        length += len(line) + 1
+    }
+    flush()
+    return passages
+}
+
+// SetEmbedder enables semantic search with e, splitting notes into chunks
+// of about chunkSize characters. Vectors stored for another model are
+// discarded. Call it before IndexDirectory.
+func (ix *Index) SetEmbedder(e embed.Embedder, chunkSize int) {
+    if chunkSize < 1 {
+        chunkSize = DefaultChunkSize
+    }
+    store := loadVectorStore(filepath.Join(ix.indexPath, ".vectors"), e.Model())
+    
+    ix.vecMu.Lock()
+    defer ix.vecMu.Unlock()
+    ix.embedder = e
+    ix.chunkSize = chunkSize
+    ix.vectors = store
+}
+
+// semantic returns the embedder and vector store, or nil if semantic search
+// is off.
+func (ix *Index) semantic() (embed.Embedder, *vectorStore) {
+    ix.vecMu.Lock()
+    defer ix.vecMu.Unlock()
+    return ix.embedder, ix.vectors
+}
+
+// embedFile embeds the chunks of the note at path.
+func (ix *Index) embedFile(ctx context.Context, e embed.Embedder, path string, modTime time.Time) (vectorFile, error) {
+    _, body, offset, err := ix.readText(path)
+    if err != nil {
+        return vectorFile{}, err
+    }
+    title := noteTitle(path, body)
+    
+    passages := chunkNote(body, offset, ix.chunkSize)
+    texts := make([]string, len(passages))
+    for i, p := range passages {
+        // The title gives every chunk the context of its note
+        texts[i] = title + "\n" + p.text
+    }
+    
+    f := vectorFile{ModTime: modTime}
+    if len(texts) == 0 {
+        return f, nil
+    }
+    vectors, err := e.Embed(ctx, texts)
+    if err != nil {
+        return vectorFile{}, err
+    }
+    for i, p := range passages {
+        f.Chunks = append(f.Chunks, chunk{Line: p.line, Lines: p.lines, Vector: normalize(vectors[i])})
+    }
+    return f, nil
+}
+
+// syncVectors embeds the notes that changed since they were last embedded
+// and drops those no longer indexed. It stops at the first failure, so an
+// unreachable provider fails once; the remaining notes are embedded by the
+// next sync.
+func (ix *Index) syncVectors() error {
+    e, store := ix.semantic()
+    if e == nil {
+        return nil
+    }
+    
+    indexed := ix.IndexedFiles()
+    for _, path := range store.paths() {
+        if _, ok := indexed[path]; !ok {
+            store.remove(path)
+        }
+    }
+    
+    var err error
+    embedded := 0
+    for path, modTime := range indexed {
+        if stored, ok := store.modTime(path); ok && stored.Equal(modTime) {
+            continue
+        }
+        var f vectorFile
+        if f, err = ix.embedFile(context.Background(), e, path, modTime); err != nil {
+            err = fmt.Errorf("failed to embed %s: %w", ix.vaultPath(path), err)
+            break
+        }
+        store.put(path, f)
+        embedded++
+    }
+    if embedded > 0 {
+        slog.Info("Embedded notes", "notes", embedded, "model", e.Model())
+    }
+    
+    if saveErr := store.save(filepath.Join(ix.indexPath, ".vectors")); saveErr != nil && err == nil {
+        err = fmt.Errorf("failed to save vectors: %w", saveErr)
+    }
+    return err
+}
+
+// updateVectors embeds a single changed note.
+func (ix *Index) updateVectors(path string) {
+    e, store := ix.semantic()
+    if e == nil {
+        return
+    }
+    modTime, ok := ix.indexedAt(path)
+    if !ok {
+        store.remove(path)
+        return
+    }
+    f, err := ix.embedFile(context.Background(), e, path, modTime)
+    if err != nil {
+        slog.Warn("Failed to embed note", "path", ix.vaultPath(path), "err", err)
+        return
+    }
+    store.put(path, f)
+}
+
+// rrfK dampens the weight of top ranks in reciprocal rank fusion; 60 is
+// the constant of the original paper and works well without tuning.
+const rrfK = 60
+
+// fuseRanks merges rankings of paths by reciprocal rank fusion: a path
+// scores the sum of 1/(rrfK + rank) over the rankings it appears in, rank
+// counting from 1. The result is ordered best first; ties keep the order of
+// first appearance.
+func fuseRanks(rankings ...[]string) ([]string, map[string]float64) {
+    scores := make(map[string]float64)
+    var order []string
+    for _, ranking := range rankings {
+        for i, path := range ranking {
+            if _, ok := scores[path]; !ok {
+                order = append(order, path)
+            }
+            scores[path] += 1 / float64(rrfK+i+1)
+        }
+    }
+    sort.SliceStable(order, func(i, j int) bool {
+        return scores[order[i]] > scores[order[j]]
+    })
+    return order, scores
+}
+
+// chunkSnippet shows the first lines of c, for notes found by meaning
+// rather than by their words.
+func chunkSnippet(content string, c chunk, maxLength int) (string, []int) {
+    lines := strings.Split(content, "\n")
+    var parts []string
+    var numbers []int
+    for n := c.Line; n < c.Line+c.Lines && n <= len(lines); n++ {
+        if strings.TrimSpace(lines[n-1]) == "" {
+            continue
+        }
+        numbers = append(numbers, n)
+        if len(parts) < 3 {
+            parts = append(parts, fmt.Sprintf("L%d: %s", n, lines[n-1]))
+        }
+    }
+    
+    snippet := strings.Join(parts, "\n")
+    if runes := []rune(snippet); len(runes) > maxLength {
+        snippet = string(runes[:maxLength]) + "..."
+    }
+    return snippet, numbers
+}
\ No newline at end of file
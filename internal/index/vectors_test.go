@@ -0,0 +1,197 @@
+package index
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+// wordEmbedder embeds text as the counts of a fixed vocabulary, so that
+// texts sharing words are close. It records the texts it embeds.
+type wordEmbedder struct {
+    vocabulary []string
+    embedded   []string
+}
+
+func (e *wordEmbedder) Model() string {
+    return "test/words"
+}
+
+func (e *wordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+    e.embedded = append(e.embedded, texts...)
+    vectors := make([][]float32, len(texts))
+    for i, text := range texts {
+        vectors[i] = make([]float32, len(e.vocabulary))
+        for j, word := range e.vocabulary {
+            vectors[i][j] = float32(strings.Count(strings.ToLower(text), word))
+        }
+    }
+    return vectors, nil
+}
+
+func TestChunkNote(t *testing.T) {
+    body := "# Title\n\nFirst paragraph\nstill first.\n\nSecond paragraph.\n## Heading\nThird.\n"
+    
+    // Chunks end at the first paragraph or heading once half full
+    got := chunkNote(body, 3, 40)
+    want := []passage{
+        {line: 4, lines: 4, text: "# Title\n\nFirst paragraph\nstill first."},
+        {line: 9, lines: 3, text: "Second paragraph.\n## Heading\nThird."},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("chunkNote() =\n%+v\nwant\n%+v", got, want)
+    }
+    
+    // A paragraph longer than a chunk is split at line ends
+    got = chunkNote("one two three\nfour five six\nseven\n", 0, 20)
+    if len(got) != 2 || got[0].text != "one two three" || got[1].line != 2 {
+        t.Errorf("Expected the paragraph to be split, got %+v", got)
+    }
+    
+    // Large chunks hold the whole note
+    got = chunkNote(body, 0, 1000)
+    if len(got) != 1 || got[0].line != 1 || !strings.HasSuffix(got[0].text, "Third.") {
+        t.Errorf("Expected a single chunk, got %+v", got)
+    }
+}
+
+func TestFuseRanks(t *testing.T) {
+    order, scores := fuseRanks(
+        []string{"a.md", "b.md", "c.md"},
+        []string{"c.md", "d.md", "b.md"},
+    )
+    // b and c are in both rankings, c ranks better on average
+    if want := []string{"c.md", "b.md", "a.md", "d.md"}; !reflect.DeepEqual(order, want) {
+        t.Errorf("fuseRanks() = %v, want %v", order, want)
+    }
+    if want := 1.0/61 + 1.0/63; scores["c.md"] != want {
+        t.Errorf("score of c.md = %v, want %v", scores["c.md"], want)
+    }
+}
+
+func TestVectorStoreSaveLoad(t *testing.T) {
+    path := filepath.Join(t.TempDir(), ".vectors")
+    store := newVectorStore("test/a")
+    store.put("/vault/note.md", vectorFile{
+        ModTime: time.Unix(1700000000, 0),
+        Chunks:  []chunk{{Line: 1, Lines: 2, Vector: []float32{0.6, 0.8}}},
+    })
+    if err := store.save(path); err != nil {
+        t.Fatalf("save failed: %v", err)
+    }
+    
+    loaded := loadVectorStore(path, "test/a")
+    if !reflect.DeepEqual(loaded.Files, store.Files) {
+        t.Errorf("Loaded %+v, want %+v", loaded.Files, store.Files)
+    }
+    if other := loadVectorStore(path, "test/b"); len(other.Files) != 0 {
+        t.Errorf("Expected vectors of another model to be discarded, got %+v", other.Files)
+    }
+}
+
+// semanticIndex returns an index with the registry and vector store of
+// notes, embedded with e, without a Tantivy index.
+func semanticIndex(t *testing.T, e *wordEmbedder, notes map[string]string) (*Index, string) {
+    t.Helper()
+    ix, root := similarIndex(t, notes)
+    ix.indexPath = t.TempDir()
+    ix.SetEmbedder(e, 200)
+    if err := ix.syncVectors(); err != nil {
+        t.Fatalf("syncVectors failed: %v", err)
+    }
+    return ix, root
+}
+
+func TestSyncVectorsIsIncremental(t *testing.T) {
+    e := &wordEmbedder{vocabulary: []string{"tired", "sleep"}}
+    ix, root := semanticIndex(t, e, map[string]string{
+        "a.md": "# A\nTired all day.\n",
+        "b.md": "# B\nNeed more sleep.\n",
+    })
+    if len(e.embedded) != 2 {
+        t.Fatalf("Expected both notes to be embedded, got %q", e.embedded)
+    }
+    if e.embedded[0] != "A\n# A\nTired all day." && e.embedded[1] != "A\n# A\nTired all day." {
+        t.Errorf("Expected chunks to carry the note title, got %q", e.embedded)
+    }
+    
+    e.embedded = nil
+    if err := ix.syncVectors(); err != nil || len(e.embedded) != 0 {
+        t.Errorf("Expected unchanged notes to keep their vectors, embedded %q (%v)", e.embedded, err)
+    }
+    
+    a := filepath.Join(root, "a.md")
+    b := filepath.Join(root, "b.md")
+    ix.lastIndexed[a] = time.Now().Add(time.Minute)
+    delete(ix.lastIndexed, b)
+    if err := ix.syncVectors(); err != nil {
+        t.Fatalf("syncVectors failed: %v", err)
+    }
+    if len(e.embedded) != 1 {
+        t.Errorf("Expected only the changed note to be embedded, got %q", e.embedded)
+    }
+    if paths := ix.vectors.paths(); len(paths) != 1 || paths[0] != a {
+        t.Errorf("Expected the removed note to be dropped, got %v", paths)
+    }
+    
+    // A new index picks up the saved vectors
+    reopened := &Index{indexPath: ix.indexPath}
+    reopened.SetEmbedder(e, 200)
+    if _, ok := reopened.vectors.modTime(a); !ok {
+        t.Error("Expected the vectors to be saved next to the index")
+    }
+}
+
+func TestFuseSemantic(t *testing.T) {
+    e := &wordEmbedder{vocabulary: []string{"exhausted", "work", "burnout", "garden"}}
+    ix, root := semanticIndex(t, e, map[string]string{
+        "burnout.md": "# Burnout\nSigns of burnout.\n",
+        "job.md":     "# Job\nExhausted at work again, work never ends.\n",
+        "garden.md":  "# Garden\nThe garden in spring.\n",
+    })
+    path := func(name string) string { return filepath.Join(root, name) }
+    
+    // The keyword search only found the note that uses the word
+    hits := []scoredResult{{path: path("burnout.md"), score: 3}}
+    fused, chunks, err := ix.fuseSemantic("exhausted at work", hits, 10)
+    if err != nil {
+        t.Fatalf("fuseSemantic failed: %v", err)
+    }
+    
+    var order []string
+    for _, hit := range fused {
+        order = append(order, filepath.Base(hit.path))
+    }
+    if len(order) < 2 || order[0] != "burnout.md" && order[0] != "job.md" {
+        t.Fatalf("Unexpected order %v", order)
+    }
+    found := false
+    for _, hit := range fused {
+        if hit.path == path("job.md") {
+            found = true
+            if hit.modified.IsZero() {
+                t.Error("Expected notes found by meaning to carry their modification time")
+            }
+        }
+    }
+    if !found {
+        t.Errorf("Expected the note found by meaning, got %v", order)
+    }
+    
+    content, _ := os.ReadFile(path("job.md"))
+    snippet, lines := chunkSnippet(string(content), chunks[path("job.md")], 150)
+    if !strings.Contains(snippet, "L2: Exhausted at work") || !reflect.DeepEqual(lines, []int{1, 2}) {
+        t.Errorf("Unexpected chunk snippet %q %v", snippet, lines)
+    }
+}
+
+func TestFuseSemanticWithoutEmbedder(t *testing.T) {
+    ix := &Index{}
+    if _, _, err := ix.fuseSemantic("query", nil, 10); err == nil || !strings.Contains(err.Error(), "not configured") {
+        t.Errorf("Expected an error without an embedder, got %v", err)
+    }
+}
\ No newline at end of file
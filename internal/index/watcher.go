@@ -2,24 +2,60 @@ package index
 
 import (
     "fmt"
+    "log/slog"
     "os"
     "path/filepath"
-    "strings"
     "sync"
     "time"
     
     "github.com/fsnotify/fsnotify"
 )
 
+// Watch modes understood by NewWatcher.
+const (
+    WatchModeAuto     = "auto"
+    WatchModeFSNotify = "fsnotify"
+    WatchModePoll     = "poll"
+)
+
+// Watcher keeps the index in sync with changes to the vault.
+type Watcher interface {
+    Start()
+    Stop() error
+}
+
+// NewWatcher creates the watcher for the given mode. In auto mode fsnotify is
+// used until it is found to miss changes, after which polling takes over.
+func NewWatcher(index *Index, rootPath, mode string, pollInterval time.Duration) (Watcher, error) {
+    switch mode {
+    case WatchModeFSNotify:
+        return NewFileWatcher(index, rootPath)
+    case WatchModePoll:
+        return NewPollingWatcher(index, rootPath, pollInterval), nil
+    case WatchModeAuto, "":
+        return NewAutoWatcher(index, rootPath, pollInterval), nil
+    default:
+        return nil, fmt.Errorf("unknown watch mode %q", mode)
+    }
+}
+
+// Watch creates the watcher that keeps ix in sync with rootPath, see
+// NewWatcher.
+func (ix *Index) Watch(rootPath, mode string, pollInterval time.Duration) (Watcher, error) {
+    return NewWatcher(ix, rootPath, mode, pollInterval)
+}
+
+// FileWatcher watches the vault using fsnotify events.
 type FileWatcher struct {
     watcher  *fsnotify.Watcher
-    index    *TantivyIndex
+    index    *Index
     rootPath string
     mu       sync.Mutex
     events   map[string]time.Time
+    observed map[string]time.Time
 }
 
-func NewFileWatcher(index *TantivyIndex, rootPath string) (*FileWatcher, error) {
+func NewFileWatcher(index *Index, rootPath string) (*FileWatcher, error) {
     watcher, err := fsnotify.NewWatcher()
     if err != nil {
         return nil, err
@@ -30,14 +66,19 @@ func NewFileWatcher(index *TantivyIndex, rootPath string) (*FileWatcher, error)
         index:    index,
         rootPath: rootPath,
         events:   make(map[string]time.Time),
+        observed: make(map[string]time.Time),
     }
     
     // Rekursiv alle Directories hinzufügen
+    matcher := index.ignoreMatcher()
     err = filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
         if err != nil {
             return err
         }
-        if info.IsDir() && !strings.Contains(path, ".git") {
+        if info.IsDir() {
+            if matcher.Match(path, true) {
+                return filepath.SkipDir
+            }
             return watcher.Add(path)
         }
         return nil
@@ -57,21 +98,31 @@ func (fw *FileWatcher) Start() {
                 return
             }
             fw.handleEvent(event)
-            
+        
         case <-debounceTimer.C:
             fw.processPendingEvents()
-            
+        
         case err, ok := <-fw.watcher.Errors:
             if !ok {
                 return
             }
-            fmt.Printf("Watcher error: %v\n", err)
+            slog.Warn("Watcher error", "err", err)
         }
     }
 }
 
 func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
-    if !strings.HasSuffix(event.Name, ".md") {
+    // New folders are not covered by the initial recursive watch
+    if event.Has(fsnotify.Create) {
+        if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+            if !fw.index.ignoreMatcher().Match(event.Name, true) {
+                fw.watcher.Add(event.Name)
+            }
+            return
+        }
+    }
+    
+    if !fw.index.shouldIndex(event.Name) {
         return
     }
     
@@ -79,6 +130,17 @@ func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
     defer fw.mu.Unlock()
     
     fw.events[event.Name] = time.Now()
+    fw.observed[event.Name] = time.Now()
+}
+
+// takeObserved returns the paths fsnotify reported since the last call.
+func (fw *FileWatcher) takeObserved() map[string]time.Time {
+    fw.mu.Lock()
+    defer fw.mu.Unlock()
+    
+    observed := fw.observed
+    fw.observed = make(map[string]time.Time)
+    return observed
 }
 
 func (fw *FileWatcher) processPendingEvents() {
@@ -92,9 +154,17 @@ func (fw *FileWatcher) processPendingEvents() {
     
     for path := range events {
         if _, err := os.Stat(path); os.IsNotExist(err) {
-            fw.index.RemoveFile(path)
+            if err := fw.index.RemoveFile(path); err != nil {
+                slog.Warn("Failed to remove file", "path", path, "err", err)
+                continue
+            }
+            slog.Debug("Removed file", "path", path)
         } else {
-            fw.index.UpdateFile(path)
+            if err := fw.index.UpdateFile(path); err != nil {
+                slog.Warn("Failed to update file", "path", path, "err", err)
+                continue
+            }
+            slog.Debug("Updated file", "path", path)
         }
     }
     
@@ -0,0 +1,166 @@
+package markdown
+
+import (
+    "regexp"
+    "strings"
+)
+
+// Block is a part of a note marked with a ^block-id, which links and embeds
+// can point to. Line is the first line of the block, counting from the
+// first line of the body, and Text the block without its ID.
+type Block struct {
+    ID   string `json:"id"`
+    Line int    `json:"line"`
+    Text string `json:"text"`
+}
+
+var (
+    // blockID matches a ^block-id at the end of a line.
+    blockID = regexp.MustCompile(`(?:^|\s)\^([A-Za-z0-9-]+)\s*$`)
+    
+    listItem = regexp.MustCompile(`^\s*(?:>\s*)*(?:[-*+]|\d+[.)])\s`)
+)
+
+// Blocks returns the blocks with an ID in body, outside code blocks, in
+// order. An ID at the end of a paragraph marks the paragraph, at the end of
+// a list item or heading just that line, and on a line of its own the
+// block of lines before it, such as a table or quote. The line with an ID
+// ends its block, so the lines after it start a new one.
+func Blocks(body string) []Block {
+    lines := strings.Split(body, "\n")
+    prose := make(map[int]bool, len(lines))
+    proseLines(body, func(n int, _ string) {
+        prose[n-1] = true
+    })
+    
+    // paraStart is the first line of the paragraph the current line is in,
+    // and runStart to runEnd the last lines without a blank line between
+    // them that no block took yet
+    paraStart, runStart, runEnd := 0, -1, -1
+    var blocks []Block
+    for i, line := range lines {
+        line = strings.TrimRight(line, "\r")
+        if !prose[i] || strings.TrimSpace(line) == "" {
+            paraStart = i + 1
+            continue
+        }
+        loc := blockID.FindStringSubmatchIndex(line)
+        if loc == nil {
+            if runEnd != i-1 {
+                runStart = i
+            }
+            runEnd = i
+            if isBlockStart(line) {
+                paraStart = i + 1
+            }
+            continue
+        }
+        id := line[loc[2]:loc[3]]
+        text := strings.TrimRight(line[:loc[0]], " \t")
+        
+        start, end := i, i
+        switch {
+        case strings.TrimSpace(text) == "":
+            // The block before, after any blank lines
+            start, end = runStart, runEnd
+        case listItem.MatchString(text):
+        default:
+            if _, ok := atxHeading(strings.TrimSpace(text)); !ok {
+                start = paraStart
+            }
+        }
+        paraStart, runStart, runEnd = i+1, -1, -1
+        if end < 0 {
+            continue
+        }
+        
+        var parts []string
+        for j := start; j <= end; j++ {
+            part := strings.TrimRight(lines[j], "\r")
+            if j == i {
+                part = text
+            }
+            parts = append(parts, part)
+        }
+        blocks = append(blocks, Block{ID: id, Line: start + 1, Text: strings.Join(parts, "\n")})
+    }
+    return blocks
+}
+
+// isBlockStart reports whether line is a heading or list item, which end
+// the paragraph above them.
+func isBlockStart(line string) bool {
+    if listItem.MatchString(line) {
+        return true
+    }
+    _, ok := atxHeading(strings.TrimSpace(line))
+    return ok
+}
+
+// Section returns the part of body that subpath points to, and the line it
+// starts on: the block with ID "^id", or the heading with its content up to
+// the next heading of the same or a higher level. Nested headings are
+// separated by '#' ("Project#Risks"). Headings and IDs are compared
+// case-insensitively.
+func Section(body, subpath string) (string, int, bool) {
+    subpath = strings.TrimPrefix(strings.TrimSpace(subpath), "#")
+    if id, ok := strings.CutPrefix(subpath, "^"); ok {
+        for _, block := range Blocks(body) {
+            if strings.EqualFold(block.ID, id) {
+                return block.Text, block.Line, true
+            }
+        }
+        return "", 0, false
+    }
+    
+    type heading struct {
+        line, level int
+        text        string
+    }
+    var headings []heading
+    proseLines(body, func(n int, line string) {
+        trimmed := strings.TrimSpace(line)
+        if text, ok := atxHeading(trimmed); ok {
+            level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+            text = strings.TrimSpace(blockID.ReplaceAllString(text, ""))
+            headings = append(headings, heading{line: n, level: level, text: text})
+        }
+    })
+    
+    // Each part of the subpath is looked for below the previous one
+    from, to, found := 0, len(headings), -1
+    for _, part := range strings.Split(subpath, "#") {
+        part = strings.TrimSpace(part)
+        if part == "" {
+            continue
+        }
+        found = -1
+        for i := from; i < to; i++ {
+            if strings.EqualFold(headings[i].text, part) {
+                found = i
+                break
+            }
+        }
+        if found < 0 {
+            return "", 0, false
+        }
+        from, to = found+1, len(headings)
+        for i := found + 1; i < len(headings); i++ {
+            if headings[i].level <= headings[found].level {
+                to = i
+                break
+            }
+        }
+    }
+    if found < 0 {
+        return "", 0, false
+    }
+    
+    lines := strings.Split(body, "\n")
+    end := len(lines)
+    if to < len(headings) {
+        end = headings[to].line - 1
+    }
+    section := strings.Join(lines[headings[found].line-1:end], "\n")
+    return strings.TrimRight(section, " \t\r\n"), headings[found].line, true
+}
\ No newline at end of file
@@ -0,0 +1,78 @@
+package markdown
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestBlocks(t *testing.T) {
+    body := "# Plan ^top\n" +
+        "We ship in June\n" +
+        "if the budget holds. ^goal\n" +
+        "\n" +
+        "- Hire two people ^hiring\n" +
+        "- Rent an office\n" +
+        "\n" +
+        "| Cost | Amount |\n" +
+        "| ---- | ------ |\n" +
+        "\n" +
+        "^costs\n" +
+        "Not a block^id, nor `code ^id`\n" +
+        "```\nfenced ^id\n```\n"
+    
+    want := []Block{
+        {ID: "top", Line: 1, Text: "# Plan"},
+        {ID: "goal", Line: 2, Text: "We ship in June\nif the budget holds."},
+        {ID: "hiring", Line: 5, Text: "- Hire two people"},
+        {ID: "costs", Line: 8, Text: "| Cost | Amount |\n| ---- | ------ |"},
+    }
+    if got := Blocks(body); !reflect.DeepEqual(got, want) {
+        t.Errorf("Blocks() =\n%+v\nwant\n%+v", got, want)
+    }
+    
+    // A line with an ID ends its block, so every line of a note full of
+    // IDs is a block of its own
+    got := Blocks(strings.Repeat("x ^id\n", 5000) + "\n^after")
+    if len(got) != 5000 || got[4999].Line != 5000 || got[4999].Text != "x" {
+        t.Errorf("Expected 5000 blocks of one line, got %d, last %+v", len(got), got[len(got)-1])
+    }
+}
+
+func TestSection(t *testing.T) {
+    body := "# Project\n" +
+        "Intro\n" +
+        "## Risks\n" +
+        "Budget might slip. ^slip\n" +
+        "### Mitigation\n" +
+        "Weekly reviews\n" +
+        "## Budget\n" +
+        "Plenty\n" +
+        "# Appendix\n" +
+        "## Risks\n" +
+        "None here\n"
+    
+    tests := []struct {
+        subpath string
+        text    string
+        line    int
+    }{
+        {"Risks", "## Risks\nBudget might slip. ^slip\n### Mitigation\nWeekly reviews", 3},
+        {"#mitigation", "### Mitigation\nWeekly reviews", 5},
+        {"Appendix#Risks", "## Risks\nNone here", 10},
+        {"Project#Budget", "## Budget\nPlenty", 7},
+        {"^SLIP", "Budget might slip.", 4},
+    }
+    for _, tt := range tests {
+        text, line, ok := Section(body, tt.subpath)
+        if !ok || text != tt.text || line != tt.line {
+            t.Errorf("Section(%q) = %q, %d, %v, want %q, %d", tt.subpath, text, line, ok, tt.text, tt.line)
+        }
+    }
+    
+    for _, subpath := range []string{"Missing", "Risks#Budget", "^none"} {
+        if _, _, ok := Section(body, subpath); ok {
+            t.Errorf("Expected Section(%q) to find nothing", subpath)
+        }
+    }
+}
\ No newline at end of file
@@ -0,0 +1,86 @@
+package markdown
+
+import (
+    "regexp"
+    "strings"
+    "unicode"
+    "unicode/utf8"
+)
+
+// Callout is an Obsidian callout, a blockquote that starts with
+// "> [!type] Title". Type is in lower case and Title reads like the title
+// of the callout, or like its type if it has none, as Obsidian shows it.
+// Line and End are its first and last line. A callout nested in another
+// comes after it.
+type Callout struct {
+    Type  string `json:"type"`
+    Title string `json:"title"`
+    Line  int    `json:"line"`
+    End   int    `json:"end"`
+}
+
+var (
+    // calloutHeader matches the first line of a callout, with the type in
+    // group 1 and the title in group 2.
+    calloutHeader = regexp.MustCompile(`^\s*(?:>\s*)+\[!([^\]\s]+)\][+-]?(?:\s+(.*))?$`)
+    
+    // quotePrefix matches the quote markers at the start of a line and the
+    // [!type] of a callout header.
+    quotePrefix = regexp.MustCompile(`^\s*(?:>[ \t]?)+(?:\[![^\]\s]+\][+-]?[ \t]*)?`)
+)
+
+// Callouts returns the callouts in text, outside code blocks, in order.
+// Lines count from the first line of text.
+func Callouts(text string) []Callout {
+    var callouts []Callout
+    // open holds the callouts the current line is in, and depths the
+    // number of quote markers they start with
+    var open, depths []int
+    proseLines(text, func(n int, line string) {
+        line = strings.TrimRight(line, "\r")
+        depth := quoteDepth(line)
+        for len(open) > 0 && depth < depths[len(depths)-1] {
+            open, depths = open[:len(open)-1], depths[:len(depths)-1]
+        }
+        for _, i := range open {
+            callouts[i].End = n
+        }
+        
+        // A header in the same quote is text, a new callout needs one more
+        // level
+        match := calloutHeader.FindStringSubmatch(line)
+        if match == nil || len(depths) > 0 && depth <= depths[len(depths)-1] {
+            return
+        }
+        typ := strings.ToLower(match[1])
+        title := strings.TrimSpace(cleanLine(match[2], func(string) {}))
+        if title == "" {
+            r, size := utf8.DecodeRuneInString(typ)
+            title = string(unicode.ToUpper(r)) + typ[size:]
+        }
+        callouts = append(callouts, Callout{Type: typ, Title: title, Line: n, End: n})
+        open, depths = append(open, len(callouts)-1), append(depths, depth)
+    })
+    return callouts
+}
+
+// quoteDepth returns the number of quote markers at the start of line.
+func quoteDepth(line string) int {
+    depth := 0
+    for _, r := range line {
+        switch r {
+        case '>':
+            depth++
+        case ' ', '\t':
+        default:
+            return depth
+        }
+    }
+    return depth
+}
+
+// unquote removes the quote markers from the start of line, and the
+// [!type] of a callout header.
+func unquote(line string) string {
+    return quotePrefix.ReplaceAllString(line, "")
+}
\ No newline at end of file
@@ -0,0 +1,42 @@
+package markdown
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestCallouts(t *testing.T) {
+    text := strings.Join([]string{
+        "# Storage",                              // 1
+        "> [!Decision]- Use [[Postgres]]",        // 2
+        "> We need transactions.",                // 3
+        ">",                                      // 4
+        "> > [!warning]",                         // 5
+        "> > Backups first.",                     // 6
+        "> Decided on 2024-03-01.",               // 7
+        "> [!note] Not a new callout",            // 8
+        "",                                       // 9
+        "> Just a quote",                         // 10
+        "```",                                    // 11
+        "> [!todo] In code",                      // 12
+        "```",                                    // 13
+        ">[!todo]",                               // 14
+    }, "\n")
+    
+    got := Callouts(text)
+    want := []Callout{
+        {Type: "decision", Title: "Use Postgres", Line: 2, End: 8},
+        {Type: "warning", Title: "Warning", Line: 5, End: 6},
+        {Type: "todo", Title: "Todo", Line: 14, End: 14},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("Callouts() =\n%+v\nwant\n%+v", got, want)
+    }
+    
+    cleaned := Clean(strings.Join(strings.Split(text, "\n")[:8], "\n"))
+    wantText := "# Storage\nUse Postgres\nWe need transactions.\nBackups first.\nDecided on 2024-03-01.\nNot a new callout"
+    if cleaned.Text != wantText {
+        t.Errorf("Clean() = %q, want %q", cleaned.Text, wantText)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,133 @@
+package markdown
+
+import (
+    "encoding/json"
+    "path"
+    "strings"
+)
+
+// Canvas node types.
+const (
+    NodeText  = "text"
+    NodeFile  = "file"
+    NodeLink  = "link"
+    NodeGroup = "group"
+)
+
+// Canvas is an Obsidian canvas, a board of cards stored as JSON Canvas in a
+// .canvas file. Positions, sizes and colors are left out.
+type Canvas struct {
+    Nodes []CanvasNode `json:"nodes"`
+    Edges []CanvasEdge `json:"edges"`
+}
+
+// CanvasNode is a card on a canvas: markdown text, a file of the vault,
+// with an optional heading or block in Subpath, a web page, or a group of
+// other cards with a label.
+type CanvasNode struct {
+    ID      string `json:"id"`
+    Type    string `json:"type"`
+    Text    string `json:"text,omitempty"`
+    File    string `json:"file,omitempty"`
+    Subpath string `json:"subpath,omitempty"`
+    URL     string `json:"url,omitempty"`
+    Label   string `json:"label,omitempty"`
+}
+
+// CanvasEdge is an arrow between two cards, with an optional label.
+type CanvasEdge struct {
+    ID       string `json:"id"`
+    FromNode string `json:"fromNode"`
+    ToNode   string `json:"toNode"`
+    Label    string `json:"label,omitempty"`
+}
+
+// CanvasText is the searchable text of a card or of an edge label, with
+// the ID of the card or edge it belongs to.
+type CanvasText struct {
+    ID   string
+    Text string
+}
+
+// ParseCanvas parses the content of a .canvas file. An empty file is an
+// empty canvas, the way Obsidian creates them.
+func ParseCanvas(data []byte) (*Canvas, error) {
+    c := &Canvas{}
+    if strings.TrimSpace(string(data)) == "" {
+        return c, nil
+    }
+    if err := json.Unmarshal(data, c); err != nil {
+        return nil, err
+    }
+    return c, nil
+}
+
+// Texts returns the text of every card, then the edge labels, in file
+// order. Files are named by their path without the extension, web pages by
+// their URL and groups by their label; cards without text are left out.
+func (c *Canvas) Texts() []CanvasText {
+    var texts []CanvasText
+    add := func(id, text string) {
+        if text = strings.TrimSpace(text); text != "" {
+            texts = append(texts, CanvasText{ID: id, Text: text})
+        }
+    }
+    for _, node := range c.Nodes {
+        switch node.Type {
+        case NodeText:
+            add(node.ID, node.Text)
+        case NodeFile:
+            add(node.ID, strings.TrimSuffix(node.File, path.Ext(node.File)))
+        case NodeLink:
+            add(node.ID, node.URL)
+        case NodeGroup:
+            add(node.ID, node.Label)
+        }
+    }
+    for _, edge := range c.Edges {
+        add(edge.ID, edge.Label)
+    }
+    return texts
+}
+
+// Text returns the Texts of the canvas separated by blank lines.
+func (c *Canvas) Text() string {
+    var parts []string
+    for _, t := range c.Texts() {
+        parts = append(parts, t.Text)
+    }
+    return strings.Join(parts, "\n\n")
+}
+
+// Groups returns the labels of the groups on the canvas.
+func (c *Canvas) Groups() []string {
+    var labels []string
+    for _, node := range c.Nodes {
+        if label := strings.TrimSpace(node.Label); node.Type == NodeGroup && label != "" {
+            labels = append(labels, label)
+        }
+    }
+    return labels
+}
+
+// Node returns the card with the given ID.
+func (c *Canvas) Node(id string) (CanvasNode, bool) {
+    for _, node := range c.Nodes {
+        if node.ID == id {
+            return node, true
+        }
+    }
+    return CanvasNode{}, false
+}
+
+// Links returns the links in the text cards of the canvas. File cards are
+// not links; their File is the exact vault path of the file they show.
+func (c *Canvas) Links() []Link {
+    var links []Link
+    for _, node := range c.Nodes {
+        if node.Type == NodeText {
+            links = append(links, Links(node.Text)...)
+        }
+    }
+    return links
+}
\ No newline at end of file
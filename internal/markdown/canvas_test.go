@@ -0,0 +1,57 @@
+package markdown
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestParseCanvas(t *testing.T) {
+    data := `{
+        "nodes": [
+            {"id": "g1", "type": "group", "x": 0, "y": 0, "width": 800, "height": 600, "label": "Launch"},
+            {"id": "t1", "type": "text", "x": 20, "y": 20, "width": 200, "height": 100, "text": "Ship the **beta**\nsee [[Plan#Risks]]"},
+            {"id": "f1", "type": "file", "x": 300, "y": 20, "width": 200, "height": 100, "file": "Projects/Plan.md", "subpath": "#Risks"},
+            {"id": "l1", "type": "link", "x": 20, "y": 300, "width": 200, "height": 100, "url": "https://example.com"},
+            {"id": "e0", "type": "text", "x": 0, "y": 0, "width": 10, "height": 10, "text": "  "}
+        ],
+        "edges": [
+            {"id": "e1", "fromNode": "t1", "fromSide": "right", "toNode": "f1", "toSide": "left", "label": "depends on"},
+            {"id": "e2", "fromNode": "f1", "toNode": "l1"}
+        ]
+    }`
+    
+    c, err := ParseCanvas([]byte(data))
+    if err != nil {
+        t.Fatalf("ParseCanvas failed: %v", err)
+    }
+    
+    wantTexts := []CanvasText{
+        {ID: "g1", Text: "Launch"},
+        {ID: "t1", Text: "Ship the **beta**\nsee [[Plan#Risks]]"},
+        {ID: "f1", Text: "Projects/Plan"},
+        {ID: "l1", Text: "https://example.com"},
+        {ID: "e1", Text: "depends on"},
+    }
+    if got := c.Texts(); !reflect.DeepEqual(got, wantTexts) {
+        t.Errorf("Texts() =\n%+v\nwant\n%+v", got, wantTexts)
+    }
+    if got := c.Groups(); !reflect.DeepEqual(got, []string{"Launch"}) {
+        t.Errorf("Groups() = %q", got)
+    }
+    if got := c.Links(); !reflect.DeepEqual(got, []Link{{Target: "Plan", Subpath: "Risks", Line: 2}}) {
+        t.Errorf("Links() = %+v", got)
+    }
+    if node, ok := c.Node("f1"); !ok || node.File != "Projects/Plan.md" || node.Subpath != "#Risks" {
+        t.Errorf("Node(f1) = %+v, %v", node, ok)
+    }
+    if len(c.Edges) != 2 || c.Edges[1].FromNode != "f1" || c.Edges[1].ToNode != "l1" {
+        t.Errorf("Expected both edges, got %+v", c.Edges)
+    }
+    
+    if c, err := ParseCanvas(nil); err != nil || len(c.Texts()) != 0 {
+        t.Errorf("Expected an empty file to be an empty canvas, got %+v, %v", c, err)
+    }
+    if _, err := ParseCanvas([]byte("{nodes")); err == nil {
+        t.Error("Expected invalid JSON to fail")
+    }
+}
\ No newline at end of file
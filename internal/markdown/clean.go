@@ -0,0 +1,229 @@
+package markdown
+
+import (
+    "regexp"
+    "strings"
+)
+
+// Cleaned is the text of a note as it reads, for the index: links show
+// their labels, and comments, embeds, HTML tags, web addresses, block IDs
+// and the quote markers of blockquotes and callouts are left out. `code
+// spans` are kept as written. Lines holds for every line of Text the
+// 1-based line of the note it comes from; lines with nothing but markup
+// are dropped. URLs are the web addresses in the note,
+// in order and each once. Fenced code blocks are not part of Text but
+// listed in Code.
+type Cleaned struct {
+    Text  string
+    Lines []int
+    URLs  []string
+    Code  []CodeBlock
+}
+
+// CodeBlock is a fenced code block. Lang is the language after the opening
+// fence in lower case, such as "go" for ```go, and empty if none is given.
+// Line is the line of the note the code starts on.
+type CodeBlock struct {
+    Lang string
+    Line int
+    Code string
+}
+
+var (
+    // wikiEmbed matches ![[embeds]], which show another file in the note.
+    wikiEmbed = regexp.MustCompile(`!\[\[[^\[\]]*\]\]`)
+    
+    // wikiLabel matches [[target]], [[target#subpath]] and [[target|label]].
+    wikiLabel = regexp.MustCompile(`\[\[([^\[\]|]*)(?:\|([^\[\]]*))?\]\]`)
+    
+    // mdLabel matches [label](target) and images ![alt](target), with the
+    // target in group 3 or 4.
+    mdLabel = regexp.MustCompile(`(!?)\[([^\]]*)\]\((?:<([^>]*)>|([^()\s]+))(?:\s+"[^"]*")?\)`)
+    
+    // autoLink matches <https://example.com>.
+    autoLink = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9+.-]*:[^\s<>]+)>`)
+    
+    // bareURL matches a web address in text, without the punctuation after
+    // it.
+    bareURL = regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s<>]*[^\s<>.,;:!?'")\]]`)
+    
+    htmlTag = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?>`)
+)
+
+// comments pairs the markers that open a comment with those that close it.
+// Obsidian hides %%comments%% in reading view, and HTML hides <!-- -->.
+var comments = [][2]string{{"%%", "%%"}, {"<!--", "-->"}}
+
+// Clean returns text, a note with its frontmatter, as it reads. A comment
+// that is never closed hides the rest of the note, as in Obsidian.
+func Clean(text string) Cleaned {
+    var c Cleaned
+    var lines []string
+    seen := make(map[string]bool)
+    addURL := func(u string) {
+        if !seen[u] {
+            seen[u] = true
+            c.URLs = append(c.URLs, u)
+        }
+    }
+    
+    fence, comment := "", ""
+    var code []string
+    for i, line := range strings.Split(text, "\n") {
+        // Comments hide code blocks, but not the other way round
+        if comment == "" {
+            trimmed := strings.TrimSpace(line)
+            if marker := fenceMarker(trimmed); marker != "" {
+                switch {
+                case fence == "":
+                    fence, code = marker, nil
+                    c.Code = append(c.Code, CodeBlock{Lang: fenceLang(trimmed[len(marker):]), Line: i + 2})
+                case strings.HasPrefix(trimmed, fence):
+                    fence = ""
+                }
+                continue
+            }
+            if fence != "" {
+                code = append(code, strings.TrimRight(line, "\r"))
+                c.Code[len(c.Code)-1].Code = strings.Join(code, "\n")
+                continue
+            }
+        }
+        
+        visible := strings.TrimRight(cleanLine(unquote(stripComments(line, &comment)), addURL), " \t\r")
+        if visible == "" && strings.TrimSpace(line) != "" {
+            continue
+        }
+        lines = append(lines, visible)
+        c.Lines = append(c.Lines, i+1)
+    }
+    c.Text = strings.Join(lines, "\n")
+    return c
+}
+
+// fenceLang returns the language of a code block from the text after its
+// opening fence, as in ```go, ``` Go or ```{python}.
+func fenceLang(info string) string {
+    fields := strings.Fields(strings.Trim(strings.TrimSpace(info), "{}"))
+    if len(fields) == 0 {
+        return ""
+    }
+    return strings.ToLower(strings.Trim(fields[0], "{}."))
+}
+
+// Reading returns the lines of the note as it reads, prose and code in
+// order, and the line of the note that each comes from.
+func (c Cleaned) Reading() ([]string, []int) {
+    var prose []string
+    if len(c.Lines) > 0 {
+        prose = strings.Split(c.Text, "\n")
+    }
+    
+    var lines []string
+    var numbers []int
+    next := 0
+    // addProse adds the prose before line before, or all that is left
+    // with -1
+    addProse := func(before int) {
+        for ; next < len(prose) && (before < 0 || c.Lines[next] < before); next++ {
+            lines = append(lines, prose[next])
+            numbers = append(numbers, c.Lines[next])
+        }
+    }
+    for _, block := range c.Code {
+        if block.Code == "" {
+            continue
+        }
+        addProse(block.Line)
+        for i, line := range strings.Split(block.Code, "\n") {
+            lines = append(lines, line)
+            numbers = append(numbers, block.Line+i)
+        }
+    }
+    addProse(-1)
+    return lines, numbers
+}
+
+// stripComments removes the comments from line. comment is the marker that
+// closes the comment the line starts in, if any, and is updated for the
+// next line.
+func stripComments(line string, comment *string) string {
+    var b strings.Builder
+    for line != "" {
+        if *comment != "" {
+            end := strings.Index(line, *comment)
+            if end < 0 {
+                break
+            }
+            line = line[end+len(*comment):]
+            *comment = ""
+            continue
+        }
+        
+        start := -1
+        var marker [2]string
+        for _, m := range comments {
+            if i := strings.Index(line, m[0]); i >= 0 && (start < 0 || i < start) {
+                start, marker = i, m
+            }
+        }
+        if start < 0 {
+            b.WriteString(line)
+            break
+        }
+        b.WriteString(line[:start])
+        line = line[start+len(marker[0]):]
+        *comment = marker[1]
+    }
+    return b.String()
+}
+
+// cleanLine cleans a line of prose outside `code spans`, and removes the
+// block ID at its end.
+func cleanLine(line string, addURL func(string)) string {
+    parts := strings.Split(line, "`")
+    for i := range parts {
+        // A backtick without a partner does not start a code span
+        if i%2 == 0 || i == len(parts)-1 && len(parts)%2 == 0 {
+            parts[i] = cleanProse(parts[i], addURL)
+        }
+    }
+    return blockID.ReplaceAllString(strings.Join(parts, "`"), "")
+}
+
+// cleanProse replaces the links in s with their labels and removes embeds,
+// web addresses and HTML tags.
+func cleanProse(s string, addURL func(string)) string {
+    s = wikiEmbed.ReplaceAllString(s, "")
+    s = mdLabel.ReplaceAllStringFunc(s, func(m string) string {
+        match := mdLabel.FindStringSubmatch(m)
+        if target := match[3] + match[4]; urlScheme.MatchString(target) {
+            addURL(target)
+        }
+        return match[2]
+    })
+    s = wikiLabel.ReplaceAllStringFunc(s, func(m string) string {
+        match := wikiLabel.FindStringSubmatch(m)
+        if label := strings.TrimSpace(match[2]); label != "" {
+            return label
+        }
+        // A pipe in a table is escaped
+        link := wikiTarget(strings.TrimSuffix(match[1], `\`))
+        switch {
+        case link.Subpath == "" || strings.HasPrefix(link.Subpath, "^"):
+            return link.Target
+        case link.Target == "":
+            return strings.ReplaceAll(link.Subpath, "#", " > ")
+        }
+        return link.Target + " > " + strings.ReplaceAll(link.Subpath, "#", " > ")
+    })
+    s = autoLink.ReplaceAllStringFunc(s, func(m string) string {
+        addURL(m[1 : len(m)-1])
+        return ""
+    })
+    s = bareURL.ReplaceAllStringFunc(s, func(m string) string {
+        addURL(m)
+        return ""
+    })
+    return htmlTag.ReplaceAllString(s, "")
+}
\ No newline at end of file
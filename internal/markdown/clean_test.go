@@ -0,0 +1,62 @@
+package markdown
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestClean(t *testing.T) {
+    note := strings.Join([]string{
+        "# Trip",                                              // 1
+        "See [[Places/Lisbon|Lisbon]] and [[Porto#Food]].",    // 2
+        "![[map.png]]",                                        // 3
+        "Book at [the site](https://example.com/book) now.",   // 4
+        "%% remember the",                                     // 5
+        "tickets %% Packing list ^packing",                    // 6
+        "",                                                    // 7
+        "Docs: <https://docs.example.com>, or https://x.org.", // 8
+        "<!-- hidden -->",                                     // 9
+        "Use `[[raw]] https://raw.example` <b>bold</b>",       // 10
+        "```Go title=main.go",                                 // 11
+        "%% kept in code %%",                                  // 12
+        "```",                                                 // 13
+        "![Beach](img/beach.jpg) end",                         // 14
+    }, "\n")
+    
+    got := Clean(note)
+    wantText := strings.Join([]string{
+        "# Trip",
+        "See Lisbon and Porto > Food.",
+        "Book at the site now.",
+        " Packing list",
+        "",
+        "Docs: , or .",
+        "Use `[[raw]] https://raw.example` bold",
+        "Beach end",
+    }, "\n")
+    if got.Text != wantText {
+        t.Errorf("Clean().Text =\n%s\nwant\n%s", got.Text, wantText)
+    }
+    if want := []int{1, 2, 4, 6, 7, 8, 10, 14}; !reflect.DeepEqual(got.Lines, want) {
+        t.Errorf("Clean().Lines = %v, want %v", got.Lines, want)
+    }
+    wantURLs := []string{"https://example.com/book", "https://docs.example.com", "https://x.org"}
+    if !reflect.DeepEqual(got.URLs, wantURLs) {
+        t.Errorf("Clean().URLs = %q, want %q", got.URLs, wantURLs)
+    }
+    if want := []CodeBlock{{Lang: "go", Line: 12, Code: "%% kept in code %%"}}; !reflect.DeepEqual(got.Code, want) {
+        t.Errorf("Clean().Code = %+v, want %+v", got.Code, want)
+    }
+    
+    // Reading puts the code back between the prose
+    lines, numbers := got.Reading()
+    if len(lines) != 9 || lines[7] != "%% kept in code %%" || numbers[7] != 12 || numbers[8] != 14 {
+        t.Errorf("Reading() = %q, %v", lines, numbers)
+    }
+    
+    // A comment that is never closed hides the rest of the note
+    if got := Clean("Visible\n%% draft\n```\nhidden"); got.Text != "Visible" {
+        t.Errorf("Expected an open comment to hide the rest, got %q", got.Text)
+    }
+}
\ No newline at end of file
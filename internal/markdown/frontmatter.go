@@ -0,0 +1,117 @@
+// Package markdown extracts the Obsidian-specific structure of a note.
+package markdown
+
+import (
+    "fmt"
+    "strings"
+    "time"
+    
+    "gopkg.in/yaml.v3"
+)
+
+// Frontmatter holds the YAML properties at the top of a note.
+type Frontmatter map[string]interface{}
+
+// SplitFrontmatter separates the frontmatter block from the body of a note.
+// It also returns the number of lines the block takes up, so positions in
+// the body can be mapped back to the file. Notes without a closing
+// delimiter have no frontmatter; invalid YAML yields empty properties.
+func SplitFrontmatter(content string) (Frontmatter, string, int) {
+    content = strings.TrimPrefix(content, "\uFEFF")
+    if !strings.HasPrefix(content, "---\n") && !strings.HasPrefix(content, "---\r\n") {
+        return nil, content, 0
+    }
+    
+    lines := strings.SplitAfter(content, "\n")
+    for i := 1; i < len(lines); i++ {
+        delim := strings.TrimRight(lines[i], "\r\n")
+        if delim != "---" && delim != "..." {
+            continue
+        }
+        
+        var fm Frontmatter
+        if err := yaml.Unmarshal([]byte(strings.Join(lines[1:i], "")), &fm); err != nil {
+            fm = nil
+        }
+        if fm == nil {
+            fm = Frontmatter{}
+        }
+        return fm, strings.Join(lines[i+1:], ""), i + 1
+    }
+    return nil, content, 0
+}
+
+// String returns the property key as a string. Scalars are formatted,
+// lists and maps yield "".
+func (fm Frontmatter) String(key string) string {
+    switch value := fm[key].(type) {
+    case nil:
+        return ""
+    case string:
+        return value
+    case []interface{}, map[string]interface{}:
+        return ""
+    default:
+        return fmt.Sprint(value)
+    }
+}
+
+// Strings returns the property key as a list of strings. A single scalar
+// yields a one-element list, so both "aliases: Foo" and a YAML list work.
+func (fm Frontmatter) Strings(key string) []string {
+    switch value := fm[key].(type) {
+    case nil:
+        return nil
+    case []interface{}:
+        var items []string
+        for _, item := range value {
+            if item == nil {
+                continue
+            }
+            if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+                items = append(items, s)
+            }
+        }
+        return items
+    case map[string]interface{}:
+        return nil
+    default:
+        if s := strings.TrimSpace(fm.String(key)); s != "" {
+            return []string{s}
+        }
+        return nil
+    }
+}
+
+// Aliases returns the alternative names of a note from the aliases property
+// or its older singular form, alias.
+func (fm Frontmatter) Aliases() []string {
+    return append(fm.Strings("aliases"), fm.Strings("alias")...)
+}
+
+// dateLayouts are the date formats accepted in date properties, besides
+// the YAML timestamps the decoder already turns into times.
+var dateLayouts = []string{
+    time.RFC3339,
+    "2006-01-02T15:04:05",
+    "2006-01-02T15:04",
+    "2006-01-02 15:04:05",
+    "2006-01-02 15:04",
+    "2006-01-02",
+}
+
+// Time returns the property key as a point in time. Dates without a time
+// zone are taken as local time.
+func (fm Frontmatter) Time(key string) (time.Time, bool) {
+    switch value := fm[key].(type) {
+    case time.Time:
+        return value, true
+    case string:
+        for _, layout := range dateLayouts {
+            if t, err := time.ParseInLocation(layout, strings.TrimSpace(value), time.Local); err == nil {
+                return t, true
+            }
+        }
+    }
+    return time.Time{}, false
+}
\ No newline at end of file
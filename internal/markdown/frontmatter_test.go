@@ -0,0 +1,80 @@
+package markdown
+
+import "testing"
+
+func TestSplitFrontmatter(t *testing.T) {
+    content := "---\nlang: de\ntags: [a, b]\nversion: 2\n---\n# Titel\nText\n"
+    
+    fm, body, lines := SplitFrontmatter(content)
+    if body != "# Titel\nText\n" {
+        t.Errorf("body = %q", body)
+    }
+    if lines != 5 {
+        t.Errorf("frontmatter lines = %d, want 5", lines)
+    }
+    if got := fm.String("lang"); got != "de" {
+        t.Errorf("lang = %q, want de", got)
+    }
+    if got := fm.String("version"); got != "2" {
+        t.Errorf("version = %q, want 2", got)
+    }
+    if got := fm.String("tags"); got != "" {
+        t.Errorf("tags as string = %q, want empty", got)
+    }
+}
+
+func TestSplitFrontmatterWithoutBlock(t *testing.T) {
+    for _, content := range []string{
+        "# Note\n---\nnot frontmatter\n---\n",
+        "---\nunterminated: true\n",
+    } {
+        fm, body, lines := SplitFrontmatter(content)
+        if fm != nil || body != content || lines != 0 {
+            t.Errorf("SplitFrontmatter(%q) = %v, %q, %d; want no frontmatter", content, fm, body, lines)
+        }
+    }
+}
+
+func TestSplitFrontmatterInvalidYAML(t *testing.T) {
+    fm, body, _ := SplitFrontmatter("---\nkey: [unclosed\n---\nbody")
+    if fm == nil || len(fm) != 0 {
+        t.Errorf("expected empty properties for invalid YAML, got %v", fm)
+    }
+    if body != "body" {
+        t.Errorf("body = %q, want %q", body, "body")
+    }
+}
+
+func TestAliases(t *testing.T) {
+    fm, _, _ := SplitFrontmatter("---\naliases: [Weekly, \"Team sync\"]\nalias: Standup\n---\n")
+    got := fm.Aliases()
+    want := []string{"Weekly", "Team sync", "Standup"}
+    if len(got) != len(want) {
+        t.Fatalf("Aliases() = %q, want %q", got, want)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Errorf("Aliases()[%d] = %q, want %q", i, got[i], want[i])
+        }
+    }
+    
+    if got := Frontmatter(nil).Aliases(); got != nil {
+        t.Errorf("Aliases() without frontmatter = %q, want nil", got)
+    }
+}
+
+func TestTime(t *testing.T) {
+    fm, _, _ := SplitFrontmatter("---\ncreated: 2024-03-05\nupdated: \"2024-03-06 14:30\"\ndate: soon\n---\n")
+    
+    created, ok := fm.Time("created")
+    if !ok || created.Year() != 2024 || created.Month() != 3 || created.Day() != 5 {
+        t.Errorf("Time(created) = %v, %v", created, ok)
+    }
+    updated, ok := fm.Time("updated")
+    if !ok || updated.Hour() != 14 || updated.Minute() != 30 {
+        t.Errorf("Time(updated) = %v, %v", updated, ok)
+    }
+    if _, ok := fm.Time("date"); ok {
+        t.Error("Expected an unparseable date to be rejected")
+    }
+}
\ No newline at end of file
@@ -0,0 +1,60 @@
+package markdown
+
+import "strings"
+
+// Headings returns the text of the ATX headings ("# Title" to "###### Sub")
+// in body, in order. Lines inside fenced code blocks are skipped, so shell
+// comments in code are not mistaken for headings.
+func Headings(body string) []string {
+    var headings []string
+    proseLines(body, func(_ int, line string) {
+        if heading, ok := atxHeading(strings.TrimSpace(line)); ok {
+            headings = append(headings, heading)
+        }
+    })
+    return headings
+}
+
+// proseLines calls fn for every line of body outside fenced code blocks,
+// with its 1-based line number.
+func proseLines(body string, fn func(n int, line string)) {
+    fence := ""
+    for i, line := range strings.Split(body, "\n") {
+        trimmed := strings.TrimSpace(line)
+        if marker := fenceMarker(trimmed); marker != "" {
+            switch {
+            case fence == "":
+                fence = marker
+            case strings.HasPrefix(trimmed, fence):
+                fence = ""
+            }
+            continue
+        }
+        if fence == "" {
+            fn(i+1, line)
+        }
+    }
+}
+
+// fenceMarker returns the ``` or ~~~ run that opens or closes a code fence.
+func fenceMarker(line string) string {
+    for _, c := range []string{"`", "~"} {
+        if strings.HasPrefix(line, c+c+c) {
+            return line[:len(line)-len(strings.TrimLeft(line, c))]
+        }
+    }
+    return ""
+}
+
+func atxHeading(line string) (string, bool) {
+    level := len(line) - len(strings.TrimLeft(line, "#"))
+    if level < 1 || level > 6 {
+        return "", false
+    }
+    rest := line[level:]
+    if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+        return "", false // #tag, not a heading
+    }
+    heading := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#"))
+    return heading, heading != ""
+}
\ No newline at end of file
@@ -0,0 +1,16 @@
+package markdown
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestHeadings(t *testing.T) {
+    body := "# Project Alpha\nIntro #tag\n## Goals ##\n```sh\n# not a heading\n```\n####### too deep\n#hashtag\n### Next steps\n"
+    
+    got := Headings(body)
+    want := []string{"Project Alpha", "Goals", "Next steps"}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("Headings() = %q, want %q", got, want)
+    }
+}
\ No newline at end of file
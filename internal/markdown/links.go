@@ -0,0 +1,88 @@
+package markdown
+
+import (
+    "net/url"
+    "regexp"
+    "strings"
+)
+
+// Link is a link or embed in a note. Target is the linked note as written,
+// a name ("Meeting notes") or a path ("Projects/roadmap.md"), and empty for
+// links within the note. Subpath is the heading or ^block after '#'; the
+// '#' may be left out before a ^block. Line is the line of the text the
+// link was found in, numbered from 1, and 0 for a link parsed on its own.
+type Link struct {
+    Target  string
+    Subpath string
+    Embed   bool
+    Line    int
+}
+
+var (
+    // wikiLink matches [[target]], [[target#subpath]] and [[target|label]],
+    // and with a leading '!' embeds.
+    wikiLink = regexp.MustCompile(`(!?)\[\[([^\[\]|]*?)\s*(?:\|[^\[\]]*)?\]\]`)
+    
+    // mdLink matches [label](target) and [label](<target with spaces>).
+    mdLink = regexp.MustCompile(`(!?)\[[^\]]*\]\((?:<([^>]*)>|([^()\s]+))(?:\s+"[^"]*")?\)`)
+    
+    urlScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// ParseLink parses a wiki link as written in a note, "[[note#heading]]" or
+// "![[note^block|label]]"; the brackets may be left out.
+func ParseLink(s string) Link {
+    s = strings.TrimSpace(s)
+    embed := strings.HasPrefix(s, "!")
+    s = strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(s, "!"), "]]"), "[[")
+    s, _, _ = strings.Cut(s, "|")
+    link := wikiTarget(s)
+    link.Embed = embed
+    return link
+}
+
+// wikiTarget splits the target of a wiki link from its subpath.
+func wikiTarget(s string) Link {
+    target, subpath, found := strings.Cut(s, "#")
+    if !found {
+        if name, id, ok := strings.Cut(target, "^"); ok {
+            target, subpath = name, "^"+id
+        }
+    }
+    return Link{
+        Target:  strings.TrimSpace(target),
+        Subpath: strings.TrimSpace(subpath),
+    }
+}
+
+// Links returns the links and embeds in body that point into the vault, in
+// order. Links to web pages and links in code are left out.
+func Links(body string) []Link {
+    var links []Link
+    proseLines(body, func(n int, line string) {
+        line = stripInlineCode(line)
+        for _, match := range wikiLink.FindAllStringSubmatch(line, -1) {
+            link := wikiTarget(match[2])
+            link.Embed = match[1] == "!"
+            link.Line = n
+            links = append(links, link)
+        }
+        for _, match := range mdLink.FindAllStringSubmatch(line, -1) {
+            raw := match[2] + match[3]
+            if urlScheme.MatchString(raw) {
+                continue
+            }
+            target, subpath, _ := strings.Cut(raw, "#")
+            if decoded, err := url.PathUnescape(target); err == nil {
+                target = decoded
+            }
+            links = append(links, Link{
+                Target:  target,
+                Subpath: subpath,
+                Embed:   match[1] == "!",
+                Line:    n,
+            })
+        }
+    })
+    return links
+}
\ No newline at end of file
@@ -0,0 +1,47 @@
+package markdown
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestLinks(t *testing.T) {
+    body := "See [[Meeting notes]] and [[Projects/roadmap|the roadmap]].\n" +
+        "![[diagram.png]] [[Budget#Q3 numbers]] [[#Local heading]]\n" +
+        "[spec](Specs/API%20design.md#auth) [web](https://example.com) [mail](mailto:a@b.c)\n" +
+        "[spaced](<Daily/2024 01 02.md>) `[[not a link]]`\n" +
+        "![[Plan#^goals]] ![[Plan^risks|Risks]]\n" +
+        "```\n[[fenced]]\n```\n"
+    
+    got := Links(body)
+    want := []Link{
+        {Target: "Meeting notes", Line: 1},
+        {Target: "Projects/roadmap", Line: 1},
+        {Target: "diagram.png", Embed: true, Line: 2},
+        {Target: "Budget", Subpath: "Q3 numbers", Line: 2},
+        {Target: "", Subpath: "Local heading", Line: 2},
+        {Target: "Specs/API design.md", Subpath: "auth", Line: 3},
+        {Target: "Daily/2024 01 02.md", Line: 4},
+        {Target: "Plan", Subpath: "^goals", Embed: true, Line: 5},
+        {Target: "Plan", Subpath: "^risks", Embed: true, Line: 5},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("Links() =\n%+v\nwant\n%+v", got, want)
+    }
+}
+
+func TestParseLink(t *testing.T) {
+    tests := map[string]Link{
+        "[[Plan]]":              {Target: "Plan"},
+        "Plan#Risks":            {Target: "Plan", Subpath: "Risks"},
+        "[[Plan#^goals|Goals]]": {Target: "Plan", Subpath: "^goals"},
+        "Plan^goals":            {Target: "Plan", Subpath: "^goals"},
+        "![[Plan#Risks#Budget]]": {Target: "Plan", Subpath: "Risks#Budget", Embed: true},
+        "[[#Local]]":            {Subpath: "Local"},
+    }
+    for input, want := range tests {
+        if got := ParseLink(input); got != want {
+            t.Errorf("ParseLink(%q) = %+v, want %+v", input, got, want)
+        }
+    }
+}
\ No newline at end of file
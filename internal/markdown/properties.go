@@ -0,0 +1,235 @@
+package markdown
+
+import (
+    "fmt"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Property types.
+const (
+    PropertyString = "string"
+    PropertyNumber = "number"
+    PropertyDate   = "date"
+    PropertyBool   = "bool"
+    PropertyList   = "list"
+)
+
+// Value is a typed property value. Type says which of the other fields
+// holds it.
+type Value struct {
+    Type   string    `json:"type"`
+    Text   string    `json:"text,omitempty"`
+    Number float64   `json:"number,omitempty"`
+    Date   time.Time `json:"date"`
+    Bool   bool      `json:"bool,omitempty"`
+    List   []Value   `json:"list,omitempty"`
+}
+
+// String formats the value the way it would be written in a note.
+func (v Value) String() string {
+    switch v.Type {
+    case PropertyNumber:
+        return strconv.FormatFloat(v.Number, 'f', -1, 64)
+    case PropertyDate:
+        if v.Date.Hour() == 0 && v.Date.Minute() == 0 && v.Date.Second() == 0 {
+            return v.Date.Format("2006-01-02")
+        }
+        return v.Date.Format("2006-01-02T15:04")
+    case PropertyBool:
+        return strconv.FormatBool(v.Bool)
+    case PropertyList:
+        items := make([]string, len(v.List))
+        for i, item := range v.List {
+            items[i] = item.String()
+        }
+        return strings.Join(items, ", ")
+    }
+    return v.Text
+}
+
+// ParseValue types a value written in a note: true and false are bools,
+// numbers are numbers, dates in the formats of date properties are dates
+// and a comma-separated list of links is a list. Anything else is a
+// string, without the quotes around it.
+func ParseValue(s string) Value {
+    s = strings.TrimSpace(s)
+    switch strings.ToLower(s) {
+    case "true":
+        return Value{Type: PropertyBool, Bool: true}
+    case "false":
+        return Value{Type: PropertyBool}
+    }
+    if isNumber(s) {
+        if n, err := strconv.ParseFloat(s, 64); err == nil {
+            return Value{Type: PropertyNumber, Number: n}
+        }
+    }
+    if t, ok := parseDate(s); ok {
+        return Value{Type: PropertyDate, Date: t}
+    }
+    if strings.HasPrefix(s, "[[") && strings.Contains(s, "]],") {
+        var list []Value
+        for _, item := range strings.Split(s, ",") {
+            item = strings.TrimSpace(item)
+            if !strings.HasPrefix(item, "[[") || !strings.HasSuffix(item, "]]") {
+                list = nil
+                break
+            }
+            list = append(list, Value{Type: PropertyString, Text: item})
+        }
+        if list != nil {
+            return Value{Type: PropertyList, List: list}
+        }
+    }
+    if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+        s = s[1 : len(s)-1]
+    }
+    return Value{Type: PropertyString, Text: s}
+}
+
+// isNumber reports whether s looks like a decimal number, which keeps
+// ParseFloat from taking words such as "Inf" or hex literals.
+func isNumber(s string) bool {
+    s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+    if s == "" || s[0] < '0' || s[0] > '9' {
+        return false
+    }
+    for _, r := range s {
+        if (r < '0' || r > '9') && r != '.' {
+            return false
+        }
+    }
+    return true
+}
+
+func parseDate(s string) (time.Time, bool) {
+    for _, layout := range dateLayouts {
+        if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+            return t, true
+        }
+    }
+    return time.Time{}, false
+}
+
+// yamlValue types a frontmatter value. The YAML decoder has typed numbers
+// and bools already; strings can still be dates. Empty values and nested
+// maps have no value.
+func yamlValue(raw interface{}) (Value, bool) {
+    switch raw := raw.(type) {
+    case nil:
+        return Value{}, false
+    case bool:
+        return Value{Type: PropertyBool, Bool: raw}, true
+    case int:
+        return Value{Type: PropertyNumber, Number: float64(raw)}, true
+    case float64:
+        return Value{Type: PropertyNumber, Number: raw}, true
+    case time.Time:
+        return Value{Type: PropertyDate, Date: raw}, true
+    case string:
+        s := strings.TrimSpace(raw)
+        if s == "" {
+            return Value{}, false
+        }
+        if t, ok := parseDate(s); ok {
+            return Value{Type: PropertyDate, Date: t}, true
+        }
+        return Value{Type: PropertyString, Text: s}, true
+    case []interface{}:
+        var list []Value
+        for _, item := range raw {
+            if v, ok := yamlValue(item); ok {
+                list = append(list, v)
+            }
+        }
+        if len(list) == 0 {
+            return Value{}, false
+        }
+        return Value{Type: PropertyList, List: list}, true
+    case map[string]interface{}, Frontmatter:
+        // Nested maps decode as Frontmatter, the type of the outer map
+        return Value{}, false
+    }
+    return Value{Type: PropertyString, Text: fmt.Sprint(raw)}, true
+}
+
+// PropertyKey normalizes a property name the way Dataview does, so that
+// "Finished Date" and "finished-date" are the same property. Bold and
+// italic markers around the name are dropped.
+func PropertyKey(name string) string {
+    name = strings.Trim(strings.TrimSpace(name), "*_")
+    return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
+
+// Inline fields of Dataview: "key:: value" on a line of its own, also as a
+// list item or in a blockquote, and "[key:: value]" or "(key:: value)"
+// anywhere in a line.
+var (
+    lineField    = regexp.MustCompile(`^\s*(?:>\s*)*(?:(?:[-*+]|\d+[.)])\s+)?([^\s\[(:][^\[(:]*?)::\s*(.*)$`)
+    bracketField = regexp.MustCompile(`[\[(]([^\[\]():]+?)::\s*([^\])]*)[\])]`)
+)
+
+// Properties returns the typed properties of a note: its frontmatter and
+// the inline fields of body outside code blocks, by normalized key. A key
+// that is set more than once collects its values in a list.
+func Properties(fm Frontmatter, body string) map[string]Value {
+    props := make(map[string]Value)
+    add := func(key string, value Value) {
+        key = PropertyKey(key)
+        if key == "" {
+            return
+        }
+        old, ok := props[key]
+        if !ok {
+            props[key] = value
+            return
+        }
+        list := Value{Type: PropertyList}
+        for _, v := range []Value{old, value} {
+            if v.Type == PropertyList {
+                list.List = append(list.List, v.List...)
+            } else {
+                list.List = append(list.List, v)
+            }
+        }
+        props[key] = list
+    }
+    
+    // Go randomizes map order, the frontmatter is added in key order so
+    // keys that normalize alike always combine the same way
+    keys := make([]string, 0, len(fm))
+    for key := range fm {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        if value, ok := yamlValue(fm[key]); ok {
+            add(key, value)
+        }
+    }
+    
+    proseLines(body, func(_ int, line string) {
+        line = strings.TrimRight(line, "\r")
+        stripped := stripInlineCode(line)
+        found := false
+        for _, m := range bracketField.FindAllStringSubmatch(stripped, -1) {
+            if value := strings.TrimSpace(m[2]); value != "" {
+                add(m[1], ParseValue(value))
+            }
+            found = true
+        }
+        if found {
+            return
+        }
+        if m := lineField.FindStringSubmatch(stripped); m != nil {
+            if value := strings.TrimSpace(m[2]); value != "" {
+                add(m[1], ParseValue(value))
+            }
+        }
+    })
+    return props
+}
\ No newline at end of file
@@ -0,0 +1,104 @@
+package markdown
+
+import (
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestParseValue(t *testing.T) {
+    date := func(s string) time.Time {
+        d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+        return d
+    }
+    tests := []struct {
+        input string
+        want  Value
+    }{
+        {"4", Value{Type: PropertyNumber, Number: 4}},
+        {"-2.5", Value{Type: PropertyNumber, Number: -2.5}},
+        {"True", Value{Type: PropertyBool, Bool: true}},
+        {"2025-03-01", Value{Type: PropertyDate, Date: date("2025-03-01")}},
+        {"active", Value{Type: PropertyString, Text: "active"}},
+        {`"42"`, Value{Type: PropertyString, Text: "42"}},
+        {"Inf", Value{Type: PropertyString, Text: "Inf"}},
+        {"1.2.3", Value{Type: PropertyString, Text: "1.2.3"}},
+        {"[[Tolkien]]", Value{Type: PropertyString, Text: "[[Tolkien]]"}},
+        {"[[A]], [[B]]", Value{Type: PropertyList, List: []Value{
+            {Type: PropertyString, Text: "[[A]]"},
+            {Type: PropertyString, Text: "[[B]]"},
+        }}},
+        {"[[A]], plain", Value{Type: PropertyString, Text: "[[A]], plain"}},
+    }
+    
+    for _, tt := range tests {
+        if got := ParseValue(tt.input); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("ParseValue(%q) = %+v, want %+v", tt.input, got, tt.want)
+        }
+    }
+}
+
+func TestPropertyKey(t *testing.T) {
+    tests := map[string]string{
+        "rating":        "rating",
+        "Finished Date": "finished-date",
+        "**Status**":    "status",
+        "  Type ":       "type",
+    }
+    for input, want := range tests {
+        if got := PropertyKey(input); got != want {
+            t.Errorf("PropertyKey(%q) = %q, want %q", input, got, want)
+        }
+    }
+}
+
+func TestProperties(t *testing.T) {
+    content := "---\n" +
+        "type: book\n" +
+        "rating: 4\n" +
+        "finished: 2025-02-14\n" +
+        "read: true\n" +
+        "genres: [fantasy, classic]\n" +
+        "empty:\n" +
+        "nested: {a: 1}\n" +
+        "---\n" +
+        "# Notes\n" +
+        "Status:: reading\n" +
+        "- **Author**:: [[Tolkien]]\n" +
+        "Liked it [mood:: happy] and (pages:: 310).\n" +
+        "genres:: epic\n" +
+        "See http://example.com for more.\n" +
+        "`code:: no`\n" +
+        "```\nfenced:: no\n```\n"
+    
+    fm, body, _ := SplitFrontmatter(content)
+    got := Properties(fm, body)
+    
+    finished, _ := time.ParseInLocation("2006-01-02", "2025-02-14", time.Local)
+    want := map[string]Value{
+        "type":     {Type: PropertyString, Text: "book"},
+        "rating":   {Type: PropertyNumber, Number: 4},
+        "finished": {Type: PropertyDate, Date: finished},
+        "read":     {Type: PropertyBool, Bool: true},
+        "genres": {Type: PropertyList, List: []Value{
+            {Type: PropertyString, Text: "fantasy"},
+            {Type: PropertyString, Text: "classic"},
+            {Type: PropertyString, Text: "epic"},
+        }},
+        "status": {Type: PropertyString, Text: "reading"},
+        "author": {Type: PropertyString, Text: "[[Tolkien]]"},
+        "mood":   {Type: PropertyString, Text: "happy"},
+        "pages":  {Type: PropertyNumber, Number: 310},
+    }
+    
+    if len(got) != len(want) {
+        t.Errorf("Properties() returned %d properties, want %d: %+v", len(got), len(want), got)
+    }
+    for key, value := range want {
+        if g, ok := got[key]; !ok {
+            t.Errorf("property %q missing", key)
+        } else if g.String() != value.String() || g.Type != value.Type {
+            t.Errorf("property %q = %+v, want %+v", key, g, value)
+        }
+    }
+}
\ No newline at end of file
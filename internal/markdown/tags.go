@@ -0,0 +1,60 @@
+package markdown
+
+import (
+    "regexp"
+    "strings"
+    "unicode"
+)
+
+// inlineTag matches #tag and #nested/tag at the start of a line or after
+// white space, the way Obsidian recognizes tags in the body of a note.
+var inlineTag = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]+)`)
+
+// Tags returns the tags of a note without the leading '#': those in the
+// tags property of the frontmatter, then those written in the body, each
+// once. Headings, code and purely numeric words like #123 are not tags.
+func Tags(fm Frontmatter, body string) []string {
+    seen := make(map[string]bool)
+    var tags []string
+    add := func(tag string) {
+        tag = strings.Trim(strings.TrimPrefix(strings.TrimSpace(tag), "#"), "/")
+        if key := strings.ToLower(tag); isTag(tag) && !seen[key] {
+            seen[key] = true
+            tags = append(tags, tag)
+        }
+    }
+    
+    for _, key := range []string{"tags", "tag"} {
+        for _, value := range fm.Strings(key) {
+            // "tags: a, b" is a single string in YAML
+            for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+                add(tag)
+            }
+        }
+    }
+    
+    proseLines(body, func(_ int, line string) {
+        for _, match := range inlineTag.FindAllStringSubmatch(stripInlineCode(line), -1) {
+            add(match[1])
+        }
+    })
+    return tags
+}
+
+// isTag reports whether tag contains something other than digits.
+func isTag(tag string) bool {
+    return strings.IndexFunc(tag, func(r rune) bool {
+        return !unicode.IsDigit(r) && r != '/' && r != '-' && r != '_'
+    }) >= 0
+}
+
+// stripInlineCode removes `code spans` from line.
+func stripInlineCode(line string) string {
+    parts := strings.Split(line, "`")
+    var b strings.Builder
+    for i := 0; i < len(parts); i += 2 {
+        b.WriteString(parts[i])
+        b.WriteByte(' ')
+    }
+    return b.String()
+}
\ No newline at end of file
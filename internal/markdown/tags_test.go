@@ -0,0 +1,25 @@
+package markdown
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestTags(t *testing.T) {
+    fm, body, _ := SplitFrontmatter("---\ntags: [project, \"#meeting\"]\n---\n" +
+        "# Heading\nDiscussed #project/alpha and #Meeting again, issue #123.\n" +
+        "`#code` stays out, so does a#b.\n```\n#fenced\n```\n#todo\n")
+    
+    got := Tags(fm, body)
+    want := []string{"project", "meeting", "project/alpha", "todo"}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("Tags() = %q, want %q", got, want)
+    }
+}
+
+func TestTagsFromCommaSeparatedProperty(t *testing.T) {
+    fm := Frontmatter{"tags": "daily, journal"}
+    if got := Tags(fm, ""); !reflect.DeepEqual(got, []string{"daily", "journal"}) {
+        t.Errorf("Tags() = %q", got)
+    }
+}
\ No newline at end of file
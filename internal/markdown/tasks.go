@@ -0,0 +1,130 @@
+package markdown
+
+import (
+    "regexp"
+    "strings"
+    "time"
+)
+
+// Task statuses. A checkbox with a character other than those of done,
+// cancelled and in progress is open, as in the Tasks plugin.
+const (
+    TaskOpen       = "open"
+    TaskInProgress = "in_progress"
+    TaskDone       = "done"
+    TaskCancelled  = "cancelled"
+)
+
+// TaskStatuses lists the statuses a task can have.
+var TaskStatuses = []string{TaskOpen, TaskInProgress, TaskDone, TaskCancelled}
+
+// Task is a checkbox list item. Dates and priority are read from the emoji
+// format of the Tasks plugin ("📅 2025-06-01", "⏫") and removed from Text;
+// dates that are not set are zero.
+type Task struct {
+    Line      int       `json:"line"`
+    Status    string    `json:"status"`
+    Text      string    `json:"text"`
+    Priority  string    `json:"priority,omitempty"`
+    Due       time.Time `json:"due"`
+    Scheduled time.Time `json:"scheduled"`
+    Start     time.Time `json:"start"`
+    Done      time.Time `json:"done"`
+    Created   time.Time `json:"created"`
+    Recurs    string    `json:"recurs,omitempty"`
+    Tags      []string  `json:"tags,omitempty"`
+}
+
+// checkbox matches "- [ ] text" and "1. [x] text", also inside blockquotes
+// and callouts.
+var checkbox = regexp.MustCompile(`^\s*(?:>\s*)*(?:[-*+]|\d+[.)])\s+\[(.)\](?:\s+(.*))?$`)
+
+// taskDate matches a Tasks plugin date, the emoji optionally followed by a
+// variation selector.
+var taskDate = regexp.MustCompile(`(📅|⏳|🛫|✅|➕|❌)\x{FE0F}?\s*(\d{4}-\d{2}-\d{2})`)
+
+// taskRecurrence matches "🔁 every week" up to the next signifier.
+var taskRecurrence = regexp.MustCompile(`🔁\x{FE0F}?\s*([^📅⏳🛫✅➕❌🔺⏫🔼🔽⏬]*)`)
+
+// taskPriorities maps the priority emojis to their names.
+var taskPriorities = []struct {
+    emoji string
+    name  string
+}{
+    {"🔺", "highest"},
+    {"⏫", "high"},
+    {"🔼", "medium"},
+    {"🔽", "low"},
+    {"⏬", "lowest"},
+}
+
+// TaskPriorities lists the priority names from highest to lowest.
+var TaskPriorities = []string{"highest", "high", "medium", "low", "lowest"}
+
+// Tasks returns the tasks in body, outside code blocks, in order. Line
+// numbers count from the first line of body.
+func Tasks(body string) []Task {
+    var tasks []Task
+    proseLines(body, func(n int, line string) {
+        match := checkbox.FindStringSubmatch(strings.TrimRight(line, "\r"))
+        if match == nil {
+            return
+        }
+        task := Task{Line: n, Status: taskStatus(match[1])}
+        text := match[2]
+        
+        for _, m := range taskDate.FindAllStringSubmatch(text, -1) {
+            date, err := time.ParseInLocation("2006-01-02", m[2], time.Local)
+            if err != nil {
+                continue
+            }
+            switch m[1] {
+            case "📅":
+                task.Due = date
+            case "⏳":
+                task.Scheduled = date
+            case "🛫":
+                task.Start = date
+            case "✅":
+                task.Done = date
+            case "➕":
+                task.Created = date
+            }
+        }
+        text = taskDate.ReplaceAllString(text, "")
+        
+        if m := taskRecurrence.FindStringSubmatch(text); m != nil {
+            task.Recurs = strings.TrimSpace(m[1])
+            text = taskRecurrence.ReplaceAllString(text, "")
+        }
+        for _, p := range taskPriorities {
+            if strings.Contains(text, p.emoji) {
+                if task.Priority == "" {
+                    task.Priority = p.name
+                }
+                text = strings.ReplaceAll(text, p.emoji, "")
+            }
+        }
+        
+        task.Text = strings.Join(strings.Fields(strings.ReplaceAll(text, "\uFE0F", "")), " ")
+        for _, m := range inlineTag.FindAllStringSubmatch(stripInlineCode(task.Text), -1) {
+            if tag := strings.Trim(m[1], "/"); isTag(tag) {
+                task.Tags = append(task.Tags, tag)
+            }
+        }
+        tasks = append(tasks, task)
+    })
+    return tasks
+}
+
+func taskStatus(symbol string) string {
+    switch symbol {
+    case "x", "X":
+        return TaskDone
+    case "-":
+        return TaskCancelled
+    case "/":
+        return TaskInProgress
+    }
+    return TaskOpen
+}
\ No newline at end of file
@@ -0,0 +1,42 @@
+package markdown
+
+import (
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestTasks(t *testing.T) {
+    body := "# Plan\n" +
+        "- [ ] Book venue #event 📅 2025-06-01 ⏫\n" +
+        "- [x] Send invites ✅ 2025-05-20 ➕ 2025-05-01\n" +
+        "  * [/] Draft agenda ⏳ 2025-05-28 🛫 2025-05-25 🔽\n" +
+        "1. [-] Order cake 🔁 every week 📅 2025-06-02\n" +
+        "> - [?] Ask about parking\n" +
+        "- [ ]\n" +
+        "- not a task\n" +
+        "```\n- [ ] fenced\n```\n"
+    
+    date := func(s string) time.Time {
+        d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+        return d
+    }
+    want := []Task{
+        {Line: 2, Status: TaskOpen, Text: "Book venue #event", Priority: "high", Due: date("2025-06-01"), Tags: []string{"event"}},
+        {Line: 3, Status: TaskDone, Text: "Send invites", Done: date("2025-05-20"), Created: date("2025-05-01")},
+        {Line: 4, Status: TaskInProgress, Text: "Draft agenda", Priority: "low", Scheduled: date("2025-05-28"), Start: date("2025-05-25")},
+        {Line: 5, Status: TaskCancelled, Text: "Order cake", Due: date("2025-06-02"), Recurs: "every week"},
+        {Line: 6, Status: TaskOpen, Text: "Ask about parking"},
+        {Line: 7, Status: TaskOpen},
+    }
+    
+    got := Tasks(body)
+    if len(got) != len(want) {
+        t.Fatalf("Tasks() returned %d tasks, want %d: %+v", len(got), len(want), got)
+    }
+    for i := range want {
+        if !reflect.DeepEqual(got[i], want[i]) {
+            t.Errorf("task %d = %+v, want %+v", i, got[i], want[i])
+        }
+    }
+}
\ No newline at end of file
@@ -3,47 +3,235 @@ package mcp
 import (
     "context"
     "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
     
     "github.com/mark3labs/mcp-go/mcp"
     "github.com/mark3labs/mcp-go/server"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/config"
     "github.com/Atomzwieback/obsidian-search-mcp/internal/index"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/periodic"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/vault"
 )
 
 type SearchHandler struct {
-    index *index.TantivyIndex
+    vaults *vault.Manager
+    config *config.Config
 }
 
-func NewSearchHandler(tantivyIndex *index.TantivyIndex) *SearchHandler {
+func NewSearchHandler(vaults *vault.Manager) *SearchHandler {
     return &SearchHandler{
-        index: tantivyIndex,
+        vaults: vaults,
+        config: config.Default(),
     }
 }
 
+// WithConfig applies result limits and tool enablement from cfg.
+func (h *SearchHandler) WithConfig(cfg *config.Config) *SearchHandler {
+    h.config = cfg
+    return h
+}
+
 func (h *SearchHandler) SetupServer() *server.MCPServer {
     s := server.NewMCPServer(
         "Obsidian Search Server",
         "1.0.0",
         server.WithToolCapabilities(false),
+        server.WithPromptCapabilities(false),
+        server.WithCompletions(),
+        server.WithPromptCompletionProvider(h),
     )
     
     // Search Tool
-    searchTool := mcp.NewTool("search_vault",
+    searchOptions := []mcp.ToolOption{
         mcp.WithDescription("Search for content in Obsidian vault markdown files"),
-        mcp.WithString("query", 
-            mcp.Required(), 
-            mcp.Description("Search query text")),
-        mcp.WithNumber("limit", 
-            mcp.Description("Maximum number of results to return")),
-    )
+        mcp.WithString("query",
+            mcp.Required(),
+            mcp.Description("Search query. "+query.Syntax)),
+        mcp.WithNumber("limit",
+            mcp.Description(fmt.Sprintf("Maximum number of results to return (default %d, at most %d)",
+                h.config.Search.DefaultLimit, h.config.Search.MaxLimit))),
+        mcp.WithNumber("offset",
+            mcp.Description("Number of results to skip, for a new search (default 0)")),
+        mcp.WithString("cursor",
+            mcp.Description("Continuation token from the previous page; pass it with the same query and options to get the next page of the same ranking")),
+        h.vaultParam("Vault to search (default: all vaults)"),
+        mcp.WithBoolean("fuzzy",
+            mcp.Description("Also match words with typos; exact matches still rank first")),
+        mcp.WithNumber("fuzzy_distance",
+            mcp.Description(fmt.Sprintf("Maximum edits per word when fuzzy is set, 1 or 2 (default %d)",
+                h.config.Search.Fuzzy.Distance))),
+        mcp.WithNumber("fuzzy_prefix_length",
+            mcp.Description(fmt.Sprintf("Leading letters that must match exactly when fuzzy is set (default %d)",
+                h.config.Search.Fuzzy.PrefixLength))),
+        mcp.WithObject("boosts",
+            mcp.Description("Field weights for this search, overriding the configured ones: "+h.boostDefaults()+
+                ". A weight of 0 leaves the field out"),
+            mcp.Properties(boostProperties()),
+            mcp.AdditionalProperties(false)),
+        mcp.WithNumber("recency_weight",
+            mcp.Description(fmt.Sprintf("How much recent notes are preferred, 0 to rank by text alone (default %g). "+
+                "Scores are multiplied by 1 + weight × 0.5^(age / %s)",
+                h.config.Search.Recency.Weight, time.Duration(h.config.Search.Recency.HalfLife)))),
+        mcp.WithString("date_from",
+            mcp.Description("Only search periodic notes (daily, weekly, monthly, quarterly and yearly notes, dated by their name) whose period ends on or after this day. Dates are YYYY-MM-DD, today, tomorrow, yesterday or a number of days from today such as -7")),
+        mcp.WithString("date_to",
+            mcp.Description("Only search periodic notes whose period starts on or before this day")),
+        mcp.WithString("folder",
+            mcp.Description("Only search notes below this vault-relative folder")),
+    }
+    if h.config.Embeddings.Enabled() {
+        searchOptions = append(searchOptions, mcp.WithBoolean("hybrid",
+            mcp.Description("Also find notes by meaning, such as \"exhausted at work\" for burnout, and merge them with the keyword results")))
+    }
+    searchTool := mcp.NewTool("search_vault", searchOptions...)
     
-    s.AddTool(searchTool, h.handleSearch)
+    h.addTool(s, searchTool, h.handleSearch)
     
     // Reindex Tool
     reindexTool := mcp.NewTool("reindex_vault",
         mcp.WithDescription("Force reindex of the entire Obsidian vault"),
+        h.vaultParam("Vault to reindex (default: all vaults)"),
+    )
+    
+    h.addTool(s, reindexTool, h.handleReindex)
+    
+    // Suggest Tool
+    suggestTool := mcp.NewTool("suggest_notes",
+        mcp.WithDescription("Complete a partially typed note name: returns notes whose title, alias or file name starts with the text, best matches first"),
+        mcp.WithString("prefix",
+            mcp.Required(),
+            mcp.Description("Beginning of the note name; every word may be abbreviated")),
+        mcp.WithNumber("limit",
+            mcp.Description(fmt.Sprintf("Maximum number of suggestions to return (default %d, at most %d)",
+                h.config.Search.DefaultLimit, h.config.Search.MaxLimit))),
+        h.vaultParam("Vault to look in (default: all vaults)"),
+    )
+    
+    h.addTool(s, suggestTool, h.handleSuggest)
+    
+    // Read Tool
+    readTool := mcp.NewTool("read_note",
+        mcp.WithDescription("Read a note from the Obsidian vault"),
+        mcp.WithString("path",
+            mcp.Required(),
+            mcp.Description("Vault-relative path of the note, the .md extension may be left out, or the name or an alias of the note as in a [[link]]")),
+        h.vaultParam("Vault containing the note (default: the first vault that has it)"),
+    )
+    
+    h.addTool(s, readTool, h.handleRead)
+    
+    // Similar Tool
+    similarTool := mcp.NewTool("find_similar",
+        mcp.WithDescription("Find notes related to a note that it does not link to yet: compares the note's most distinctive words (TF-IDF) with the other notes of its vault and lists the words each result shares with it"),
+        mcp.WithString("path",
+            mcp.Required(),
+            mcp.Description("Vault-relative path of the note, the .md extension may be left out, or the name or an alias of the note as in a [[link]]")),
+        mcp.WithNumber("limit",
+            mcp.Description(fmt.Sprintf("Maximum number of notes to return (default %d, at most %d)",
+                h.config.Search.DefaultLimit, h.config.Search.MaxLimit))),
+        h.vaultParam("Vault containing the note (default: the first vault that has it)"),
+    )
+    
+    h.addTool(s, similarTool, h.handleSimilar)
+    
+    // Tasks Tool
+    tasksTool := mcp.NewTool("query_tasks",
+        mcp.WithDescription("List the Markdown checkbox tasks (- [ ] and - [x]) of the vault with their status, dates from the Tasks plugin format (📅 due, ⏳ scheduled, ✅ done), priority, tags and note. Open tasks come first by due date, undated tasks last"),
+        mcp.WithString("status",
+            mcp.Description("Comma-separated statuses to include: open, in_progress, done, cancelled or all (default open,in_progress)")),
+        mcp.WithString("due_from",
+            mcp.Description("Earliest due date, inclusive. Dates are YYYY-MM-DD, today, tomorrow, yesterday or a number of days from today such as +7 or -3")),
+        mcp.WithString("due_to",
+            mcp.Description("Latest due date, inclusive")),
+        mcp.WithString("scheduled_from",
+            mcp.Description("Earliest scheduled date, inclusive")),
+        mcp.WithString("scheduled_to",
+            mcp.Description("Latest scheduled date, inclusive")),
+        mcp.WithString("done_from",
+            mcp.Description("Earliest completion date, inclusive")),
+        mcp.WithString("done_to",
+            mcp.Description("Latest completion date, inclusive")),
+        mcp.WithString("tags",
+            mcp.Description("Comma-separated tags the task must all have; #project also matches #project/alpha")),
+        mcp.WithString("folder",
+            mcp.Description("Only tasks in notes below this vault-relative folder")),
+        mcp.WithNumber("limit",
+            mcp.Description(fmt.Sprintf("Maximum number of tasks to return (default %d, at most %d)",
+                h.config.Search.DefaultLimit, h.config.Search.MaxLimit))),
+        h.vaultParam("Vault to look in (default: all vaults)"),
+    )
+    
+    h.addTool(s, tasksTool, h.handleTasks)
+    
+    // Properties Tool
+    propertiesTool := mcp.NewTool("query_properties",
+        mcp.WithDescription("Find notes by their frontmatter properties and Dataview inline fields (key:: value, [key:: value]) and list their values. Values are typed as text, number, date, bool or list, so ranges compare numbers and dates, e.g. where \"type = book and rating >= 4\" with sort \"finished desc\""),
+        mcp.WithString("where",
+            mcp.Description("Conditions joined by and: property = value, !=, <, <=, >, >=, contains (text or list item), or property exists / property missing. Dates are YYYY-MM-DD; quote values that contain \"and\". Links match their target, so author = Tolkien finds [[Tolkien]]. Without conditions all notes with properties match")),
+        mcp.WithString("sort",
+            mcp.Description("Property to sort by, optionally followed by asc or desc, such as \"finished desc\"; notes without it come last (default: by path)")),
+        mcp.WithString("fields",
+            mcp.Description("Comma-separated properties to show besides those in where and sort, or * for all (default: all if where and sort are empty)")),
+        mcp.WithString("folder",
+            mcp.Description("Only notes below this vault-relative folder")),
+        mcp.WithNumber("limit",
+            mcp.Description(fmt.Sprintf("Maximum number of notes to return (default %d, at most %d)",
+                h.config.Search.DefaultLimit, h.config.Search.MaxLimit))),
+        h.vaultParam("Vault to look in (default: all vaults)"),
+    )
+    
+    h.addTool(s, propertiesTool, h.handleProperties)
+    
+    // Resolve Tool
+    resolveTool := mcp.NewTool("resolve_reference",
+        mcp.WithDescription("Return the exact text a wiki link points to: a whole note ([[note]]), the section below a heading ([[note#heading]], nested as [[note#heading#subheading]]) or a block marked with a ^block-id ([[note#^id]] or [[note^id]]), with the note and line it starts on"),
+        mcp.WithString("reference",
+            mcp.Required(),
+            mcp.Description("The link as written in the note, with or without brackets, such as [[Plan#Risks]] or Plan^goals")),
+        mcp.WithString("from",
+            mcp.Description("Vault-relative path of the note containing the link; needed for links within a note ([[#heading]]) and used for relative paths")),
+        h.vaultParam("Vault to look in (default: the first vault where the link resolves)"),
     )
     
-    s.AddTool(reindexTool, h.handleReindex)
+    h.addTool(s, resolveTool, h.handleResolve)
+    
+    // Periodic Notes Tool
+    periodicTool := mcp.NewTool("get_periodic_notes",
+        mcp.WithDescription("List the daily, weekly, monthly, quarterly and yearly notes of a date range, such as last week's daily notes, dated by their name as set up in the Daily notes and Periodic Notes plugins. Notes come in date order"),
+        mcp.WithString("from",
+            mcp.Description("First day of the range, inclusive. Dates are YYYY-MM-DD, today, tomorrow, yesterday or a number of days from today such as -7")),
+        mcp.WithString("to",
+            mcp.Description("Last day of the range, inclusive; notes whose period overlaps the range are listed")),
+        mcp.WithString("period",
+            mcp.Description("Comma-separated periods to include: "+strings.Join(periodic.Periods, ", ")+" (default: all)")),
+        mcp.WithBoolean("content",
+            mcp.Description("Also return the text of every note")),
+        mcp.WithNumber("limit",
+            mcp.Description(fmt.Sprintf("Maximum number of notes to return (default %d, at most %d)",
+                h.config.Search.DefaultLimit, h.config.Search.MaxLimit))),
+        h.vaultParam("Vault to look in (default: all vaults)"),
+    )
+    
+    h.addTool(s, periodicTool, h.handlePeriodic)
+    
+    // The prompt form of read_note lets clients complete the path as the
+    // user types, which MCP only supports for prompt arguments
+    if h.config.ToolEnabled("read_note") {
+        readPrompt := mcp.NewPrompt("read_note",
+            mcp.WithPromptDescription("Add a note from the Obsidian vault to the conversation"),
+            mcp.WithArgument("path",
+                mcp.RequiredArgument(),
+                mcp.ArgumentDescription("Vault-relative path of the note")),
+            mcp.WithArgument("vault",
+                mcp.ArgumentDescription("Vault containing the note (default: the first vault that has it)")),
+        )
+        s.AddPrompt(readPrompt, h.handleReadPrompt)
+    }
     
     // Status Resource
     statusResource := mcp.NewResource(
@@ -56,6 +244,26 @@ func (h *SearchHandler) SetupServer() *server.MCPServer {
     return s
 }
 
+// vaultParam is the optional vault selector shared by all tools.
+func (h *SearchHandler) vaultParam(description string) mcp.ToolOption {
+    opts := []mcp.PropertyOption{mcp.Description(description)}
+    var names []string
+    for _, v := range h.vaults.Vaults() {
+        names = append(names, v.Name)
+    }
+    if len(names) > 0 {
+        opts = append(opts, mcp.Enum(names...))
+    }
+    return mcp.WithString("vault", opts...)
+}
+
+// addTool registers tool unless it is disabled in the configuration.
+func (h *SearchHandler) addTool(s *server.MCPServer, tool mcp.Tool, handler server.ToolHandlerFunc) {
+    if h.config.ToolEnabled(tool.Name) {
+        s.AddTool(tool, handler)
+    }
+}
+
 func (h *SearchHandler) handleSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
     // Get parameters
     query, err := request.RequireString("query")
@@ -63,46 +271,639 @@ func (h *SearchHandler) handleSearch(ctx context.Context, request mcp.CallToolRe
         return mcp.NewToolResultError(fmt.Sprintf("Invalid query parameter: %v", err)), nil
     }
     
-    limit := 10
-    // Try to get limit parameter
-    args := request.GetArguments()
-    if limitArg, exists := args["limit"]; exists {
-        if limitFloat, ok := limitArg.(float64); ok && limitFloat > 0 {
-            limit = int(limitFloat)
+    limit := h.limitArg(request)
+    
+    var opts index.SearchOptions
+    if request.GetBool("fuzzy", false) {
+        distance := request.GetInt("fuzzy_distance", h.config.Search.Fuzzy.Distance)
+        if distance < 1 || distance > 2 {
+            return mcp.NewToolResultError(fmt.Sprintf("fuzzy_distance must be 1 or 2 (got %d)", distance)), nil
+        }
+        prefix := request.GetInt("fuzzy_prefix_length", h.config.Search.Fuzzy.PrefixLength)
+        if prefix < 0 {
+            return mcp.NewToolResultError(fmt.Sprintf("fuzzy_prefix_length must not be negative (got %d)", prefix)), nil
+        }
+        opts.Fuzzy = &index.FuzzyOptions{
+            Distance:      distance,
+            PrefixLength:  prefix,
+            MaxExpansions: h.config.Search.Fuzzy.MaxExpansions,
         }
     }
     
+    if request.GetBool("hybrid", false) {
+        if !h.config.Embeddings.Enabled() {
+            return mcp.NewToolResultError("hybrid search needs an embeddings provider in the configuration"), nil
+        }
+        opts.Hybrid = true
+    }
+    
+    if opts.Boosts, err = parseBoosts(request.GetArguments()["boosts"]); err != nil {
+        return mcp.NewToolResultError(err.Error()), nil
+    }
+    if args := request.GetArguments(); args["recency_weight"] != nil {
+        weight := request.GetFloat("recency_weight", 0)
+        if weight < 0 {
+            return mcp.NewToolResultError(fmt.Sprintf("recency_weight must not be negative (got %g)", weight)), nil
+        }
+        opts.Recency = &index.RecencyOptions{
+            Weight:   weight,
+            HalfLife: time.Duration(h.config.Search.Recency.HalfLife),
+            Date:     h.config.Search.Recency.Date,
+        }
+    }
+    
+    today := time.Now()
+    if opts.Dates.From, err = parseDay(request.GetString("date_from", ""), today); err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("date_from: %v", err)), nil
+    }
+    if opts.Dates.To, err = parseDay(request.GetString("date_to", ""), today); err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("date_to: %v", err)), nil
+    }
+    opts.Folder = request.GetString("folder", "")
+    
+    page := vault.Page{
+        Offset: request.GetInt("offset", 0),
+        Limit:  limit,
+        Cursor: request.GetString("cursor", ""),
+    }
+    if page.Offset < 0 {
+        return mcp.NewToolResultError(fmt.Sprintf("offset must not be negative (got %d)", page.Offset)), nil
+    }
+    if page.Offset > 0 && page.Cursor != "" {
+        return mcp.NewToolResultError("pass either offset or cursor, not both"), nil
+    }
+    
     // Perform search
-    results, err := h.index.Search(query, limit)
+    search, err := h.vaults.Search(request.GetString("vault", ""), query, page, opts)
     if err != nil {
         return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
     }
+    results := search.Results
     
     // Format response
+    total := fmt.Sprint(search.Total)
+    if search.TotalCapped {
+        total += "+"
+    }
     var formattedResponse string
-    formattedResponse = fmt.Sprintf("Found %d results for query '%s':\n\n", len(results), query)
+    if search.Offset == 0 && search.NextCursor == "" {
+        formattedResponse = fmt.Sprintf("Found %s results for query '%s':\n\n", total, query)
+    } else if len(results) == 0 {
+        formattedResponse = fmt.Sprintf("Found %s results for query '%s', none from %d on\n\n", total, query, search.Offset+1)
+    } else {
+        formattedResponse = fmt.Sprintf("Found %s results for query '%s', showing %d-%d:\n\n",
+            total, query, search.Offset+1, search.Offset+len(results))
+    }
+    if search.Recomputed {
+        formattedResponse += "Note: the ranking of the previous page expired and the search was run again; results may repeat or be missing if notes changed since.\n\n"
+    }
     
     for i, result := range results {
-        formattedResponse += fmt.Sprintf("%d. %s (Score: %.2f)\n", i+1, result.FilePath, result.Score)
-        formattedResponse += fmt.Sprintf("   Lines: %v\n", result.LineNumbers)
+        formattedResponse += fmt.Sprintf("%d. [%s] %s (Score: %.2f)\n", search.Offset+i+1, result.Vault, result.FilePath, result.Score)
+        if len(result.Aliases) > 0 {
+            formattedResponse += fmt.Sprintf("   Also known as: %s\n", strings.Join(result.Aliases, ", "))
+        }
+        if len(result.Nodes) > 0 {
+            formattedResponse += fmt.Sprintf("   Canvas nodes: %v\n", result.Nodes)
+        } else if len(result.Pages) > 0 {
+            formattedResponse += fmt.Sprintf("   Pages: %v\n", result.Pages)
+        } else {
+            formattedResponse += fmt.Sprintf("   Lines: %v\n", result.LineNumbers)
+        }
+        if len(result.Callouts) > 0 {
+            var callouts []string
+            for _, c := range result.Callouts {
+                callouts = append(callouts, fmt.Sprintf("[%s] %s (L%d)", c.Type, c.Title, c.Line))
+            }
+            formattedResponse += fmt.Sprintf("   Callouts: %s\n", strings.Join(callouts, ", "))
+        }
         formattedResponse += fmt.Sprintf("   Snippet:\n%s\n\n", result.Snippet)
     }
+    if search.NextCursor != "" {
+        formattedResponse += fmt.Sprintf("More results: call search_vault again with cursor %q\n", search.NextCursor)
+    }
+    if search.Suggestion != "" {
+        formattedResponse += fmt.Sprintf("Did you mean: %s\n", search.Suggestion)
+    }
+    formattedResponse += formatFailures(search.Failures)
     
     return mcp.NewToolResultText(formattedResponse), nil
 }
 
+// boostProperties describes the fields accepted by the boosts parameter.
+func boostProperties() map[string]any {
+    props := make(map[string]any, len(config.BoostFields))
+    for _, field := range config.BoostFields {
+        props[field] = map[string]any{"type": "number", "minimum": 0}
+    }
+    return props
+}
+
+// boostDefaults lists the configured field weights for the tool schema.
+func (h *SearchHandler) boostDefaults() string {
+    var parts []string
+    for _, field := range config.BoostFields {
+        boost, ok := h.config.Search.Boosts[field]
+        if !ok {
+            boost = 1
+        }
+        parts = append(parts, fmt.Sprintf("%s %g", field, boost))
+    }
+    return strings.Join(parts, ", ")
+}
+
+// parseBoosts validates the boosts argument of search_vault.
+func parseBoosts(arg interface{}) (map[string]float32, error) {
+    if arg == nil {
+        return nil, nil
+    }
+    values, ok := arg.(map[string]interface{})
+    if !ok {
+        return nil, fmt.Errorf("boosts must be an object of field weights")
+    }
+    
+    boosts := make(map[string]float32, len(values))
+    for field, value := range values {
+        weight, ok := value.(float64)
+        switch {
+        case !contains(config.BoostFields, field):
+            return nil, fmt.Errorf("boosts: unknown field %q (valid: %s)", field, strings.Join(config.BoostFields, ", "))
+        case !ok || weight < 0:
+            return nil, fmt.Errorf("boosts.%s must be a number of at least 0", field)
+        }
+        boosts[field] = float32(weight)
+    }
+    return boosts, nil
+}
+
+func contains(items []string, item string) bool {
+    for _, i := range items {
+        if i == item {
+            return true
+        }
+    }
+    return false
+}
+
+// formatFailures lists the vaults that could not serve a request.
+func formatFailures(failures map[string]error) string {
+    var text string
+    for _, name := range vault.FailedVaults(failures) {
+        text += fmt.Sprintf("Warning: vault '%s' is unavailable: %v\n", name, failures[name])
+    }
+    return text
+}
+
+// limitArg returns the limit argument of request, capped by the configured
+// maximum.
+func (h *SearchHandler) limitArg(request mcp.CallToolRequest) int {
+    limit := request.GetInt("limit", h.config.Search.DefaultLimit)
+    if limit <= 0 {
+        limit = h.config.Search.DefaultLimit
+    }
+    if limit > h.config.Search.MaxLimit {
+        limit = h.config.Search.MaxLimit
+    }
+    return limit
+}
+
+func (h *SearchHandler) handleSuggest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+    prefix, err := request.RequireString("prefix")
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Invalid prefix parameter: %v", err)), nil
+    }
+    
+    suggestions, err := h.vaults.Complete(request.GetString("vault", ""), prefix, h.limitArg(request))
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Suggest failed: %v", err)), nil
+    }
+    
+    text := fmt.Sprintf("Found %d notes matching '%s':\n\n", len(suggestions.Completions), prefix)
+    for i, c := range suggestions.Completions {
+        text += fmt.Sprintf("%d. [%s] %s (%s)\n", i+1, c.Vault, c.Title, c.Path)
+        if c.Match != c.Title {
+            text += fmt.Sprintf("   Matched: %s\n", c.Match)
+        }
+    }
+    text += formatFailures(suggestions.Failures)
+    
+    return mcp.NewToolResultText(text), nil
+}
+
+func (h *SearchHandler) handleRead(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+    path, err := request.RequireString("path")
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Invalid path parameter: %v", err)), nil
+    }
+    
+    _, content, err := h.vaults.ReadNote(request.GetString("vault", ""), path)
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Read failed: %v", err)), nil
+    }
+    
+    return mcp.NewToolResultText(content), nil
+}
+
+func (h *SearchHandler) handleSimilar(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+    path, err := request.RequireString("path")
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Invalid path parameter: %v", err)), nil
+    }
+    
+    notes, err := h.vaults.Similar(request.GetString("vault", ""), path, h.limitArg(request))
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Find similar failed: %v", err)), nil
+    }
+    
+    text := fmt.Sprintf("Found %d unlinked notes similar to '%s':\n\n", len(notes), path)
+    for i, note := range notes {
+        text += fmt.Sprintf("%d. [%s] %s (Score: %.2f)\n", i+1, note.Vault, note.FilePath, note.Score)
+        text += fmt.Sprintf("   Shared terms: %s\n", strings.Join(note.SharedTerms, ", "))
+    }
+    
+    return mcp.NewToolResultText(text), nil
+}
+
+func (h *SearchHandler) handleResolve(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+    ref, err := request.RequireString("reference")
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Invalid reference parameter: %v", err)), nil
+    }
+    
+    r, err := h.vaults.Resolve(request.GetString("vault", ""), ref, request.GetString("from", ""))
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Resolve reference failed: %v", err)), nil
+    }
+    
+    target := r.Path
+    if r.Subpath != "" {
+        target += "#" + r.Subpath
+    }
+    text := fmt.Sprintf("[%s] %s (line %d):\n\n%s", r.Vault, target, r.Line, r.Text)
+    return mcp.NewToolResultText(text), nil
+}
+
+func (h *SearchHandler) handleTasks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+    today := time.Now()
+    q := index.TaskQuery{
+        Tags:   splitArg(request.GetString("tags", "")),
+        Folder: request.GetString("folder", ""),
+    }
+    
+    switch statuses := splitArg(request.GetString("status", "")); {
+    case len(statuses) == 0:
+        q.Statuses = []string{markdown.TaskOpen, markdown.TaskInProgress}
+    case len(statuses) == 1 && statuses[0] == "all":
+    default:
+        for _, status := range statuses {
+            if !contains(markdown.TaskStatuses, status) {
+                return mcp.NewToolResultError(fmt.Sprintf("unknown status %q (valid: %s, all)",
+                    status, strings.Join(markdown.TaskStatuses, ", "))), nil
+            }
+        }
+        q.Statuses = statuses
+    }
+    
+    ranges := []struct {
+        name  string
+        field *index.DateRange
+    }{
+        {"due", &q.Due},
+        {"scheduled", &q.Scheduled},
+        {"done", &q.Done},
+    }
+    for _, r := range ranges {
+        var err error
+        if r.field.From, err = parseDay(request.GetString(r.name+"_from", ""), today); err != nil {
+            return mcp.NewToolResultError(fmt.Sprintf("%s_from: %v", r.name, err)), nil
+        }
+        if r.field.To, err = parseDay(request.GetString(r.name+"_to", ""), today); err != nil {
+            return mcp.NewToolResultError(fmt.Sprintf("%s_to: %v", r.name, err)), nil
+        }
+    }
+    
+    tasks, err := h.vaults.Tasks(request.GetString("vault", ""), q, h.limitArg(request))
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Query tasks failed: %v", err)), nil
+    }
+    
+    text := fmt.Sprintf("Found %d tasks (today is %s)", tasks.Total, today.Format("2006-01-02"))
+    if len(tasks.Tasks) < tasks.Total {
+        text += fmt.Sprintf(", showing the first %d", len(tasks.Tasks))
+    }
+    text += ":\n\n"
+    for i, task := range tasks.Tasks {
+        text += fmt.Sprintf("%d. %s %s\n", i+1, taskBox(task.Status), task.Text)
+        if details := taskDetails(task.Task); details != "" {
+            text += fmt.Sprintf("   %s\n", details)
+        }
+        text += fmt.Sprintf("   [%s] %s:%d\n", task.Vault, task.Path, task.Line)
+    }
+    text += formatFailures(tasks.Failures)
+    
+    return mcp.NewToolResultText(text), nil
+}
+
+func (h *SearchHandler) handlePeriodic(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+    today := time.Now()
+    var q index.PeriodicQuery
+    for _, period := range splitArg(strings.ToLower(request.GetString("period", ""))) {
+        if !contains(periodic.Periods, period) {
+            return mcp.NewToolResultError(fmt.Sprintf("unknown period %q (valid: %s)",
+                period, strings.Join(periodic.Periods, ", "))), nil
+        }
+        q.Periods = append(q.Periods, period)
+    }
+    
+    var err error
+    if q.Dates.From, err = parseDay(request.GetString("from", ""), today); err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("from: %v", err)), nil
+    }
+    if q.Dates.To, err = parseDay(request.GetString("to", ""), today); err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("to: %v", err)), nil
+    }
+    
+    notes, err := h.vaults.PeriodicNotes(request.GetString("vault", ""), q, h.limitArg(request))
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Get periodic notes failed: %v", err)), nil
+    }
+    
+    text := fmt.Sprintf("Found %d periodic notes (today is %s)", notes.Total, today.Format("2006-01-02"))
+    if len(notes.Notes) < notes.Total {
+        text += fmt.Sprintf(", showing the first %d", len(notes.Notes))
+    }
+    text += ":\n\n"
+    for i, note := range notes.Notes {
+        days := note.Start.Format("2006-01-02")
+        if !note.End.Equal(note.Start) {
+            days += " to " + note.End.Format("2006-01-02")
+        }
+        text += fmt.Sprintf("%d. [%s] %s (%s, %s)\n", i+1, note.Vault, note.Path, note.Period, days)
+        if request.GetBool("content", false) {
+            if _, content, err := h.vaults.ReadNote(note.Vault, note.Path); err == nil {
+                text += fmt.Sprintf("\n%s\n\n", strings.TrimSpace(content))
+            }
+        }
+    }
+    text += formatFailures(notes.Failures)
+    
+    return mcp.NewToolResultText(text), nil
+}
+
+// splitArg splits a comma-separated argument into its trimmed, non-empty
+// items.
+func splitArg(value string) []string {
+    var items []string
+    for _, item := range strings.Split(value, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
+
+// parseDay parses a day given as YYYY-MM-DD, today, tomorrow, yesterday
+// or a number of days from today such as +7. An empty value is the zero
+// time.
+func parseDay(value string, today time.Time) (time.Time, error) {
+    value = strings.ToLower(strings.TrimSpace(value))
+    midnight := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+    switch value {
+    case "":
+        return time.Time{}, nil
+    case "today":
+        return midnight, nil
+    case "tomorrow":
+        return midnight.AddDate(0, 0, 1), nil
+    case "yesterday":
+        return midnight.AddDate(0, 0, -1), nil
+    }
+    if days, err := strconv.Atoi(value); err == nil && (value[0] == '+' || value[0] == '-') {
+        return midnight.AddDate(0, 0, days), nil
+    }
+    day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+    if err != nil {
+        return time.Time{}, fmt.Errorf("expected YYYY-MM-DD, today, tomorrow, yesterday or +N days, got %q", value)
+    }
+    return day, nil
+}
+
+// taskBox renders a status as the checkbox it was written with.
+func taskBox(status string) string {
+    switch status {
+    case markdown.TaskDone:
+        return "[x]"
+    case markdown.TaskCancelled:
+        return "[-]"
+    case markdown.TaskInProgress:
+        return "[/]"
+    }
+    return "[ ]"
+}
+
+// taskDetails lists the dates and priority of a task.
+func taskDetails(task markdown.Task) string {
+    var details []string
+    dates := []struct {
+        label string
+        date  time.Time
+    }{
+        {"due", task.Due},
+        {"scheduled", task.Scheduled},
+        {"starts", task.Start},
+        {"done", task.Done},
+    }
+    for _, d := range dates {
+        if !d.date.IsZero() {
+            details = append(details, d.label+" "+d.date.Format("2006-01-02"))
+        }
+    }
+    if task.Priority != "" {
+        details = append(details, "priority "+task.Priority)
+    }
+    if task.Recurs != "" {
+        details = append(details, "recurs "+task.Recurs)
+    }
+    return strings.Join(details, ", ")
+}
+
+func (h *SearchHandler) handleProperties(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+    filters, err := index.ParsePropertyFilters(request.GetString("where", ""))
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Invalid where: %v", err)), nil
+    }
+    q := index.PropertyQuery{
+        Filters: filters,
+        Folder:  request.GetString("folder", ""),
+    }
+    
+    switch words := strings.Fields(request.GetString("sort", "")); {
+    case len(words) == 0:
+    case len(words) == 1, len(words) == 2 && strings.EqualFold(words[1], "asc"):
+        q.Sort = words[0]
+    case len(words) == 2 && strings.EqualFold(words[1], "desc"):
+        q.Sort, q.Desc = words[0], true
+    default:
+        return mcp.NewToolResultError(fmt.Sprintf("Invalid sort %q: expected a property, optionally followed by asc or desc", request.GetString("sort", ""))), nil
+    }
+    
+    // Show the properties the query is about, unless asked for more
+    fields := splitArg(request.GetString("fields", ""))
+    all := len(fields) == 0 && len(filters) == 0 && q.Sort == ""
+    var shown []string
+    for _, field := range fields {
+        if field == "*" {
+            all = true
+        }
+        shown = append(shown, markdown.PropertyKey(field))
+    }
+    for _, f := range filters {
+        shown = append(shown, f.Key)
+    }
+    if q.Sort != "" {
+        shown = append(shown, markdown.PropertyKey(q.Sort))
+    }
+    
+    notes, err := h.vaults.Properties(request.GetString("vault", ""), q, h.limitArg(request))
+    if err != nil {
+        return mcp.NewToolResultError(fmt.Sprintf("Query properties failed: %v", err)), nil
+    }
+    
+    text := fmt.Sprintf("Found %d notes", notes.Total)
+    if len(notes.Notes) < notes.Total {
+        text += fmt.Sprintf(", showing the first %d", len(notes.Notes))
+    }
+    text += ":\n\n"
+    for i, note := range notes.Notes {
+        text += fmt.Sprintf("%d. [%s] %s\n", i+1, note.Vault, note.Path)
+        for _, key := range propertyKeys(note.Properties, shown, all) {
+            value := note.Properties[key]
+            text += fmt.Sprintf("   %s: %s (%s)\n", key, value, value.Type)
+        }
+    }
+    text += formatFailures(notes.Failures)
+    
+    return mcp.NewToolResultText(text), nil
+}
+
+// propertyKeys returns the keys of props to show: all of them in order, or
+// those of shown that the note has, once each and in the order given.
+func propertyKeys(props map[string]markdown.Value, shown []string, all bool) []string {
+    var keys []string
+    if all {
+        for key := range props {
+            keys = append(keys, key)
+        }
+        sort.Strings(keys)
+        return keys
+    }
+    for _, key := range shown {
+        if _, ok := props[key]; ok && !contains(keys, key) {
+            keys = append(keys, key)
+        }
+    }
+    return keys
+}
+
+func (h *SearchHandler) handleReadPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+    path := request.Params.Arguments["path"]
+    if path == "" {
+        return nil, fmt.Errorf("missing required argument: path")
+    }
+    
+    v, content, err := h.vaults.ReadNote(request.Params.Arguments["vault"], path)
+    if err != nil {
+        return nil, err
+    }
+    
+    return mcp.NewGetPromptResult(
+        fmt.Sprintf("Note %s from vault %s", path, v.Name),
+        []mcp.PromptMessage{
+            mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(content)),
+        },
+    ), nil
+}
+
+// maxCompletions is the most values a completion/complete response may hold.
+const maxCompletions = 100
+
+// CompletePromptArgument completes the arguments of the read_note prompt:
+// note paths by title, alias or file name, and vault names.
+func (h *SearchHandler) CompletePromptArgument(ctx context.Context, promptName string, argument mcp.CompleteArgument, resolved mcp.CompleteContext) (*mcp.Completion, error) {
+    completion := &mcp.Completion{Values: []string{}}
+    if promptName != "read_note" {
+        return completion, nil
+    }
+    
+    switch argument.Name {
+    case "path":
+        // One more than fits tells whether there are more
+        found, err := h.vaults.Complete(resolved.Arguments["vault"], argument.Value, maxCompletions+1)
+        if err != nil {
+            return nil, err
+        }
+        seen := make(map[string]bool)
+        for _, c := range found.Completions {
+            if !seen[c.Path] {
+                seen[c.Path] = true
+                completion.Values = append(completion.Values, c.Path)
+            }
+        }
+    case "vault":
+        for _, v := range h.vaults.Vaults() {
+            if strings.HasPrefix(strings.ToLower(v.Name), strings.ToLower(argument.Value)) {
+                completion.Values = append(completion.Values, v.Name)
+            }
+        }
+    }
+    
+    if len(completion.Values) > maxCompletions {
+        completion.Values = completion.Values[:maxCompletions]
+        completion.HasMore = true
+    } else {
+        completion.Total = len(completion.Values)
+    }
+    return completion, nil
+}
+
 func (h *SearchHandler) handleReindex(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-    // TODO: Implement reindexing logic
-    return mcp.NewToolResultText("Reindexing started..."), nil
+    vaults, err := h.vaults.Select(request.GetString("vault", ""))
+    if err != nil {
+        return mcp.NewToolResultError(err.Error()), nil
+    }
+    
+    var text string
+    failures := make(map[string]error)
+    for _, v := range vaults {
+        if err := h.vaults.Reindex(v); err != nil {
+            failures[v.Name] = err
+            continue
+        }
+        text += fmt.Sprintf("Reindexed vault '%s': %d files\n", v.Name, v.Stats().IndexedFiles)
+    }
+    text += formatFailures(failures)
+    
+    return mcp.NewToolResultText(text), nil
 }
 
 func (h *SearchHandler) handleStatus(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
     // Get status information
-    indexedFiles := h.index.GetIndexedFilesCount()
-    
-    statusText := fmt.Sprintf("Index Status:\n"+
-        "- Status: operational\n"+
-        "- Indexed files: %d\n", indexedFiles)
+    statusText := "Index Status:\n"
+    for _, v := range h.vaults.Vaults() {
+        stats := v.Stats()
+        status := "operational"
+        if stats.Error != "" {
+            status = "error: " + stats.Error
+        }
+        
+        statusText += fmt.Sprintf("\nVault: %s\n"+
+            "- Path: %s\n"+
+            "- Status: %s\n"+
+            "- Indexed files: %d\n", stats.Name, stats.Path, status, stats.IndexedFiles)
+        if !stats.LastIndexed.IsZero() {
+            statusText += fmt.Sprintf("- Last indexed: %s\n", stats.LastIndexed.Format(time.RFC3339))
+        }
+    }
     
     return []mcp.ResourceContents{
         &mcp.TextResourceContents{
@@ -1,7 +1,16 @@
 package mcp
 
 import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "strings"
     "testing"
+    "time"
+    
+    "github.com/mark3labs/mcp-go/server"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/config"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/markdown"
 )
 
 func TestNewSearchHandler(t *testing.T) {
@@ -11,8 +20,8 @@ func TestNewSearchHandler(t *testing.T) {
         t.Fatal("Expected handler to be created")
     }
     
-    if handler.index != nil {
-        t.Error("Expected index to be nil")
+    if handler.vaults != nil {
+        t.Error("Expected vaults to be nil")
     }
 }
 
@@ -27,4 +36,246 @@ func TestSetupServer(t *testing.T) {
     // Verify server has the expected tools
     // Note: mcp-go doesn't expose a way to check registered tools directly
     // so we just verify the server was created successfully
+}
+
+// listTools returns the names of the tools registered on s.
+func listTools(t *testing.T, s *server.MCPServer) []string {
+    t.Helper()
+    response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+    
+    data, _ := json.Marshal(response)
+    var decoded struct {
+        Result struct {
+            Tools []struct {
+                Name string `json:"name"`
+            } `json:"tools"`
+        } `json:"result"`
+    }
+    if err := json.Unmarshal(data, &decoded); err != nil {
+        t.Fatalf("Failed to decode tools/list response: %v", err)
+    }
+    
+    var names []string
+    for _, tool := range decoded.Result.Tools {
+        names = append(names, tool.Name)
+    }
+    return names
+}
+
+func TestDisabledTools(t *testing.T) {
+    cfg := config.Default()
+    cfg.Tools["reindex_vault"] = false
+    
+    tools := listTools(t, NewSearchHandler(nil).WithConfig(cfg).SetupServer())
+    for _, name := range tools {
+        if name == "reindex_vault" {
+            t.Error("Expected reindex_vault to be disabled")
+        }
+    }
+    if len(tools) != len(config.ToolNames)-1 {
+        t.Errorf("Expected %d tools, got %v", len(config.ToolNames)-1, tools)
+    }
+}
+
+func TestToolNamesAreConfigurable(t *testing.T) {
+    // Every registered tool must be known to the config validation
+    for _, name := range listTools(t, NewSearchHandler(nil).SetupServer()) {
+        if !config.Default().ToolEnabled(name) || !contains(config.ToolNames, name) {
+            t.Errorf("Tool %s is missing from config.ToolNames", name)
+        }
+    }
+}
+
+// complete sends a completion/complete request for an argument of the
+// read_note prompt and returns the decoded response.
+func complete(t *testing.T, s *server.MCPServer, argument, value string) (values []string, errMsg string) {
+    t.Helper()
+    request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"completion/complete","params":`+
+        `{"ref":{"type":"ref/prompt","name":"read_note"},"argument":{"name":%q,"value":%q}}}`, argument, value)
+    response := s.HandleMessage(context.Background(), json.RawMessage(request))
+    
+    data, _ := json.Marshal(response)
+    var decoded struct {
+        Result struct {
+            Completion struct {
+                Values []string `json:"values"`
+            } `json:"completion"`
+        } `json:"result"`
+        Error *struct {
+            Message string `json:"message"`
+        } `json:"error"`
+    }
+    if err := json.Unmarshal(data, &decoded); err != nil {
+        t.Fatalf("Failed to decode completion/complete response: %v", err)
+    }
+    if decoded.Error != nil {
+        return nil, decoded.Error.Message
+    }
+    return decoded.Result.Completion.Values, ""
+}
+
+func TestCompletePromptArgument(t *testing.T) {
+    s := NewSearchHandler(nil).SetupServer()
+    
+    // Without vaults there is nothing to complete, but the request succeeds
+    values, errMsg := complete(t, s, "path", "Road")
+    if errMsg != "" {
+        t.Fatalf("completion/complete failed: %s", errMsg)
+    }
+    if len(values) != 0 {
+        t.Errorf("Expected no completions, got %v", values)
+    }
+}
+
+func TestReadNotePromptFollowsTool(t *testing.T) {
+    listPrompts := func(cfg *config.Config) string {
+        s := NewSearchHandler(nil).WithConfig(cfg).SetupServer()
+        response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`))
+        data, _ := json.Marshal(response)
+        return string(data)
+    }
+    
+    if prompts := listPrompts(config.Default()); !strings.Contains(prompts, `"read_note"`) {
+        t.Errorf("Expected a read_note prompt, got %s", prompts)
+    }
+    
+    cfg := config.Default()
+    cfg.Tools["read_note"] = false
+    if prompts := listPrompts(cfg); strings.Contains(prompts, `"read_note"`) {
+        t.Errorf("Expected no read_note prompt when the tool is disabled, got %s", prompts)
+    }
+}
+
+func TestParseBoosts(t *testing.T) {
+    boosts, err := parseBoosts(map[string]interface{}{"title": 3.0, "content": 0.0})
+    if err != nil {
+        t.Fatalf("parseBoosts failed: %v", err)
+    }
+    if boosts["title"] != 3 || boosts["content"] != 0 || len(boosts) != 2 {
+        t.Errorf("Unexpected boosts: %v", boosts)
+    }
+    
+    for _, arg := range []interface{}{
+        "title=3",
+        map[string]interface{}{"body": 1.0},
+        map[string]interface{}{"title": -1.0},
+        map[string]interface{}{"title": "high"},
+    } {
+        if _, err := parseBoosts(arg); err == nil {
+            t.Errorf("Expected parseBoosts(%v) to fail", arg)
+        }
+    }
+}
+
+func TestHybridParamFollowsEmbeddings(t *testing.T) {
+    describeTools := func(cfg *config.Config) string {
+        s := NewSearchHandler(nil).WithConfig(cfg).SetupServer()
+        response := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+        data, _ := json.Marshal(response)
+        return string(data)
+    }
+    
+    if tools := describeTools(config.Default()); strings.Contains(tools, `"hybrid"`) {
+        t.Errorf("Expected no hybrid parameter without embeddings, got %s", tools)
+    }
+    
+    cfg := config.Default()
+    cfg.Embeddings.Provider = "ollama"
+    if tools := describeTools(cfg); !strings.Contains(tools, `"hybrid"`) {
+        t.Errorf("Expected a hybrid parameter with embeddings, got %s", tools)
+    }
+}
+
+func TestSearchPageArguments(t *testing.T) {
+    s := NewSearchHandler(nil).WithConfig(config.Default()).SetupServer()
+    call := func(arguments string) string {
+        message := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"search_vault","arguments":%s}}`, arguments)
+        data, _ := json.Marshal(s.HandleMessage(context.Background(), json.RawMessage(message)))
+        return string(data)
+    }
+    
+    if out := call(`{"query":"garden","offset":10,"cursor":"abc"}`); !strings.Contains(out, "either offset or cursor") {
+        t.Errorf("Expected offset and cursor to be exclusive, got %s", out)
+    }
+    if out := call(`{"query":"garden","offset":-1}`); !strings.Contains(out, "offset must not be negative") {
+        t.Errorf("Expected a negative offset to be rejected, got %s", out)
+    }
+}
+
+func TestPropertiesArguments(t *testing.T) {
+    s := NewSearchHandler(nil).WithConfig(config.Default()).SetupServer()
+    call := func(arguments string) string {
+        message := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"query_properties","arguments":%s}}`, arguments)
+        data, _ := json.Marshal(s.HandleMessage(context.Background(), json.RawMessage(message)))
+        return string(data)
+    }
+    
+    if out := call(`{"where":"rating >="}`); !strings.Contains(out, "Invalid where") {
+        t.Errorf("Expected an incomplete condition to be rejected, got %s", out)
+    }
+    if out := call(`{"sort":"finished sideways"}`); !strings.Contains(out, "Invalid sort") {
+        t.Errorf("Expected an unknown sort direction to be rejected, got %s", out)
+    }
+}
+
+func TestPeriodicArguments(t *testing.T) {
+    s := NewSearchHandler(nil).WithConfig(config.Default()).SetupServer()
+    call := func(tool, arguments string) string {
+        message := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":%q,"arguments":%s}}`, tool, arguments)
+        data, _ := json.Marshal(s.HandleMessage(context.Background(), json.RawMessage(message)))
+        return string(data)
+    }
+    
+    if out := call("get_periodic_notes", `{"period":"daily,fortnightly"}`); !strings.Contains(out, `unknown period \"fortnightly\"`) {
+        t.Errorf("Expected an unknown period to be rejected, got %s", out)
+    }
+    if out := call("get_periodic_notes", `{"from":"last week"}`); !strings.Contains(out, "from: expected YYYY-MM-DD") {
+        t.Errorf("Expected an invalid day to be rejected, got %s", out)
+    }
+    if out := call("search_vault", `{"query":"retro","date_to":"06/08"}`); !strings.Contains(out, "date_to: expected YYYY-MM-DD") {
+        t.Errorf("Expected an invalid search day to be rejected, got %s", out)
+    }
+}
+
+func TestPropertyKeys(t *testing.T) {
+    props := map[string]markdown.Value{
+        "type":     markdown.ParseValue("book"),
+        "rating":   markdown.ParseValue("4"),
+        "finished": markdown.ParseValue("2025-03-01"),
+    }
+    if got := propertyKeys(props, []string{"rating", "missing", "type", "rating"}, false); strings.Join(got, ",") != "rating,type" {
+        t.Errorf("Expected the shown keys the note has, once each, got %q", got)
+    }
+    if got := propertyKeys(props, nil, true); strings.Join(got, ",") != "finished,rating,type" {
+        t.Errorf("Expected all keys in order, got %q", got)
+    }
+}
+
+func TestParseDay(t *testing.T) {
+    today := time.Date(2025, 6, 4, 15, 30, 0, 0, time.Local)
+    tests := map[string]string{
+        "":           "0001-01-01",
+        "today":      "2025-06-04",
+        "Tomorrow":   "2025-06-05",
+        "yesterday":  "2025-06-03",
+        "+7":         "2025-06-11",
+        "-4":         "2025-05-31",
+        "2025-12-24": "2025-12-24",
+    }
+    for value, want := range tests {
+        got, err := parseDay(value, today)
+        if err != nil {
+            t.Errorf("parseDay(%q) failed: %v", value, err)
+            continue
+        }
+        if got.Format("2006-01-02") != want {
+            t.Errorf("parseDay(%q) = %s, want %s", value, got.Format("2006-01-02"), want)
+        }
+    }
+    
+    for _, value := range []string{"next week", "7", "2025-13-01"} {
+        if _, err := parseDay(value, today); err == nil {
+            t.Errorf("Expected parseDay(%q) to fail", value)
+        }
+    }
 }
\ No newline at end of file
@@ -0,0 +1,427 @@
+package periodic
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "slices"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// The periods a note can be about.
+const (
+    Daily     = "daily"
+    Weekly    = "weekly"
+    Monthly   = "monthly"
+    Quarterly = "quarterly"
+    Yearly    = "yearly"
+)
+
+// Periods lists the periods from the shortest to the longest.
+var Periods = []string{Daily, Weekly, Monthly, Quarterly, Yearly}
+
+// defaultFormats are the formats Obsidian uses for a period when none is
+// set.
+var defaultFormats = map[string]string{
+    Daily:     "YYYY-MM-DD",
+    Weekly:    "gggg-[W]ww",
+    Monthly:   "YYYY-MM",
+    Quarterly: "YYYY-[Q]Q",
+    Yearly:    "YYYY",
+}
+
+// Format is how the notes of a period are named: Folder is the vault folder
+// they are in, "" for the root, and Format the Moment.js date format of
+// their path below it without the extension, such as "YYYY/MM/YYYY-MM-DD".
+type Format struct {
+    Period string
+    Folder string
+    Format string
+    
+    // pattern matches a path of the format, with a group for every
+    // token in fields
+    pattern *regexp.Regexp
+    fields  []string
+    depth   int
+}
+
+// Date is the period a periodic note is about, from its first day Start to
+// its last day End.
+type Date struct {
+    Period string
+    Start  time.Time
+    End    time.Time
+}
+
+// NewFormat compiles the format of the notes of period. An empty format is
+// the default of the period.
+func NewFormat(period, folder, format string) (Format, error) {
+    if !slices.Contains(Periods, period) {
+        return Format{}, fmt.Errorf("unknown period %q", period)
+    }
+    if strings.TrimSpace(format) == "" {
+        format = defaultFormats[period]
+    }
+    f := Format{
+        Period: period,
+        Folder: strings.Trim(filepath.ToSlash(strings.TrimSpace(folder)), "/"),
+        Format: format,
+    }
+    
+    var b strings.Builder
+    b.WriteString("(?i)^")
+    for rest := format; rest != ""; {
+        if rest[0] == '[' {
+            end := strings.IndexByte(rest, ']')
+            if end < 0 {
+                return Format{}, fmt.Errorf("format %q: unclosed [", format)
+            }
+            literal := rest[1:end]
+            b.WriteString(regexp.QuoteMeta(literal))
+            f.depth += strings.Count(literal, "/")
+            rest = rest[end+1:]
+            continue
+        }
+        t, ok := matchToken(rest)
+        if !ok {
+            if rest[0] == '/' {
+                f.depth++
+            }
+            b.WriteString(regexp.QuoteMeta(rest[:1]))
+            rest = rest[1:]
+            continue
+        }
+        b.WriteString(t.pattern)
+        if t.field != "" {
+            f.fields = append(f.fields, t.field)
+        }
+        rest = rest[len(t.name):]
+    }
+    b.WriteString("$")
+    if !slices.ContainsFunc(f.fields, func(field string) bool { return strings.HasSuffix(field, "year") || field == "year2" }) {
+        return Format{}, fmt.Errorf("format %q has no year", format)
+    }
+    f.pattern = regexp.MustCompile(b.String())
+    return f, nil
+}
+
+// token is a Moment.js format token: the pattern of its text and the field
+// of the date it holds, none for weekday names.
+type token struct {
+    name    string
+    pattern string
+    field   string
+}
+
+var (
+    months    = "January|February|March|April|May|June|July|August|September|October|November|December"
+    shortDays = "Sun|Mon|Tue|Wed|Thu|Fri|Sat"
+    
+    // tokens lists longer tokens before their prefixes.
+    tokens = []token{
+        {"YYYY", `(\d{4})`, "year"},
+        {"YY", `(\d{2})`, "year2"},
+        {"gggg", `(\d{4})`, "weekyear"},
+        {"GGGG", `(\d{4})`, "isoweekyear"},
+        {"MMMM", "(" + months + ")", "monthname"},
+        {"MMM", `(Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)`, "monthname"},
+        {"MM", `(\d{2})`, "month"},
+        {"M", `(\d{1,2})`, "month"},
+        {"DDDD", `(\d{3})`, "yearday"},
+        {"DDD", `(\d{1,3})`, "yearday"},
+        {"Do", `(\d{1,2})(?:st|nd|rd|th)`, "day"},
+        {"DD", `(\d{2})`, "day"},
+        {"D", `(\d{1,2})`, "day"},
+        {"dddd", `(?:Sunday|Monday|Tuesday|Wednesday|Thursday|Friday|Saturday)`, ""},
+        {"ddd", "(?:" + shortDays + ")", ""},
+        {"dd", `(?:Su|Mo|Tu|We|Th|Fr|Sa)`, ""},
+        {"d", `[0-6]`, ""},
+        {"ww", `(\d{2})`, "week"},
+        {"w", `(\d{1,2})`, "week"},
+        {"WW", `(\d{2})`, "isoweek"},
+        {"W", `(\d{1,2})`, "isoweek"},
+        {"Q", `([1-4])`, "quarter"},
+    }
+)
+
+// matchToken returns the token s starts with.
+func matchToken(s string) (token, bool) {
+    for _, t := range tokens {
+        if strings.HasPrefix(s, t.name) {
+            return t, true
+        }
+    }
+    return token{}, false
+}
+
+// Parse returns the period of the note at path, a vault path without the
+// extension, if its name follows the format. Notes in folders below the
+// folder of the format are periodic notes as well.
+func (f Format) Parse(path string) (Date, bool) {
+    rest := path
+    if f.Folder != "" {
+        if !strings.HasPrefix(path, f.Folder+"/") {
+            return Date{}, false
+        }
+        rest = path[len(f.Folder)+1:]
+    }
+    parts := strings.Split(rest, "/")
+    if len(parts) <= f.depth {
+        return Date{}, false
+    }
+    match := f.pattern.FindStringSubmatch(strings.Join(parts[len(parts)-1-f.depth:], "/"))
+    if match == nil {
+        return Date{}, false
+    }
+    
+    values := make(map[string]int)
+    for i, field := range f.fields {
+        text := match[i+1]
+        if field == "monthname" {
+            for m, name := range strings.Split(months, "|") {
+                if strings.HasPrefix(strings.ToLower(name), strings.ToLower(text)) {
+                    values["month"] = m + 1
+                    break
+                }
+            }
+            continue
+        }
+        n, _ := strconv.Atoi(text)
+        if field == "year2" {
+            // Moment reads 69 to 99 as 1969 to 1999
+            field, n = "year", n+2000
+            if n > 2068 {
+                n -= 100
+            }
+        }
+        values[field] = n
+    }
+    
+    start, ok := startDay(values)
+    if !ok {
+        return Date{}, false
+    }
+    switch f.Period {
+    case Monthly:
+        start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.Local)
+    case Quarterly:
+        start = time.Date(start.Year(), (start.Month()-1)/3*3+1, 1, 0, 0, 0, 0, time.Local)
+    case Yearly:
+        start = time.Date(start.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+    }
+    return Date{Period: f.Period, Start: start, End: periodEnd(f.Period, start)}, true
+}
+
+// startDay returns the first day that the fields of a note name give,
+// in the order of precision: a day, a week, a month, a quarter or a year.
+func startDay(values map[string]int) (time.Time, bool) {
+    year, hasYear := values["year"]
+    day := func(month, d int) (time.Time, bool) {
+        t := time.Date(year, time.Month(month), d, 0, 0, 0, 0, time.Local)
+        return t, t.Month() == time.Month(month) && t.Day() == d
+    }
+    
+    switch {
+    case values["day"] > 0 && values["month"] > 0 && hasYear:
+        return day(values["month"], values["day"])
+    case values["yearday"] > 0 && hasYear:
+        t := time.Date(year, 1, values["yearday"], 0, 0, 0, 0, time.Local)
+        return t, t.Year() == year
+    case values["isoweek"] > 0:
+        y, ok := values["isoweekyear"]
+        if !ok {
+            y = year
+        }
+        return weekStart(y, values["isoweek"], time.Monday, 4)
+    case values["week"] > 0:
+        y, ok := values["weekyear"]
+        if !ok {
+            y = year
+        }
+        return weekStart(y, values["week"], time.Sunday, 1)
+    case !hasYear:
+        return time.Time{}, false
+    case values["month"] > 0:
+        return day(values["month"], 1)
+    case values["quarter"] > 0:
+        return day((values["quarter"]-1)*3+1, 1)
+    }
+    return day(1, 1)
+}
+
+// weekStart returns the first day of week of year for weeks that start on
+// first, where week 1 is the week with January jan in it. ISO weeks start
+// on Monday and contain January 4; Moment's default locale weeks start on
+// Sunday and contain January 1.
+func weekStart(year, week int, first time.Weekday, jan int) (time.Time, bool) {
+    if week < 1 || week > 53 {
+        return time.Time{}, false
+    }
+    anchor := time.Date(year, 1, jan, 0, 0, 0, 0, time.Local)
+    offset := (int(anchor.Weekday()) - int(first) + 7) % 7
+    start := anchor.AddDate(0, 0, -offset+(week-1)*7)
+    
+    // Week 53 only exists in years that have it
+    next := time.Date(year+1, 1, jan, 0, 0, 0, 0, time.Local)
+    next = next.AddDate(0, 0, -((int(next.Weekday()) - int(first) + 7) % 7))
+    return start, start.Before(next)
+}
+
+// periodEnd returns the last day of the period that starts on start.
+func periodEnd(period string, start time.Time) time.Time {
+    switch period {
+    case Weekly:
+        return start.AddDate(0, 0, 6)
+    case Monthly:
+        return start.AddDate(0, 1, -1)
+    case Quarterly:
+        return start.AddDate(0, 3, -1)
+    case Yearly:
+        return start.AddDate(1, 0, -1)
+    }
+    return start
+}
+
+// Match returns the period of the note at path, a vault path without the
+// extension, by the first format that fits.
+func Match(formats []Format, path string) (Date, bool) {
+    for _, f := range formats {
+        if d, ok := f.Parse(path); ok {
+            return d, true
+        }
+    }
+    return Date{}, false
+}
+
+// Default returns the formats of a vault without settings: daily notes
+// named YYYY-MM-DD anywhere in the vault, as the Daily notes core plugin
+// creates them.
+func Default() []Format {
+    f, _ := NewFormat(Daily, "", "")
+    return []Format{f}
+}
+
+// settings are the settings of one period in .obsidian.
+type settings struct {
+    Enabled bool   `json:"enabled"`
+    Folder  string `json:"folder"`
+    Format  string `json:"format"`
+}
+
+// pluginKeys maps the periods to their keys in the settings of the Periodic
+// Notes plugin, before and since its calendar sets.
+var pluginKeys = map[string][2]string{
+    Daily:     {"daily", "day"},
+    Weekly:    {"weekly", "week"},
+    Monthly:   {"monthly", "month"},
+    Quarterly: {"quarterly", "quarter"},
+    Yearly:    {"yearly", "year"},
+}
+
+// Load reads the formats of periodic notes from the settings of the vault
+// at root: the Daily notes core plugin and, if it is enabled, the Periodic
+// Notes plugin, whose daily notes take precedence. Daily notes have the
+// default format without settings. Missing settings are skipped;
+// unreadable or invalid ones are reported.
+func Load(root string) ([]Format, error) {
+    obsidian := filepath.Join(root, ".obsidian")
+    periods := make(map[string]settings)
+    
+    var daily settings
+    found, err := readJSON(filepath.Join(obsidian, "daily-notes.json"), &daily)
+    if err != nil {
+        return nil, err
+    }
+    if found {
+        periods[Daily] = daily
+    }
+    
+    var plugins []string
+    if _, err := readJSON(filepath.Join(obsidian, "community-plugins.json"), &plugins); err != nil {
+        return nil, err
+    }
+    if slices.Contains(plugins, "periodic-notes") {
+        if err := readPlugin(filepath.Join(obsidian, "plugins", "periodic-notes", "data.json"), periods); err != nil {
+            return nil, err
+        }
+    }
+    if _, ok := periods[Daily]; !ok {
+        periods[Daily] = settings{}
+    }
+    
+    var formats []Format
+    for _, period := range Periods {
+        s, ok := periods[period]
+        if !ok {
+            continue
+        }
+        f, err := NewFormat(period, s.Folder, s.Format)
+        if err != nil {
+            return nil, fmt.Errorf("%s notes: %w", period, err)
+        }
+        formats = append(formats, f)
+    }
+    return formats, nil
+}
+
+// readPlugin adds the enabled periods in the settings of the Periodic Notes
+// plugin at path to periods. Since version 1.0 they are in the active
+// calendar set.
+func readPlugin(path string, periods map[string]settings) error {
+    var data map[string]json.RawMessage
+    if found, err := readJSON(path, &data); err != nil || !found {
+        return err
+    }
+    
+    key := 0
+    if raw, ok := data["calendarSets"]; ok {
+        var sets []map[string]json.RawMessage
+        var active string
+        if err := json.Unmarshal(raw, &sets); err != nil {
+            return fmt.Errorf("failed to parse %s: %w", path, err)
+        }
+        json.Unmarshal(data["activeCalendarSet"], &active)
+        data, key = nil, 1
+        for _, set := range sets {
+            var id string
+            json.Unmarshal(set["id"], &id)
+            if data == nil || id == active {
+                data = set
+            }
+        }
+    }
+    
+    for _, period := range Periods {
+        raw, ok := data[pluginKeys[period][key]]
+        if !ok {
+            continue
+        }
+        var s settings
+        if err := json.Unmarshal(raw, &s); err != nil {
+            return fmt.Errorf("failed to parse %s: %w", path, err)
+        }
+        if s.Enabled {
+            periods[period] = s
+        }
+    }
+    return nil
+}
+
+// readJSON decodes the file at path into v and reports whether it exists.
+func readJSON(path string, v any) (bool, error) {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    if err := json.Unmarshal(data, v); err != nil {
+        return false, fmt.Errorf("failed to parse %s: %w", path, err)
+    }
+    return true, nil
+}
\ No newline at end of file
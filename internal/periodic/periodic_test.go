@@ -0,0 +1,119 @@
+package periodic
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestParse(t *testing.T) {
+    tests := []struct {
+        period, folder, format string
+        path                   string
+        start, end             string
+    }{
+        {Daily, "", "", "2024-03-05", "2024-03-05", "2024-03-05"},
+        {Daily, "Journal/", "", "Journal/2024/2024-03-05", "2024-03-05", "2024-03-05"},
+        {Daily, "Journal", "YYYY/MM/YYYY-MM-DD", "Journal/2024/03/2024-03-05", "2024-03-05", "2024-03-05"},
+        {Daily, "", "dddd, MMMM Do YYYY", "Tuesday, March 5th 2024", "2024-03-05", "2024-03-05"},
+        {Daily, "", "DD.MM.YY", "05.03.24", "2024-03-05", "2024-03-05"},
+        {Weekly, "", "", "2024-W02", "2024-01-07", "2024-01-13"},
+        {Weekly, "", "gggg-[W]ww", "2024-W01", "2023-12-31", "2024-01-06"},
+        {Weekly, "", "GGGG-[W]WW", "2024-W01", "2024-01-01", "2024-01-07"},
+        {Weekly, "", "GGGG-[W]WW", "2020-W53", "2020-12-28", "2021-01-03"},
+        {Monthly, "", "", "2024-02", "2024-02-01", "2024-02-29"},
+        {Monthly, "", "MMM YYYY", "Feb 2024", "2024-02-01", "2024-02-29"},
+        {Quarterly, "", "", "2024-Q2", "2024-04-01", "2024-06-30"},
+        {Yearly, "Years", "", "Years/2024", "2024-01-01", "2024-12-31"},
+    }
+    for _, tt := range tests {
+        f, err := NewFormat(tt.period, tt.folder, tt.format)
+        if err != nil {
+            t.Fatalf("NewFormat(%q) failed: %v", tt.format, err)
+        }
+        d, ok := f.Parse(tt.path)
+        if !ok {
+            t.Errorf("Expected %q to be a %s note of %q", tt.path, tt.period, f.Format)
+            continue
+        }
+        if got := d.Start.Format("2006-01-02"); got != tt.start {
+            t.Errorf("%q: expected start %s, got %s", tt.path, tt.start, got)
+        }
+        if got := d.End.Format("2006-01-02"); got != tt.end {
+            t.Errorf("%q: expected end %s, got %s", tt.path, tt.end, got)
+        }
+    }
+    
+    // Names that only look like dates
+    daily, _ := NewFormat(Daily, "Journal", "")
+    weekly, _ := NewFormat(Weekly, "", "GGGG-[W]WW")
+    for _, path := range []string{"2024-03-05", "Journal/2024-02-30", "Journal/2024-03-05 notes", "Journal/Plan"} {
+        if d, ok := daily.Parse(path); ok {
+            t.Errorf("Expected %q not to be a daily note, got %+v", path, d)
+        }
+    }
+    if d, ok := weekly.Parse("2021-W53"); ok {
+        t.Errorf("Expected no week 53 in 2021, got %+v", d)
+    }
+    
+    if _, err := NewFormat(Daily, "", "MM-DD"); err == nil {
+        t.Error("Expected an error for a format without a year")
+    }
+}
+
+func TestLoad(t *testing.T) {
+    write := func(root, name, content string) {
+        path := filepath.Join(root, ".obsidian", name)
+        os.MkdirAll(filepath.Dir(path), 0755)
+        os.WriteFile(path, []byte(content), 0644)
+    }
+    periods := func(formats []Format) map[string]string {
+        m := make(map[string]string)
+        for _, f := range formats {
+            m[f.Period] = f.Folder + ":" + f.Format
+        }
+        return m
+    }
+    
+    // Without settings daily notes have the default format
+    formats, err := Load(t.TempDir())
+    if err != nil || len(formats) != 1 || formats[0].Format != "YYYY-MM-DD" {
+        t.Errorf("Expected the default daily format, got %+v, %v", formats, err)
+    }
+    
+    // The core plugin, and Periodic Notes only if it is enabled
+    root := t.TempDir()
+    write(root, "daily-notes.json", `{"folder": "Daily", "format": "YYYY/YYYY-MM-DD"}`)
+    write(root, "plugins/periodic-notes/data.json", `{
+        "daily": {"enabled": false, "format": "DD-MM-YYYY"},
+        "weekly": {"enabled": true, "folder": "Weekly", "format": ""},
+        "monthly": {"enabled": false}
+    }`)
+    formats, err = Load(root)
+    if got := periods(formats); err != nil || len(got) != 1 || got[Daily] != "Daily:YYYY/YYYY-MM-DD" {
+        t.Errorf("Expected the core daily format, got %v, %v", got, err)
+    }
+    write(root, "community-plugins.json", `["calendar", "periodic-notes"]`)
+    formats, err = Load(root)
+    if got := periods(formats); err != nil || len(got) != 2 || got[Daily] != "Daily:YYYY/YYYY-MM-DD" || got[Weekly] != "Weekly:gggg-[W]ww" {
+        t.Errorf("Expected daily and weekly formats, got %v, %v", got, err)
+    }
+    
+    // Calendar sets of Periodic Notes 1.0
+    write(root, "plugins/periodic-notes/data.json", `{
+        "activeCalendarSet": "Work",
+        "calendarSets": [
+            {"id": "Default", "day": {"enabled": true, "format": "YYYYMMDD"}},
+            {"id": "Work", "day": {"enabled": true, "folder": "Work", "format": "DD.MM.YYYY"}, "month": {"enabled": true}}
+        ]
+    }`)
+    formats, err = Load(root)
+    if got := periods(formats); err != nil || len(got) != 2 || got[Daily] != "Work:DD.MM.YYYY" || got[Monthly] != ":YYYY-MM" {
+        t.Errorf("Expected the active calendar set, got %v, %v", got, err)
+    }
+    
+    write(root, "daily-notes.json", `{"folder": `)
+    if _, err := Load(root); err == nil {
+        t.Error("Expected an error for invalid settings")
+    }
+}
\ No newline at end of file
@@ -0,0 +1,93 @@
+package query
+
+import (
+    "strings"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+)
+
+// Matcher finds the words and phrases of a query in a line of text, for
+// choosing and highlighting snippets. Excluded parts of the query are
+// ignored. Words are compared after folding case and diacritics, and match
+// the beginning of a word in the text so that "meeting" finds "meetings".
+type Matcher struct {
+    patterns []pattern
+}
+
+type pattern struct {
+    words []string
+    slop  int
+}
+
+// NewMatcher returns a matcher for the words and phrases that node searches
+// for.
+func NewMatcher(node Node) *Matcher {
+    m := &Matcher{}
+    m.collect(node)
+    return m
+}
+
+func (m *Matcher) collect(node Node) {
+    switch n := node.(type) {
+    case *Term:
+        m.add(analysis.Tokenize(n.Text), 0)
+    case *Phrase:
+        m.add(analysis.Tokenize(strings.Join(n.Words, " ")), n.Slop)
+    case *Bool:
+        for _, c := range n.Clauses {
+            if c.Occur != MustNot {
+                m.collect(c.Node)
+            }
+        }
+    }
+}
+
+func (m *Matcher) add(words []string, slop int) {
+    if len(words) > 0 {
+        m.patterns = append(m.patterns, pattern{words, slop})
+    }
+}
+
+// Match returns the weight of the best match in line: the number of words
+// of the longest phrase found, so a line with a whole phrase outranks one
+// with single words. It returns 0 if nothing matches.
+func (m *Matcher) Match(line string) int {
+    if m == nil {
+        return 0
+    }
+    
+    tokens := analysis.Tokenize(line)
+    best := 0
+    for _, p := range m.patterns {
+        if len(p.words) > best && p.matches(tokens) {
+            best = len(p.words)
+        }
+    }
+    return best
+}
+
+// matches reports whether the words of p occur in tokens in order, with at
+// most p.slop tokens in between altogether.
+func (p pattern) matches(tokens []string) bool {
+    for start, token := range tokens {
+        if !strings.HasPrefix(token, p.words[0]) {
+            continue
+        }
+        if p.matchFrom(tokens, start+1, 1, p.slop) {
+            return true
+        }
+    }
+    return false
+}
+
+func (p pattern) matchFrom(tokens []string, next, word, slop int) bool {
+    if word == len(p.words) {
+        return true
+    }
+    for i := next; i < len(tokens) && i-next <= slop; i++ {
+        if strings.HasPrefix(tokens[i], p.words[word]) && p.matchFrom(tokens, i+1, word+1, slop-(i-next)) {
+            return true
+        }
+    }
+    return false
+}
\ No newline at end of file
@@ -0,0 +1,44 @@
+package query
+
+import "testing"
+
+func TestMatcher(t *testing.T) {
+    node, err := Parse(`"budget review" OR (plan NEAR/2 approved) -draft`)
+    if err != nil {
+        t.Fatalf("Parse failed: %v", err)
+    }
+    m := NewMatcher(node)
+    
+    tests := []struct {
+        line string
+        want int
+    }{
+        {"Budget review on Monday", 2},
+        {"Budget reviews", 2},
+        {"review the budget", 0},
+        {"The plan was finally approved", 2},
+        {"The plan was not yet approved", 0},
+        {"Draft only", 0},
+        {"Plan approved, budget review pending", 2},
+    }
+    for _, tt := range tests {
+        if got := m.Match(tt.line); got != tt.want {
+            t.Errorf("Match(%q) = %d, want %d", tt.line, got, tt.want)
+        }
+    }
+}
+
+func TestMatcherFoldsDiacritics(t *testing.T) {
+    node, err := Parse("Munchen AND Koln")
+    if err != nil {
+        t.Fatalf("Parse failed: %v", err)
+    }
+    m := NewMatcher(node)
+    
+    if got := m.Match("Ankunft in München"); got != 1 {
+        t.Errorf("Match = %d, want 1", got)
+    }
+    if got := m.Match("KÖLN"); got != 1 {
+        t.Errorf("Match = %d, want 1", got)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,567 @@
+// Package query parses the search syntax accepted by search_vault and turns
+// it into Tantivy queries and snippet matchers.
+package query
+
+import (
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+// Syntax describes the query language for users. It is part of the
+// search_vault tool schema and the CLI help.
+const Syntax = `Words match notes containing any of them, best matches first. ` +
+    `"exact phrase" matches the words next to each other in this order. ` +
+    `a NEAR/n b matches a followed by b with at most n words in between. ` +
+    `a AND b requires both, a OR b either one. ` +
+    `+word requires a word, -word or NOT word excludes notes containing it. ` +
+    `Parentheses group, e.g. (budget OR cost) AND NOT draft. ` +
+    `Operators are upper case; NEAR binds tightest, then NOT, +, -, then AND, then OR. ` +
+    `lang:go only finds notes with a code block in that language, callout:decision notes with ` +
+    `a callout of that type; filters are required, several on the same name match any of them, ` +
+    `and -lang:go excludes. To search one folder, such as the decisions in Projects, set the folder option.`
+
+// Filters maps the names of name:value filters to the index fields whose
+// values they match exactly.
+var Filters = map[string]string{
+    "lang":    "code_lang",
+    "callout": "callout_type",
+}
+
+// Limits that keep pathological queries away from the index.
+const (
+    MaxNear  = 100
+    maxDepth = 32
+)
+
+// Occur says how a clause takes part in a match.
+type Occur int
+
+const (
+    Should  Occur = iota // may match, more matching clauses rank higher
+    Must                 // has to match
+    MustNot              // must not match
+)
+
+// Node is a parsed query: a Term, a Phrase, a Field or a Bool.
+type Node interface {
+    node()
+}
+
+// Term is a single word. Words with punctuation, like "e-mail", match as a
+// phrase of their parts. A Boost of 0 means the default weight.
+type Term struct {
+    Text  string
+    Boost float64
+}
+
+// Phrase matches its words in order, with at most Slop words in between
+// in total.
+type Phrase struct {
+    Words []string
+    Slop  int
+}
+
+// Field matches notes whose index field Name has the value Value, in lower
+// case. It is written as a filter, see Filters.
+type Field struct {
+    Name  string
+    Value string
+}
+
+// Clause is one part of a Bool.
+type Clause struct {
+    Occur Occur
+    Node  Node
+}
+
+// Bool combines clauses. At least one clause is not MustNot.
+type Bool struct {
+    Clauses []Clause
+}
+
+func (*Term) node()   {}
+func (*Phrase) node() {}
+func (*Field) node()  {}
+func (*Bool) node()   {}
+
+// Parse parses a query in the syntax described by Syntax. Words without
+// letters or digits are dropped; a query or group that is left without
+// anything to match is an error.
+func Parse(input string) (Node, error) {
+    tokens, err := lex(input)
+    if err != nil {
+        return nil, err
+    }
+    
+    p := &parser{tokens: tokens}
+    node, err := p.parseOr()
+    if err != nil {
+        return nil, err
+    }
+    // parseOr only stops early at a closing parenthesis
+    if tok := p.peek(); tok.kind != tokEOF {
+        return nil, fmt.Errorf("unmatched ')' at position %d", tok.pos)
+    }
+    
+    node = requireFilters(prune(node))
+    if node == nil {
+        return nil, errors.New("query has no words to search for")
+    }
+    if err := validate(node); err != nil {
+        return nil, err
+    }
+    return node, nil
+}
+
+type tokenKind int
+
+const (
+    tokEOF tokenKind = iota
+    tokWord
+    tokPhrase
+    tokLParen
+    tokRParen
+    tokAnd
+    tokOr
+    tokNot
+    tokNear
+    tokPlus
+    tokMinus
+    tokFilter
+)
+
+type token struct {
+    kind tokenKind
+    text string
+    n    int
+    pos  int
+}
+
+func (t token) String() string {
+    switch t.kind {
+    case tokEOF:
+        return "end of query"
+    case tokPhrase:
+        return fmt.Sprintf("%q", t.text)
+    case tokPlus, tokMinus, tokLParen, tokRParen:
+        return fmt.Sprintf("'%s'", t.text)
+    }
+    return t.text
+}
+
+func isBreak(r rune) bool {
+    return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+}
+
+func lex(input string) ([]token, error) {
+    var tokens []token
+    runes := []rune(input)
+    for i := 0; i < len(runes); {
+        r := runes[i]
+        switch {
+        case unicode.IsSpace(r):
+            i++
+        case r == '(':
+            tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+            i++
+        case r == ')':
+            tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+            i++
+        case r == '"':
+            end := i + 1
+            for end < len(runes) && runes[end] != '"' {
+                end++
+            }
+            if end == len(runes) {
+                return nil, fmt.Errorf("unterminated phrase starting at position %d", i)
+            }
+            tokens = append(tokens, token{kind: tokPhrase, text: string(runes[i+1 : end]), pos: i})
+            i = end + 1
+        case (r == '+' || r == '-') && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')':
+            // A sign sticks to the word, phrase or group that follows
+            kind := tokPlus
+            if r == '-' {
+                kind = tokMinus
+            }
+            tokens = append(tokens, token{kind: kind, text: string(r), pos: i})
+            i++
+        default:
+            start := i
+            for i < len(runes) && !isBreak(runes[i]) {
+                i++
+            }
+            tok, err := wordToken(string(runes[start:i]), start)
+            if err != nil {
+                return nil, err
+            }
+            tokens = append(tokens, tok)
+        }
+    }
+    return append(tokens, token{kind: tokEOF, pos: len(runes)}), nil
+}
+
+func wordToken(word string, pos int) (token, error) {
+    switch word {
+    case "AND":
+        return token{kind: tokAnd, text: word, pos: pos}, nil
+    case "OR":
+        return token{kind: tokOr, text: word, pos: pos}, nil
+    case "NOT":
+        return token{kind: tokNot, text: word, pos: pos}, nil
+    case "NEAR":
+        return token{}, fmt.Errorf("NEAR at position %d needs a distance, as in NEAR/3", pos)
+    }
+    
+    if distance, ok := strings.CutPrefix(word, "NEAR/"); ok {
+        n, err := strconv.Atoi(distance)
+        if err != nil || n < 0 || n > MaxNear {
+            return token{}, fmt.Errorf("invalid proximity %q at position %d: use NEAR/n with n from 0 to %d", word, pos, MaxNear)
+        }
+        return token{kind: tokNear, text: word, n: n, pos: pos}, nil
+    }
+    if name, value, ok := strings.Cut(word, ":"); ok && Filters[name] != "" {
+        if value == "" {
+            return token{}, fmt.Errorf("filter %s at position %d needs a value, as in %s:value", word, pos, name)
+        }
+        return token{kind: tokFilter, text: word, pos: pos}, nil
+    }
+    return token{kind: tokWord, text: word, pos: pos}, nil
+}
+
+type parser struct {
+    tokens []token
+    next   int
+    depth  int
+}
+
+func (p *parser) peek() token {
+    return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+    tok := p.tokens[p.next]
+    if tok.kind != tokEOF {
+        p.next++
+    }
+    return tok
+}
+
+// startsOperand reports whether tok can begin an operand of AND and OR.
+func startsOperand(tok token) bool {
+    switch tok.kind {
+    case tokWord, tokPhrase, tokFilter, tokLParen, tokNot, tokPlus, tokMinus:
+        return true
+    }
+    return false
+}
+
+// parseOr parses a list of clauses. Clauses next to each other are joined
+// by OR, written or not.
+func (p *parser) parseOr() (Node, error) {
+    var clauses []Clause
+    for {
+        tok := p.peek()
+        if tok.kind == tokEOF || tok.kind == tokRParen {
+            break
+        }
+        if tok.kind == tokOr {
+            if len(clauses) == 0 {
+                return nil, fmt.Errorf("OR at position %d needs a term on both sides", tok.pos)
+            }
+            p.advance()
+            if !startsOperand(p.peek()) {
+                return nil, fmt.Errorf("OR at position %d needs a term on both sides", tok.pos)
+            }
+            continue
+        }
+        
+        clause, err := p.parseAnd()
+        if err != nil {
+            return nil, err
+        }
+        clauses = append(clauses, clause)
+    }
+    
+    switch {
+    case len(clauses) == 0 && p.depth > 0:
+        return nil, errors.New("empty parentheses")
+    case len(clauses) == 0:
+        return nil, errors.New("empty query")
+    case len(clauses) == 1 && clauses[0].Occur == Should:
+        return clauses[0].Node, nil
+    }
+    return &Bool{Clauses: clauses}, nil
+}
+
+// parseAnd parses clauses joined by AND into a single clause.
+func (p *parser) parseAnd() (Clause, error) {
+    first, err := p.parseUnary()
+    if err != nil || p.peek().kind != tokAnd {
+        return first, err
+    }
+    
+    clauses := []Clause{required(first)}
+    for p.peek().kind == tokAnd {
+        and := p.advance()
+        if !startsOperand(p.peek()) {
+            return Clause{}, fmt.Errorf("AND at position %d needs a term on both sides", and.pos)
+        }
+        clause, err := p.parseUnary()
+        if err != nil {
+            return Clause{}, err
+        }
+        clauses = append(clauses, required(clause))
+    }
+    return Clause{Occur: Should, Node: &Bool{Clauses: clauses}}, nil
+}
+
+func required(c Clause) Clause {
+    if c.Occur == Should {
+        c.Occur = Must
+    }
+    return c
+}
+
+// parseUnary parses NOT, + and - in front of an operand.
+func (p *parser) parseUnary() (Clause, error) {
+    tok := p.peek()
+    switch tok.kind {
+    case tokNot, tokPlus, tokMinus:
+        p.advance()
+        if !startsOperand(p.peek()) {
+            return Clause{}, fmt.Errorf("%s at position %d must be followed by a term", tok, tok.pos)
+        }
+        clause, err := p.parseUnary()
+        if err != nil {
+            return Clause{}, err
+        }
+        if clause.Occur != Should {
+            return Clause{}, fmt.Errorf("%s at position %d is followed by another operator", tok, tok.pos)
+        }
+        clause.Occur = MustNot
+        if tok.kind == tokPlus {
+            clause.Occur = Must
+        }
+        return clause, nil
+    }
+    
+    node, err := p.parseNear()
+    return Clause{Occur: Should, Node: node}, err
+}
+
+// parseNear parses words and phrases joined by NEAR/n into one phrase whose
+// slop is the sum of the distances.
+func (p *parser) parseNear() (Node, error) {
+    node, err := p.parseAtom()
+    if err != nil || p.peek().kind != tokNear {
+        return node, err
+    }
+    
+    near := &Phrase{}
+    if err := near.join(node, 0); err != nil {
+        return nil, err
+    }
+    for p.peek().kind == tokNear {
+        op := p.advance()
+        next, err := p.parseAtom()
+        if err != nil {
+            return nil, err
+        }
+        if err := near.join(next, op.n); err != nil {
+            return nil, fmt.Errorf("%s at position %d: %w", op.text, op.pos, err)
+        }
+    }
+    return near, nil
+}
+
+func (ph *Phrase) join(node Node, distance int) error {
+    switch n := node.(type) {
+    case *Term:
+        ph.Words = append(ph.Words, n.Text)
+    case *Phrase:
+        ph.Words = append(ph.Words, n.Words...)
+        ph.Slop += n.Slop
+    default:
+        return errors.New("NEAR joins words and phrases, not groups")
+    }
+    ph.Slop += distance
+    return nil
+}
+
+func (p *parser) parseAtom() (Node, error) {
+    tok := p.advance()
+    switch tok.kind {
+    case tokWord:
+        return &Term{Text: tok.text}, nil
+    case tokFilter:
+        name, value, _ := strings.Cut(tok.text, ":")
+        return &Field{Name: Filters[name], Value: strings.ToLower(value)}, nil
+    case tokPhrase:
+        words := strings.Fields(tok.text)
+        if len(words) == 0 {
+            return nil, fmt.Errorf("empty phrase at position %d", tok.pos)
+        }
+        return &Phrase{Words: words}, nil
+    case tokLParen:
+        if p.depth++; p.depth > maxDepth {
+            return nil, fmt.Errorf("parentheses nested deeper than %d levels", maxDepth)
+        }
+        node, err := p.parseOr()
+        if err != nil {
+            return nil, err
+        }
+        if p.peek().kind != tokRParen {
+            return nil, fmt.Errorf("missing ')' for '(' at position %d", tok.pos)
+        }
+        p.advance()
+        p.depth--
+        return node, nil
+    case tokRParen:
+        return nil, fmt.Errorf("unmatched ')' at position %d", tok.pos)
+    case tokEOF:
+        return nil, errors.New("query ends where a term is expected")
+    }
+    return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+}
+
+// searchable reports whether text has anything the index can match.
+func searchable(text string) bool {
+    return strings.IndexFunc(text, func(r rune) bool {
+        return unicode.IsLetter(r) || unicode.IsDigit(r)
+    }) >= 0
+}
+
+// prune removes words without letters or digits, and groups that become
+// empty. It returns nil if nothing is left.
+func prune(node Node) Node {
+    switch n := node.(type) {
+    case *Term:
+        if !searchable(n.Text) {
+            return nil
+        }
+    case *Phrase:
+        var words []string
+        for _, word := range n.Words {
+            if searchable(word) {
+                words = append(words, word)
+            }
+        }
+        if len(words) == 0 {
+            return nil
+        }
+        n.Words = words
+    case *Bool:
+        var clauses []Clause
+        for _, c := range n.Clauses {
+            if c.Node = prune(c.Node); c.Node != nil {
+                clauses = append(clauses, c)
+            }
+        }
+        if len(clauses) == 0 {
+            return nil
+        }
+        n.Clauses = clauses
+    }
+    return node
+}
+
+// requireFilters makes the filters among the clauses of a query required,
+// so "lang:go timeout" finds notes with Go code about timeouts. Filters on
+// the same field match any of their values. The words of a query without
+// required words stay required as a group: one of them has to match.
+func requireFilters(node Node) Node {
+    b, ok := node.(*Bool)
+    if !ok {
+        return node
+    }
+    
+    groups := make(map[string]*Bool)
+    var filters, others, should, rest []Clause
+    hasMust := false
+    for _, c := range b.Clauses {
+        f, ok := c.Node.(*Field)
+        switch {
+        case ok && c.Occur == Should:
+            group := groups[f.Name]
+            if group == nil {
+                group = &Bool{}
+                groups[f.Name] = group
+                filters = append(filters, Clause{Occur: Must, Node: group})
+            }
+            group.Clauses = append(group.Clauses, c)
+        case c.Occur == Should:
+            should = append(should, c)
+            others = append(others, c)
+        default:
+            hasMust = hasMust || c.Occur == Must
+            rest = append(rest, c)
+            others = append(others, c)
+        }
+    }
+    if len(filters) == 0 {
+        return b
+    }
+    
+    for i, c := range filters {
+        if group := c.Node.(*Bool); len(group.Clauses) == 1 {
+            filters[i].Node = group.Clauses[0].Node
+        }
+    }
+    // With a required word the other words are optional already
+    if !hasMust && len(should) == 1 {
+        others = append([]Clause{required(should[0])}, rest...)
+    } else if !hasMust && len(should) > 1 {
+        others = append([]Clause{{Occur: Must, Node: &Bool{Clauses: should}}}, rest...)
+    }
+    b.Clauses = append(others, filters...)
+    return b
+}
+
+// FilterValues returns the values that node requires or allows for the
+// filter name, such as "decision" for callout:decision. Excluded values are
+// left out.
+func FilterValues(node Node, name string) []string {
+    var values []string
+    var collect func(node Node)
+    collect = func(node Node) {
+        switch n := node.(type) {
+        case *Field:
+            if n.Name == Filters[name] {
+                values = append(values, n.Value)
+            }
+        case *Bool:
+            for _, c := range n.Clauses {
+                if c.Occur != MustNot {
+                    collect(c.Node)
+                }
+            }
+        }
+    }
+    collect(node)
+    return values
+}
+
+// validate rejects groups that only exclude: they match nothing.
+func validate(node Node) error {
+    b, ok := node.(*Bool)
+    if !ok {
+        return nil
+    }
+    
+    positive := false
+    for _, c := range b.Clauses {
+        if c.Occur != MustNot {
+            positive = true
+        }
+        if err := validate(c.Node); err != nil {
+            return err
+        }
+    }
+    if !positive {
+        return errors.New("a query or group cannot only exclude terms, add a term to search for")
+    }
+    return nil
+}
\ No newline at end of file
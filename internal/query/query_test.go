@@ -0,0 +1,119 @@
+package query
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestParseRendersTantivySyntax(t *testing.T) {
+    tests := []struct {
+        input string
+        want  string
+    }{
+        {"meeting notes", "meeting notes"},
+        {`"meeting notes"`, `"meeting notes"`},
+        {"budget NEAR/3 review", `"budget review"~3`},
+        {`"quarterly budget" NEAR/2 review NEAR/1 draft`, `"quarterly budget review draft"~3`},
+        {"budget AND review", "+budget +review"},
+        {"budget OR review", "budget review"},
+        {"budget AND NOT draft", "+budget -draft"},
+        {"+budget -draft plan", "+budget -draft plan"},
+        {"(budget OR cost) AND NOT draft", "+(budget cost) -draft"},
+        {"a AND b OR c AND d", "(+a +b) (+c +d)"},
+        {"-(draft OR archive) report", "-(draft archive) report"},
+        {"e-mail c++", `"e-mail" "c++"`},
+        {"budget - review", "budget review"},
+        {"Café AND München", "+Café +München"},
+        {"lang:go", `code_lang:"go"`},
+        {"lang:Go context.WithTimeout", `+"context.WithTimeout" +code_lang:"go"`},
+        {"timeout retry lang:go lang:rust", `+(timeout retry) +(code_lang:"go" code_lang:"rust")`},
+        {"+deploy review lang:go", `+deploy review +code_lang:"go"`},
+        {"deploy -lang:go", `deploy -code_lang:"go"`},
+        {"-draft lang:go", `-draft +code_lang:"go"`},
+        {"author:me", `"author:me"`},
+        {"callout:Decision lang:go", `+callout_type:"decision" +code_lang:"go"`},
+    }
+    
+    for _, tt := range tests {
+        node, err := Parse(tt.input)
+        if err != nil {
+            t.Errorf("Parse(%q) failed: %v", tt.input, err)
+            continue
+        }
+        if got := Tantivy(node); got != tt.want {
+            t.Errorf("Tantivy(Parse(%q)) = %q, want %q", tt.input, got, tt.want)
+        }
+    }
+}
+
+func TestParseErrors(t *testing.T) {
+    tests := []struct {
+        input string
+        want  string
+    }{
+        {"", "empty query"},
+        {"  ", "empty query"},
+        {"&& ??", "no words"},
+        {`"unclosed phrase`, "unterminated phrase"},
+        {`""`, "empty phrase"},
+        {"(budget", "missing ')'"},
+        {"budget)", "unmatched ')'"},
+        {"()", "empty parentheses"},
+        {"budget AND", "AND at position 7"},
+        {"OR budget", "OR at position 0"},
+        {"budget OR", "OR at position 7"},
+        {"NOT", "must be followed by a term"},
+        {"NOT -budget", "followed by another operator"},
+        {"budget NEAR review", "needs a distance"},
+        {"budget NEAR/x review", "invalid proximity"},
+        {"budget NEAR/500 review", "invalid proximity"},
+        {"(a OR b) NEAR/2 c", "not groups"},
+        {"-draft", "only exclude"},
+        {"-lang:go", "only exclude"},
+        {"budget lang:", "needs a value"},
+        {"report (NOT draft)", "only exclude"},
+        {strings.Repeat("(", 40) + "a" + strings.Repeat(")", 40), "nested deeper"},
+    }
+    
+    for _, tt := range tests {
+        _, err := Parse(tt.input)
+        if err == nil {
+            t.Errorf("Parse(%q) succeeded, want an error containing %q", tt.input, tt.want)
+            continue
+        }
+        if !strings.Contains(err.Error(), tt.want) {
+            t.Errorf("Parse(%q) error = %q, want it to contain %q", tt.input, err, tt.want)
+        }
+    }
+}
+
+func TestOperatorsAreCaseSensitive(t *testing.T) {
+    node, err := Parse("salt and pepper or near")
+    if err != nil {
+        t.Fatalf("Parse failed: %v", err)
+    }
+    if got := Tantivy(node); got != "salt and pepper or near" {
+        t.Errorf("Expected lower case operators to be words, got %q", got)
+    }
+}
+
+func TestTantivyBoost(t *testing.T) {
+    node := &Bool{Clauses: []Clause{
+        {Occur: Should, Node: &Term{Text: "meeting"}},
+        {Occur: Should, Node: &Term{Text: "meetings", Boost: 0.5}},
+        {Occur: Should, Node: &Term{Text: "e-mail", Boost: 0.25}},
+    }}
+    if got := Tantivy(node); got != `meeting meetings^0.5 "e-mail"^0.25` {
+        t.Errorf("Tantivy = %q", got)
+    }
+}
+
+func TestFilterValues(t *testing.T) {
+    node, err := Parse("callout:decision OR callout:todo -callout:draft lang:go postgres")
+    if err != nil {
+        t.Fatalf("Parse failed: %v", err)
+    }
+    if got := FilterValues(node, "callout"); len(got) != 2 || got[0] != "decision" || got[1] != "todo" {
+        t.Errorf("FilterValues() = %q, want decision and todo", got)
+    }
+}
\ No newline at end of file
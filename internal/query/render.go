@@ -0,0 +1,71 @@
+package query
+
+import (
+    "fmt"
+    "strings"
+    "unicode"
+)
+
+// Tantivy renders node in the syntax of Tantivy's query parser. Only plain
+// words are written bare; everything else is quoted so that punctuation
+// never reaches the parser as syntax.
+func Tantivy(node Node) string {
+    return render(node, false)
+}
+
+func render(node Node, nested bool) string {
+    switch n := node.(type) {
+    case *Term:
+        text := n.Text
+        if !isPlain(text) {
+            text = quote(strings.Fields(text))
+        }
+        if n.Boost != 0 && n.Boost != 1 {
+            text += fmt.Sprintf("^%g", n.Boost)
+        }
+        return text
+    case *Field:
+        return n.Name + ":" + quote([]string{n.Value})
+    case *Phrase:
+        text := quote(n.Words)
+        if n.Slop > 0 {
+            text += fmt.Sprintf("~%d", n.Slop)
+        }
+        return text
+    case *Bool:
+        parts := make([]string, 0, len(n.Clauses))
+        for _, c := range n.Clauses {
+            prefix := ""
+            switch c.Occur {
+            case Must:
+                prefix = "+"
+            case MustNot:
+                prefix = "-"
+            }
+            parts = append(parts, prefix+render(c.Node, true))
+        }
+        text := strings.Join(parts, " ")
+        if nested {
+            text = "(" + text + ")"
+        }
+        return text
+    }
+    return ""
+}
+
+func quote(words []string) string {
+    return `"` + strings.Join(words, " ") + `"`
+}
+
+// isPlain reports whether word consists of letters and digits only.
+func isPlain(word string) bool {
+    if word == "" {
+        return false
+    }
+    for _, r := range word {
+        if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+            return false
+        }
+    }
+    return true
+}
\ No newline at end of file
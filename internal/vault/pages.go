@@ -0,0 +1,117 @@
+package vault
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "sync"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/index"
+)
+
+// Page selects part of the results of a search: Limit results from Offset
+// on, or the page after the one that returned Cursor.
+type Page struct {
+    Offset int
+    Limit  int
+    Cursor string
+}
+
+// Ranked searches are kept for a while so that the pages of a search come
+// from one ranking even if the index changes in between.
+const (
+    maxSnapshots = 32
+    snapshotTTL  = 15 * time.Minute
+)
+
+// snapshot is the merged ranking of one search over its vaults.
+type snapshot struct {
+    id      string
+    created time.Time
+    hits    []vaultHit
+    // capped is set if a vault had index.MaxHits matches and may have more
+    capped   bool
+    failures map[string]error
+}
+
+type vaultHit struct {
+    vault *Vault
+    hit   index.Hit
+}
+
+// pageCache holds the most recent snapshots. The zero value is ready to use.
+type pageCache struct {
+    mu        sync.Mutex
+    snapshots map[string]*snapshot
+    order     []string
+}
+
+// put stores s under a new id and drops the oldest snapshot if the cache
+// is full.
+func (c *pageCache) put(s *snapshot) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.snapshots == nil {
+        c.snapshots = make(map[string]*snapshot)
+    }
+    
+    id := make([]byte, 8)
+    rand.Read(id)
+    s.id = hex.EncodeToString(id)
+    s.created = time.Now()
+    
+    c.snapshots[s.id] = s
+    c.order = append(c.order, s.id)
+    if len(c.order) > maxSnapshots {
+        delete(c.snapshots, c.order[0])
+        c.order = c.order[1:]
+    }
+}
+
+// get returns the snapshot with id, or nil if it expired or was dropped.
+func (c *pageCache) get(id string) *snapshot {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    s := c.snapshots[id]
+    if s == nil || time.Since(s.created) > snapshotTTL {
+        return nil
+    }
+    return s
+}
+
+// cursor is the continuation token of a page. It names the snapshot, the
+// offset of the next page and the search it belongs to, so a cursor can be
+// followed by running the search again once its snapshot is gone.
+type cursor struct {
+    Snapshot string `json:"s"`
+    Offset   int    `json:"o"`
+    Search   string `json:"q"`
+}
+
+func (c cursor) encode() string {
+    data, _ := json.Marshal(c)
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (cursor, error) {
+    var c cursor
+    data, err := base64.RawURLEncoding.DecodeString(token)
+    if err == nil {
+        err = json.Unmarshal(data, &c)
+    }
+    if err != nil || c.Offset < 0 {
+        return cursor{}, errors.New("invalid cursor")
+    }
+    return c, nil
+}
+
+// searchKey identifies a search by its vault, query and options.
+func searchKey(name, q string, opts index.SearchOptions) string {
+    data, _ := json.Marshal(opts)
+    sum := sha256.Sum256([]byte(name + "\x00" + q + "\x00" + string(data)))
+    return hex.EncodeToString(sum[:8])
+}
\ No newline at end of file
@@ -0,0 +1,763 @@
+package vault
+
+import (
+    "errors"
+    "fmt"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/analysis"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/config"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/embed"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/extract"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/ignore"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/index"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/periodic"
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/query"
+)
+
+// Vault is one named vault with its own index and watcher. A vault that
+// fails to open or index records the error instead of stopping the server.
+type Vault struct {
+    Name      string
+    Path      string
+    IndexPath string
+    Index     index.SearchIndex
+    
+    mu          sync.Mutex
+    err         error
+    lastIndexed time.Time
+    watcher     index.Watcher
+}
+
+// Stats summarizes the state of a vault for status reporting.
+type Stats struct {
+    Name         string    `json:"name"`
+    Path         string    `json:"path"`
+    IndexPath    string    `json:"index_path"`
+    IndexedFiles int       `json:"indexed_files"`
+    LastIndexed  time.Time `json:"last_indexed"`
+    Error        string    `json:"error,omitempty"`
+}
+
+func (v *Vault) setErr(err error) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    v.err = err
+}
+
+// Err returns the last opening or indexing failure.
+func (v *Vault) Err() error {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    return v.err
+}
+
+// Available reports whether the vault's index could be opened.
+func (v *Vault) Available() bool {
+    return v.Index != nil
+}
+
+func (v *Vault) Stats() Stats {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    
+    stats := Stats{
+        Name:        v.Name,
+        Path:        v.Path,
+        IndexPath:   v.IndexPath,
+        LastIndexed: v.lastIndexed,
+    }
+    if v.Index != nil {
+        stats.IndexedFiles = v.Index.GetIndexedFilesCount()
+    }
+    if v.err != nil {
+        stats.Error = v.err.Error()
+    }
+    return stats
+}
+
+// Manager owns all configured vaults.
+type Manager struct {
+    vaults    []*Vault
+    workers   int
+    cfg       *config.Config
+    pages     pageCache
+    closeOnce sync.Once
+}
+
+// Open opens the index of every configured vault. Vaults that fail to open
+// are kept with their error so they show up in status reports.
+func Open(cfg *config.Config) *Manager {
+    m := &Manager{
+        workers: cfg.MaxWorkers,
+        cfg:     cfg,
+    }
+    
+    embedder, err := Embedder(cfg)
+    if err != nil {
+        slog.Warn("Semantic search disabled", "err", err)
+    }
+    
+    for _, vc := range cfg.VaultList() {
+        v := &Vault{
+            Name:      vc.Name,
+            Path:      vc.Path,
+            IndexPath: vc.IndexPath,
+        }
+        m.vaults = append(m.vaults, v)
+        
+        idx, err := index.Open(vc.IndexPath, IndexOptions(cfg))
+        if err != nil {
+            v.setErr(fmt.Errorf("failed to open index: %w", err))
+            slog.Error("Vault unavailable", "vault", v.Name, "err", v.Err())
+            continue
+        }
+        
+        patterns := append(append([]string{}, cfg.IgnorePatterns...), vc.Ignore...)
+        matcher, err := ignore.Load(vc.Path, patterns)
+        if err != nil {
+            idx.Close()
+            v.setErr(fmt.Errorf("failed to load ignore rules: %w", err))
+            slog.Error("Vault unavailable", "vault", v.Name, "err", v.Err())
+            continue
+        }
+        idx.SetIgnore(matcher)
+        
+        // Periodic notes only date notes, so broken settings are no reason
+        // to give up the vault
+        formats, err := periodic.Load(vc.Path)
+        if err != nil {
+            slog.Warn("Failed to load periodic notes settings, using the defaults", "vault", v.Name, "err", err)
+            formats = periodic.Default()
+        }
+        idx.SetPeriodic(formats)
+        if embedder != nil {
+            idx.SetEmbedder(embedder, cfg.Embeddings.ChunkSize)
+        }
+        
+        v.Index = idx
+    }
+    
+    return m
+}
+
+// IndexOptions maps the search and attachment settings of cfg onto index
+// options.
+func IndexOptions(cfg *config.Config) index.Options {
+    opts := index.DefaultOptions()
+    opts.SnippetLength = cfg.Search.SnippetLength
+    opts.Languages = cfg.Search.Languages
+    opts.Backend = cfg.Search.Backend
+    opts.ResolveEmbeds = cfg.Search.ResolveEmbeds
+    for field, boost := range cfg.Search.Boosts {
+        opts.FieldBoosts[field] = float32(boost)
+    }
+    opts.Recency = index.RecencyOptions{
+        Weight:   cfg.Search.Recency.Weight,
+        HalfLife: time.Duration(cfg.Search.Recency.HalfLife),
+        Date:     cfg.Search.Recency.Date,
+    }
+    opts.Attachments = nil
+    if len(cfg.Attachments.Extensions) > 0 {
+        opts.Attachments = extract.NewRegistry(extract.Limits{
+            MaxSize: int64(cfg.Attachments.MaxSizeMB) << 20,
+            Timeout: time.Duration(cfg.Attachments.Timeout),
+            MaxText: extract.DefaultLimits.MaxText,
+        })
+        for _, ext := range cfg.Attachments.Extensions {
+            if e, ok := extract.Builtin(ext); ok {
+                opts.Attachments.Register(ext, e)
+            }
+        }
+    }
+    return opts
+}
+
+// Embedder returns the embedder configured for semantic search, or nil if
+// semantic search is off.
+func Embedder(cfg *config.Config) (embed.Embedder, error) {
+    if !cfg.Embeddings.Enabled() {
+        return nil, nil
+    }
+    return embed.New(embed.Config{
+        Provider:  cfg.Embeddings.Provider,
+        URL:       cfg.Embeddings.URL,
+        Model:     cfg.Embeddings.Model,
+        APIKey:    cfg.Embeddings.APIKey,
+        BatchSize: cfg.Embeddings.BatchSize,
+        Timeout:   time.Duration(cfg.Embeddings.Timeout),
+    })
+}
+
+// Vaults returns all vaults in configuration order.
+func (m *Manager) Vaults() []*Vault {
+    if m == nil {
+        return nil
+    }
+    return m.vaults
+}
+
+// Select returns the vault with the given name, or all vaults if name is empty.
+func (m *Manager) Select(name string) ([]*Vault, error) {
+    if name == "" {
+        return m.Vaults(), nil
+    }
+    
+    var names []string
+    for _, v := range m.Vaults() {
+        if v.Name == name {
+            return []*Vault{v}, nil
+        }
+        names = append(names, v.Name)
+    }
+    return nil, fmt.Errorf("unknown vault %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// IndexAll brings every available vault up to date, in parallel.
+func (m *Manager) IndexAll() {
+    var wg sync.WaitGroup
+    for _, v := range m.Vaults() {
+        if !v.Available() {
+            continue
+        }
+        wg.Add(1)
+        go func(v *Vault) {
+            defer wg.Done()
+            m.index(v, false)
+        }(v)
+    }
+    wg.Wait()
+}
+
+// Index brings the given vault's index up to date.
+func (m *Manager) Index(v *Vault) error {
+    if !v.Available() {
+        return v.Err()
+    }
+    return m.index(v, false)
+}
+
+// Reindex forces a full rebuild of the given vault's index.
+func (m *Manager) Reindex(v *Vault) error {
+    if !v.Available() {
+        return v.Err()
+    }
+    return m.index(v, true)
+}
+
+func (m *Manager) index(v *Vault, rebuild bool) (err error) {
+    // A broken vault must not take the others down with it
+    defer func() {
+        if r := recover(); r != nil {
+            err = fmt.Errorf("indexing panicked: %v", r)
+        }
+        v.mu.Lock()
+        v.err = err
+        if err == nil {
+            v.lastIndexed = time.Now()
+        }
+        v.mu.Unlock()
+        if err != nil {
+            slog.Error("Indexing failed", "vault", v.Name, "err", err)
+        }
+    }()
+    
+    slog.Info("Indexing vault", "vault", v.Name, "path", v.Path)
+    if rebuild {
+        return v.Index.Rebuild(v.Path, m.workers)
+    }
+    return v.Index.IndexDirectory(v.Path, m.workers)
+}
+
+// StartWatchers starts a watcher for every available vault.
+func (m *Manager) StartWatchers() {
+    for _, v := range m.Vaults() {
+        if !v.Available() {
+            continue
+        }
+        watcher, err := v.Index.Watch(v.Path, m.cfg.WatchMode, time.Duration(m.cfg.PollInterval))
+        if err != nil {
+            slog.Error("Failed to start file watcher", "vault", v.Name, "err", err)
+            continue
+        }
+        v.mu.Lock()
+        v.watcher = watcher
+        v.mu.Unlock()
+        go watcher.Start()
+    }
+}
+
+// Close stops all watchers and closes all indexes.
+func (m *Manager) Close() {
+    m.closeOnce.Do(func() {
+        for _, v := range m.vaults {
+            v.mu.Lock()
+            if v.watcher != nil {
+                v.watcher.Stop()
+                v.watcher = nil
+            }
+            v.mu.Unlock()
+            if v.Index != nil {
+                v.Index.Close()
+            }
+        }
+    })
+}
+
+// SearchResults are one page of the merged hits of a multi-vault search
+// together with the vaults that could not be searched. Searches without
+// results carry a corrected query in Suggestion when one of the vaults
+// knows a spelling.
+type SearchResults struct {
+    Results    []index.SearchResult
+    Failures   map[string]error
+    Suggestion string
+    
+    // Offset is the position of the first result in the whole ranking and
+    // Total the number of matches. Total is a lower bound if TotalCapped
+    // is set, because a vault had more than index.MaxHits matches.
+    Offset      int
+    Total       int
+    TotalCapped bool
+    // NextCursor continues with the next page, it is empty on the last.
+    NextCursor string
+    // Recomputed is set if the snapshot of the cursor's search expired and
+    // the search was run again, so the page may repeat or skip results if
+    // the vault changed since the previous page.
+    Recomputed bool
+}
+
+// Search queries the named vault, or all vaults if name is empty, and
+// interleaves the per-vault rankings into a single list tagged by vault.
+// The ranking is kept for a while, so the cursor of a page continues in the
+// same ranking even if notes change before the next page is fetched.
+func (m *Manager) Search(name, q string, page Page, opts index.SearchOptions) (*SearchResults, error) {
+    vaults, err := m.Select(name)
+    if err != nil {
+        return nil, err
+    }
+    
+    // Syntax errors are the caller's, not a failure of every vault
+    if _, err := query.Parse(analysis.Normalize(q)); err != nil {
+        return nil, fmt.Errorf("invalid query: %w", err)
+    }
+    
+    key := searchKey(name, q, opts)
+    offset := page.Offset
+    var snap *snapshot
+    recomputed := false
+    if page.Cursor != "" {
+        c, err := decodeCursor(page.Cursor)
+        if err != nil {
+            return nil, err
+        }
+        if c.Search != key {
+            return nil, fmt.Errorf("cursor belongs to a different search")
+        }
+        offset = c.Offset
+        snap = m.pages.get(c.Snapshot)
+        recomputed = snap == nil
+    }
+    if snap == nil {
+        snap = rank(vaults, q, opts)
+        m.pages.put(snap)
+    }
+    
+    out := &SearchResults{
+        Failures:    snap.failures,
+        Offset:      offset,
+        Total:       len(snap.hits),
+        TotalCapped: snap.capped,
+        Recomputed:  recomputed,
+    }
+    
+    end := min(offset+page.Limit, len(snap.hits))
+    if offset < end {
+        out.Results = results(q, opts, snap.hits[offset:end])
+    }
+    if end < len(snap.hits) {
+        out.NextCursor = cursor{Snapshot: snap.id, Offset: end, Search: key}.encode()
+    }
+    
+    if len(snap.hits) == 0 {
+        for _, v := range vaults {
+            if !v.Available() {
+                continue
+            }
+            if out.Suggestion = v.Index.Suggest(q); out.Suggestion != "" {
+                break
+            }
+        }
+    }
+    return out, nil
+}
+
+// rank runs a search on every vault and merges the rankings.
+func rank(vaults []*Vault, q string, opts index.SearchOptions) *snapshot {
+    snap := &snapshot{failures: make(map[string]error)}
+    var perVault [][]vaultHit
+    total := 0
+    for _, v := range vaults {
+        if !v.Available() {
+            snap.failures[v.Name] = v.Err()
+            continue
+        }
+        
+        hits, err := v.Index.Rank(q, opts)
+        if err != nil {
+            snap.failures[v.Name] = err
+            continue
+        }
+        if len(hits) >= index.MaxHits {
+            snap.capped = true
+        }
+        tagged := make([]vaultHit, len(hits))
+        for i, hit := range hits {
+            tagged[i] = vaultHit{vault: v, hit: hit}
+        }
+        perVault = append(perVault, tagged)
+        total += len(hits)
+    }
+    snap.hits = interleave(perVault, total)
+    return snap
+}
+
+// results makes the snippets of a page of hits, one call per vault.
+func results(q string, opts index.SearchOptions, hits []vaultHit) []index.SearchResult {
+    positions := make(map[*Vault][]int)
+    var order []*Vault
+    for i, h := range hits {
+        if positions[h.vault] == nil {
+            order = append(order, h.vault)
+        }
+        positions[h.vault] = append(positions[h.vault], i)
+    }
+    
+    out := make([]index.SearchResult, len(hits))
+    for _, v := range order {
+        page := make([]index.Hit, len(positions[v]))
+        for j, i := range positions[v] {
+            page[j] = hits[i].hit
+        }
+        for j, result := range v.Index.Results(q, opts, page) {
+            result.Vault = v.Name
+            out[positions[v][j]] = result
+        }
+    }
+    return out
+}
+
+// interleave merges ranked lists by taking the next best hit of each list in
+// turn, so that no vault dominates the first page.
+func interleave[T any](lists [][]T, limit int) []T {
+    merged := make([]T, 0, limit)
+    for rank := 0; len(merged) < limit; rank++ {
+        added := false
+        for _, list := range lists {
+            if rank < len(list) && len(merged) < limit {
+                merged = append(merged, list[rank])
+                added = true
+            }
+        }
+        if !added {
+            break
+        }
+    }
+    return merged
+}
+
+// Completions are the merged name completions of a multi-vault lookup
+// together with the vaults that could not be searched.
+type Completions struct {
+    Completions []index.Completion
+    Failures    map[string]error
+}
+
+// Complete looks up notes whose names start with prefix in the named vault,
+// or all vaults if name is empty, interleaving the per-vault rankings.
+func (m *Manager) Complete(name, prefix string, limit int) (*Completions, error) {
+    vaults, err := m.Select(name)
+    if err != nil {
+        return nil, err
+    }
+    
+    out := &Completions{Failures: make(map[string]error)}
+    var perVault [][]index.Completion
+    for _, v := range vaults {
+        if !v.Available() {
+            out.Failures[v.Name] = v.Err()
+            continue
+        }
+        
+        completions, err := v.Index.Complete(prefix, limit)
+        if err != nil {
+            out.Failures[v.Name] = err
+            continue
+        }
+        for i := range completions {
+            completions[i].Vault = v.Name
+        }
+        perVault = append(perVault, completions)
+    }
+    
+    out.Completions = interleave(perVault, limit)
+    return out, nil
+}
+
+// TaskResults are the tasks matching a query in one or all vaults, merged
+// in due date order, together with the vaults that could not be searched.
+// Total counts all matching tasks, Tasks holds the first limit.
+type TaskResults struct {
+    Tasks    []index.TaskMatch
+    Total    int
+    Failures map[string]error
+}
+
+// Tasks returns up to limit tasks matching q from the named vault, or all
+// vaults if name is empty.
+func (m *Manager) Tasks(name string, q index.TaskQuery, limit int) (*TaskResults, error) {
+    vaults, err := m.Select(name)
+    if err != nil {
+        return nil, err
+    }
+    
+    out := &TaskResults{Failures: make(map[string]error)}
+    for _, v := range vaults {
+        if !v.Available() {
+            out.Failures[v.Name] = v.Err()
+            continue
+        }
+        for _, task := range v.Index.Tasks(q) {
+            task.Vault = v.Name
+            out.Tasks = append(out.Tasks, task)
+        }
+    }
+    
+    index.SortTasks(out.Tasks)
+    out.Total = len(out.Tasks)
+    if len(out.Tasks) > limit {
+        out.Tasks = out.Tasks[:limit]
+    }
+    return out, nil
+}
+
+// PropertyResults are the notes matching a property query in one or all
+// vaults, merged in the order of the query, together with the vaults that
+// could not be searched. Total counts all matching notes, Notes holds the
+// first limit.
+type PropertyResults struct {
+    Notes    []index.PropertyMatch
+    Total    int
+    Failures map[string]error
+}
+
+// Properties returns up to limit notes matching q from the named vault, or
+// all vaults if name is empty.
+func (m *Manager) Properties(name string, q index.PropertyQuery, limit int) (*PropertyResults, error) {
+    vaults, err := m.Select(name)
+    if err != nil {
+        return nil, err
+    }
+    
+    out := &PropertyResults{Failures: make(map[string]error)}
+    for _, v := range vaults {
+        if !v.Available() {
+            out.Failures[v.Name] = v.Err()
+            continue
+        }
+        for _, note := range v.Index.Properties(q) {
+            note.Vault = v.Name
+            out.Notes = append(out.Notes, note)
+        }
+    }
+    
+    index.SortProperties(out.Notes, q.Sort, q.Desc)
+    out.Total = len(out.Notes)
+    if len(out.Notes) > limit {
+        out.Notes = out.Notes[:limit]
+    }
+    return out, nil
+}
+
+// PeriodicResults are the periodic notes matching a query in one or all
+// vaults, merged in date order, together with the vaults that could not be
+// searched. Total counts all matching notes, Notes holds the first limit.
+type PeriodicResults struct {
+    Notes    []index.PeriodicNote
+    Total    int
+    Failures map[string]error
+}
+
+// PeriodicNotes returns up to limit periodic notes matching q from the
+// named vault, or all vaults if name is empty.
+func (m *Manager) PeriodicNotes(name string, q index.PeriodicQuery, limit int) (*PeriodicResults, error) {
+    vaults, err := m.Select(name)
+    if err != nil {
+        return nil, err
+    }
+    
+    out := &PeriodicResults{Failures: make(map[string]error)}
+    for _, v := range vaults {
+        if !v.Available() {
+            out.Failures[v.Name] = v.Err()
+            continue
+        }
+        for _, note := range v.Index.PeriodicNotes(q) {
+            note.Vault = v.Name
+            out.Notes = append(out.Notes, note)
+        }
+    }
+    
+    index.SortPeriodic(out.Notes)
+    out.Total = len(out.Notes)
+    if len(out.Notes) > limit {
+        out.Notes = out.Notes[:limit]
+    }
+    return out, nil
+}
+
+// ReadNote returns the vault containing the note at the vault-relative path
+// and the note's content. The ".md" extension may be left out, a canvas is
+// named with its ".canvas" extension, and a note may also be named by its
+// file name or an alias. Without a vault name the vaults are tried in
+// order.
+func (m *Manager) ReadNote(name, path string) (*Vault, string, error) {
+    vaults, err := m.Select(name)
+    if err != nil {
+        return nil, "", err
+    }
+    
+    for _, v := range vaults {
+        file, err := v.noteFile(path)
+        if err != nil {
+            return nil, "", err
+        }
+        if content, err := os.ReadFile(file); err == nil {
+            return v, string(content), nil
+        }
+    }
+    return nil, "", fmt.Errorf("note %q not found", path)
+}
+
+// Similar returns up to limit notes like the one at the vault-relative
+// path, or with that name or alias, from the vault containing it. Without a
+// vault name the vaults are tried in order.
+func (m *Manager) Similar(name, path string, limit int) ([]index.SimilarNote, error) {
+    vaults, err := m.Select(name)
+    if err != nil {
+        return nil, err
+    }
+    
+    for _, v := range vaults {
+        file, err := v.noteFile(path)
+        if err != nil {
+            return nil, err
+        }
+        if _, err := os.Stat(file); err != nil {
+            continue
+        }
+        if !v.Available() {
+            return nil, fmt.Errorf("vault %s is unavailable: %v", v.Name, v.Err())
+        }
+        
+        notes, err := v.Index.Similar(file, limit)
+        if err != nil {
+            return nil, err
+        }
+        for i := range notes {
+            notes[i].Vault = v.Name
+        }
+        return notes, nil
+    }
+    return nil, fmt.Errorf("note %q not found", path)
+}
+
+// Resolve returns the text that the wiki link ref points to, from the
+// first vault it resolves in. from is the vault-relative path of the note
+// containing the link, for relative links and links within that note; only
+// the vaults that have it are tried. If the link resolves nowhere, the error
+// comes from the first vault that has the note it points to.
+func (m *Manager) Resolve(name, ref, from string) (*index.Reference, error) {
+    vaults, err := m.Select(name)
+    if err != nil {
+        return nil, err
+    }
+    
+    var firstErr error
+    for _, v := range vaults {
+        if from != "" {
+            file, err := v.notePath(from)
+            if err != nil {
+                return nil, err
+            }
+            if _, err := os.Stat(file); err != nil {
+                continue
+            }
+        }
+        if !v.Available() {
+            if firstErr == nil {
+                firstErr = fmt.Errorf("vault %s is unavailable: %v", v.Name, v.Err())
+            }
+            continue
+        }
+        
+        r, err := v.Index.Resolve(ref, from)
+        if err == nil {
+            r.Vault = v.Name
+            return r, nil
+        }
+        if firstErr == nil || errors.Is(firstErr, index.ErrNoNote) && !errors.Is(err, index.ErrNoNote) {
+            firstErr = err
+        }
+    }
+    if firstErr == nil {
+        firstErr = fmt.Errorf("note %q not found", from)
+    }
+    return nil, firstErr
+}
+
+// notePath resolves a vault-relative note path to a file in the vault,
+// rejecting paths that lead outside of it.
+func (v *Vault) notePath(path string) (string, error) {
+    rel := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(path, "/")))
+    if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+        return "", fmt.Errorf("invalid note path %q", path)
+    }
+    if ext := strings.ToLower(filepath.Ext(rel)); ext != ".md" && ext != ".canvas" {
+        rel += ".md"
+    }
+    return filepath.Join(v.Path, rel), nil
+}
+
+// noteFile returns the file of the note named path in the vault: the note
+// at the vault-relative path if there is one, or else the note a link to
+// path points to, found by file name or alias.
+func (v *Vault) noteFile(path string) (string, error) {
+    file, err := v.notePath(path)
+    if err != nil {
+        return "", err
+    }
+    if _, err := os.Stat(file); err == nil || !v.Available() {
+        return file, nil
+    }
+    if found := v.Index.Lookup(path); found != "" {
+        return found, nil
+    }
+    return file, nil
+}
+
+// FailedVaults returns the names of vaults in failures, sorted.
+func FailedVaults(failures map[string]error) []string {
+    names := make([]string, 0, len(failures))
+    for name := range failures {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
\ No newline at end of file
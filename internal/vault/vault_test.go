@@ -0,0 +1,258 @@
+package vault
+
+import (
+    "errors"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    
+    "github.com/Atomzwieback/obsidian-search-mcp/internal/index"
+)
+
+func TestSelect(t *testing.T) {
+    m := &Manager{vaults: []*Vault{{Name: "work"}, {Name: "personal"}}}
+    
+    all, err := m.Select("")
+    if err != nil || len(all) != 2 {
+        t.Fatalf("Expected all vaults, got %v (%v)", all, err)
+    }
+    
+    one, err := m.Select("personal")
+    if err != nil || len(one) != 1 || one[0].Name != "personal" {
+        t.Fatalf("Expected the personal vault, got %v (%v)", one, err)
+    }
+    
+    if _, err := m.Select("archive"); err == nil {
+        t.Error("Expected an error for an unknown vault")
+    }
+}
+
+func TestSearchSkipsFailedVaults(t *testing.T) {
+    broken := &Vault{Name: "broken"}
+    broken.setErr(errors.New("failed to open index"))
+    m := &Manager{vaults: []*Vault{broken}}
+    
+    results, err := m.Search("", "golang", Page{Limit: 10}, index.SearchOptions{})
+    if err != nil {
+        t.Fatalf("Search failed: %v", err)
+    }
+    if len(results.Results) != 0 {
+        t.Errorf("Expected no results, got %v", results.Results)
+    }
+    if results.Failures["broken"] == nil {
+        t.Error("Expected the broken vault to be reported as a failure")
+    }
+}
+
+func TestInterleave(t *testing.T) {
+    work := []index.SearchResult{{FilePath: "w1"}, {FilePath: "w2"}, {FilePath: "w3"}}
+    personal := []index.SearchResult{{FilePath: "p1"}}
+    
+    merged := interleave([][]index.SearchResult{work, personal}, 3)
+    
+    var paths []string
+    for _, r := range merged {
+        paths = append(paths, r.FilePath)
+    }
+    want := []string{"w1", "p1", "w2"}
+    if len(paths) != len(want) {
+        t.Fatalf("Expected %v, got %v", want, paths)
+    }
+    for i := range want {
+        if paths[i] != want[i] {
+            t.Errorf("Expected %v, got %v", want, paths)
+            break
+        }
+    }
+}
+
+func TestReadNote(t *testing.T) {
+    dir := t.TempDir()
+    if err := os.MkdirAll(filepath.Join(dir, "Projects"), 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(filepath.Join(dir, "Projects", "Roadmap.md"), []byte("# Roadmap"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    m := &Manager{vaults: []*Vault{{Name: "work", Path: dir}}}
+    
+    for _, path := range []string{"Projects/Roadmap.md", "Projects/Roadmap", "/Projects/Roadmap"} {
+        v, content, err := m.ReadNote("", path)
+        if err != nil {
+            t.Errorf("ReadNote(%q) failed: %v", path, err)
+            continue
+        }
+        if v.Name != "work" || content != "# Roadmap" {
+            t.Errorf("ReadNote(%q) = %s, %q", path, v.Name, content)
+        }
+    }
+    
+    for _, path := range []string{"../secret", "Projects/../../secret.md", "Missing"} {
+        if _, _, err := m.ReadNote("", path); err == nil {
+            t.Errorf("Expected ReadNote(%q) to fail", path)
+        }
+    }
+}
+
+func TestReadNoteByAlias(t *testing.T) {
+    v := bm25Vault(t, "work", map[string]string{
+        "Kubernetes Cluster Notes.md": "---\naliases: [K8s]\n---\n# Cluster",
+    })
+    m := &Manager{vaults: []*Vault{v}}
+    
+    for _, path := range []string{"K8s", "k8s", "kubernetes cluster notes"} {
+        if _, content, err := m.ReadNote("", path); err != nil || !strings.Contains(content, "# Cluster") {
+            t.Errorf("ReadNote(%q) = %q, %v", path, content, err)
+        }
+    }
+    if _, _, err := m.ReadNote("", "Kubernetes"); err == nil {
+        t.Error("Expected a name that is no alias to fail")
+    }
+}
+
+func TestSearchRejectsInvalidQuery(t *testing.T) {
+    broken := &Vault{Name: "broken"}
+    m := &Manager{vaults: []*Vault{broken}}
+    
+    _, err := m.Search("", `"unclosed phrase`, Page{Limit: 10}, index.SearchOptions{})
+    if err == nil || !strings.Contains(err.Error(), "invalid query") {
+        t.Errorf("Expected an invalid query error, got %v", err)
+    }
+}
+
+func TestSimilarFindsNoteVault(t *testing.T) {
+    dir := t.TempDir()
+    if err := os.WriteFile(filepath.Join(dir, "Roadmap.md"), []byte("# Roadmap"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    m := &Manager{vaults: []*Vault{{Name: "personal", Path: t.TempDir()}, {Name: "work", Path: dir}}}
+    
+    if _, err := m.Similar("", "Missing", 10); err == nil || !strings.Contains(err.Error(), "not found") {
+        t.Errorf("Expected a missing note error, got %v", err)
+    }
+    if _, err := m.Similar("", "Roadmap", 10); err == nil || !strings.Contains(err.Error(), "work is unavailable") {
+        t.Errorf("Expected the unavailable vault holding the note to be named, got %v", err)
+    }
+}
+
+// bm25Vault indexes notes in a new vault with the pure-Go backend, which
+// runs without the Tantivy library.
+func bm25Vault(t *testing.T, name string, notes map[string]string) *Vault {
+    t.Helper()
+    dir := t.TempDir()
+    for file, content := range notes {
+        if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    opts := index.DefaultOptions()
+    opts.Backend = index.BackendBM25
+    idx, err := index.Open(t.TempDir(), opts)
+    if err != nil {
+        t.Fatalf("Failed to open index: %v", err)
+    }
+    t.Cleanup(func() { idx.Close() })
+    if err := idx.IndexDirectory(dir, 1); err != nil {
+        t.Fatalf("Failed to index vault: %v", err)
+    }
+    return &Vault{Name: name, Path: dir, Index: idx}
+}
+
+func TestSearchPages(t *testing.T) {
+    work := bm25Vault(t, "work", map[string]string{
+        "a.md": "garden", "b.md": "garden plan", "c.md": "garden beds", "d.md": "garden tools", "e.md": "garden",
+    })
+    personal := bm25Vault(t, "personal", map[string]string{
+        "f.md": "garden", "g.md": "garden party", "h.md": "garden",
+    })
+    m := &Manager{vaults: []*Vault{work, personal}}
+    
+    seen := make(map[string]bool)
+    page := Page{Limit: 3}
+    for pages := 0; ; pages++ {
+        out, err := m.Search("", "garden", page, index.SearchOptions{})
+        if err != nil {
+            t.Fatalf("Search failed: %v", err)
+        }
+        if out.Total != 8 || out.TotalCapped || out.Recomputed {
+            t.Fatalf("Expected 8 exact matches, got %+v", out)
+        }
+        if out.Offset != pages*3 {
+            t.Errorf("Expected offset %d, got %d", pages*3, out.Offset)
+        }
+        for _, r := range out.Results {
+            key := r.Vault + "/" + filepath.Base(r.FilePath)
+            if seen[key] {
+                t.Errorf("%s was returned twice", key)
+            }
+            seen[key] = true
+        }
+        if out.NextCursor == "" {
+            break
+        }
+        page.Cursor = out.NextCursor
+    }
+    if len(seen) != 8 {
+        t.Errorf("Expected all 8 notes over the pages, got %v", seen)
+    }
+    
+    first, _ := m.Search("", "garden", Page{Limit: 3}, index.SearchOptions{})
+    
+    // Notes added after the first page don't shift the next pages
+    file := filepath.Join(work.Path, "z.md")
+    os.WriteFile(file, []byte("garden garden garden"), 0644)
+    work.Index.UpdateFile(file)
+    next, err := m.Search("", "garden", Page{Limit: 10, Cursor: first.NextCursor}, index.SearchOptions{})
+    if err != nil {
+        t.Fatalf("Search failed: %v", err)
+    }
+    if next.Total != 8 || len(next.Results) != 5 || next.NextCursor != "" {
+        t.Errorf("Expected the rest of the first ranking, got %+v", next)
+    }
+    
+    // Without the snapshot the search runs again and says so
+    m.pages.snapshots = nil
+    next, err = m.Search("", "garden", Page{Limit: 10, Cursor: first.NextCursor}, index.SearchOptions{})
+    if err != nil {
+        t.Fatalf("Search failed: %v", err)
+    }
+    if !next.Recomputed || next.Total != 9 || next.Offset != 3 {
+        t.Errorf("Expected a recomputed page, got %+v", next)
+    }
+    
+    if _, err := m.Search("", "party", Page{Limit: 3, Cursor: first.NextCursor}, index.SearchOptions{}); err == nil {
+        t.Error("Expected an error for the cursor of another search")
+    }
+    if _, err := m.Search("", "garden", Page{Limit: 3, Cursor: "not a cursor"}, index.SearchOptions{}); err == nil {
+        t.Error("Expected an error for an invalid cursor")
+    }
+    
+    out, _ := m.Search("", "garden", Page{Offset: 7, Limit: 3}, index.SearchOptions{})
+    if len(out.Results) != 2 || out.Offset != 7 || out.NextCursor != "" {
+        t.Errorf("Expected the last two results, got %+v", out)
+    }
+}
+func TestResolveAcrossVaults(t *testing.T) {
+    work := bm25Vault(t, "work", map[string]string{
+        "Plan.md": "# Plan\n## Risks\nWork risks\n",
+    })
+    personal := bm25Vault(t, "personal", map[string]string{
+        "Trip.md": "# Trip\nPack light ^packing\n## Risks\nRain\n",
+    })
+    m := &Manager{vaults: []*Vault{work, personal}}
+    
+    r, err := m.Resolve("", "[[Trip#^packing]]", "")
+    if err != nil || r.Vault != "personal" || r.Text != "Pack light" {
+        t.Errorf("Expected the block from the personal vault, got %+v, %v", r, err)
+    }
+    if r, err := m.Resolve("", "[[#Risks]]", "Trip"); err != nil || r.Vault != "personal" || r.Text != "## Risks\nRain" {
+        t.Errorf("Expected the heading in the note named by from, got %+v, %v", r, err)
+    }
+    if _, err := m.Resolve("", "[[Trip#Budget]]", ""); err == nil || !strings.Contains(err.Error(), "no heading") {
+        t.Errorf("Expected the error of the vault with the note, got %v", err)
+    }
+    if _, err := m.Resolve("", "[[#Risks]]", "Missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+        t.Errorf("Expected a missing note error, got %v", err)
+    }
+}
\ No newline at end of file